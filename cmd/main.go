@@ -7,6 +7,8 @@ import (
 	"opscore/internal/log"
 	"os"
 	"opscore/internal/api"
+	"opscore/internal/service/datamigrate"
+	"opscore/internal/service/kubernetes"
 
 
 	"github.com/gin-gonic/gin"
@@ -32,7 +34,7 @@ func main() {
 	logger.Info("Starting OpsCore application")
 
 	// 加载配置
-	_, err = config.SetupConfig()
+	_, err = config.InitConfig()
 	if err != nil {
 		logger.Fatal("Failed to load config", zap.Error(err))
 	}
@@ -59,6 +61,23 @@ func main() {
 		os.Exit(0)
 	}
 
+	// 加密历史遗留的明文 kubeconfig 和数据源密码，并将旧密钥加密的数据迁移到当前主密钥
+	if _, err := kubernetes.ReencryptClusterCredentials(); err != nil {
+		logger.Warn("Failed to encrypt cluster credentials", zap.Error(err))
+	}
+	if _, err := datamigrate.ReencryptCredentials(); err != nil {
+		logger.Warn("Failed to encrypt datasource credentials", zap.Error(err))
+	}
+
+	// 进程重启会中断正在执行的节点排空，将遗留的 running 任务标记为失败
+	if _, err := kubernetes.FailOrphanedDrainTasks(); err != nil {
+		logger.Warn("Failed to finalize orphaned drain tasks", zap.Error(err))
+	}
+
+	// 启动集群健康检查
+	monitor := kubernetes.StartHealthMonitor()
+	defer monitor.Stop()
+
 	// 设置Gin模式
 	gin.SetMode(gin.ReleaseMode)
 
@@ -14,11 +14,62 @@ var (
 )
 
 type Config struct {
-	VMware VMwareConfig `mapstructure:"vmware"`
-	Kubernetes Kubernetes `mapstructure:"kubernetes"`
+	VMware      VMwareConfig `mapstructure:"vmware"`
+	Kubernetes  Kubernetes   `mapstructure:"kubernetes"`
+	Security    Security     `mapstructure:"security"`
+	DataMigrate DataMigrate  `mapstructure:"datamigrate"`
+}
+
+// DataMigrate 数据迁移模块配置
+type DataMigrate struct {
+	PoolMaxOpenConns   int `mapstructure:"poolMaxOpenConns"`   // 每个缓存连接的最大打开连接数
+	PoolMaxIdleConns   int `mapstructure:"poolMaxIdleConns"`   // 每个缓存连接的最大空闲连接数
+	PoolIdleTimeoutSec int `mapstructure:"poolIdleTimeoutSec"` // 缓存连接空闲多久后被回收（秒）
+	PoolMaxEntries     int `mapstructure:"poolMaxEntries"`     // 最多缓存的数据源数量
+}
+
+// Security 安全相关配置
+type Security struct {
+	// EncryptionKey 用于加密数据源密码等敏感信息的主密钥，可被环境变量 OPSCORE_ENCRYPTION_KEY 覆盖
+	EncryptionKey string `mapstructure:"encryptionKey"`
+	// PreviousEncryptionKeys 轮换前的旧主密钥，仅用于解密，可被环境变量 OPSCORE_PREVIOUS_ENCRYPTION_KEYS 覆盖
+	PreviousEncryptionKeys []string `mapstructure:"previousEncryptionKeys"`
+	// TrustedProxies 允许透传 X-Opscore-User / X-Forwarded-User 用户头的网关地址（CIDR 或 IP），
+	// 其他来源的用户头会被忽略，审计记录中的操作人记为 anonymous
+	TrustedProxies []string `mapstructure:"trustedProxies"`
+	// ProxySecret 网关通过 X-Opscore-Proxy-Secret 请求头携带的共享密钥，匹配时同样信任用户头，为空时不启用
+	ProxySecret string `mapstructure:"proxySecret"`
 }
 type Kubernetes struct {
 	PackageImagesDir string `mapstructure:"packageImagesDir"`
+	HealthCheck      HealthCheck `mapstructure:"healthCheck"`
+	ClientCache      ClientCache `mapstructure:"clientCache"`
+	Terminal         Terminal    `mapstructure:"terminal"`
+}
+
+// Terminal Pod 终端配置
+type Terminal struct {
+	IdleTimeoutSec int      `mapstructure:"idleTimeoutSec"` // 无输入多久后断开会话（秒）
+	RecordingDir   string   `mapstructure:"recordingDir"`   // asciicast 录像保存目录
+	AllowedOrigins []string `mapstructure:"allowedOrigins"` // 允许发起 WebSocket 握手的 Origin，为空时拒绝所有浏览器连接
+}
+
+// ClientCache 集群客户端缓存配置
+type ClientCache struct {
+	IdleTimeoutSec         int  `mapstructure:"idleTimeoutSec"`         // 客户端空闲多久后回收（秒）
+	Informers              bool `mapstructure:"informers"`              // 是否为热点读路径启用共享 informer
+	InformerResyncSec      int  `mapstructure:"informerResyncSec"`      // informer 全量重新同步间隔（秒）
+	InformerSyncTimeoutSec int  `mapstructure:"informerSyncTimeoutSec"` // 等待 informer 首次同步的超时（秒）
+}
+
+// HealthCheck 集群健康检查配置
+type HealthCheck struct {
+	Enabled              bool   `mapstructure:"enabled"`
+	IntervalSec          int    `mapstructure:"intervalSec"`          // 检查间隔（秒）
+	TimeoutSec           int    `mapstructure:"timeoutSec"`           // 单个集群检查超时（秒）
+	CertExpiryWarnDays   int    `mapstructure:"certExpiryWarnDays"`   // 凭证过期前多少天告警
+	HistoryRetentionDays int    `mapstructure:"historyRetentionDays"` // 状态历史保留天数
+	AlertWebhook         string `mapstructure:"alertWebhook"`         // 告警 Webhook 地址，为空时只记录日志
 }
 
 type VMwareConfig struct {
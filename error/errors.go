@@ -29,4 +29,40 @@ var (
 
 	// ErrInvalidConfig 无效配置
 	ErrInvalidConfig = errors.New("invalid configuration")
+
+	// ErrConnectionNotFound 数据源连接不存在
+	ErrConnectionNotFound = errors.New("datasource connection not found")
+
+	// ErrConnectionNameExists 数据源连接名称已存在
+	ErrConnectionNameExists = errors.New("datasource connection name already exists")
+
+	// ErrNoPrimaryKey 表没有主键
+	ErrNoPrimaryKey = errors.New("table has no primary key")
+
+	// ErrCompareNotSupported 数据源不支持深度对比
+	ErrCompareNotSupported = errors.New("data source does not support deep compare")
+
+	// ErrClusterNotFound Kubernetes 集群不存在
+	ErrClusterNotFound = errors.New("kubernetes cluster not found")
+
+	// ErrInvalidKubeConfig kubeconfig 无效或无法连接集群
+	ErrInvalidKubeConfig = errors.New("invalid kubeconfig")
+
+	// ErrResourceTypeNotFound 集群中不存在该资源类型
+	ErrResourceTypeNotFound = errors.New("resource type not found")
+
+	// ErrResourceScope 命名空间级资源缺少命名空间，或对集群级资源指定了命名空间
+	ErrResourceScope = errors.New("resource scope mismatch")
+
+	// ErrInvalidResource 提交的资源对象无效
+	ErrInvalidResource = errors.New("invalid resource object")
+
+	// ErrTerminalSessionNotFound 终端会话不存在
+	ErrTerminalSessionNotFound = errors.New("terminal session not found")
+
+	// ErrRevisionNotFound 工作负载的历史版本不存在
+	ErrRevisionNotFound = errors.New("workload revision not found")
+
+	// ErrDrainTaskNotFound 节点排空任务不存在
+	ErrDrainTaskNotFound = errors.New("node drain task not found")
 )
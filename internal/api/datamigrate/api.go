@@ -1,6 +1,7 @@
 package datamigrate
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -159,9 +160,12 @@ func (h *APIHandler) ListTasksHandler(c *gin.Context) {
 		end = total
 	}
 
+	// 响应中的数据源密码统一脱敏
 	var pageTasks []*model.MigrationTask
 	if start < total {
-		pageTasks = tasks[start:end]
+		for _, task := range tasks[start:end] {
+			pageTasks = append(pageTasks, datamigrate.MaskTask(task))
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -216,6 +220,16 @@ func (h *APIHandler) TestConnectionHandler(c *gin.Context) {
 		return
 	}
 
+	// 解析已保存连接
+	config, err := h.service.ResolveDataSourceConfig(config)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1,
+			"msg":  "Invalid data source config: " + err.Error(),
+		})
+		return
+	}
+
 	// 创建数据源
 	ds, err := h.service.Factory.NewDataSource(model.DataSourceType(config.Type))
 	if err != nil {
@@ -262,25 +276,26 @@ func (h *APIHandler) ListDatabasesHandler(c *gin.Context) {
 		return
 	}
 
-	// 创建数据源
-	ds, err := h.service.Factory.NewDataSource(model.DataSourceType(config.Type))
+	// 解析已保存连接
+	config, err := h.service.ResolveDataSourceConfig(config)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code": 1,
-			"msg":  "Unsupported data source type: " + config.Type,
+			"msg":  "Invalid data source config: " + err.Error(),
 		})
 		return
 	}
-	defer ds.Close()
 
-	// 连接数据源
-	if err := ds.Connect(config); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+	// 从连接管理器获取复用的数据源
+	ds, release, err := h.service.Connections.Acquire(config)
+	if err != nil {
+		c.JSON(acquireErrorStatus(err), gin.H{
 			"code": 1,
 			"msg":  "Failed to connect: " + err.Error(),
 		})
 		return
 	}
+	defer release()
 
 	// 列出数据库
 	databases, err := ds.ListDatabases()
@@ -315,8 +330,8 @@ func (h *APIHandler) ListTablesHandler(c *gin.Context) {
 		return
 	}
 
-	// 新增日志
-	h.logger.Info("ListTablesHandler 参数", zap.Any("req", req), zap.String("type", string(req.Type)))
+	// 新增日志，不记录连接密码
+	h.logger.Info("ListTablesHandler 参数", zap.String("database", req.Database), zap.String("type", string(req.Type)), zap.String("connection_id", req.ConnectionID))
 
 	if req.Database == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -326,25 +341,27 @@ func (h *APIHandler) ListTablesHandler(c *gin.Context) {
 		return
 	}
 
-	// 创建数据源
-	ds, err := h.service.Factory.NewDataSource(model.DataSourceType(req.Type))
+	// 解析已保存连接
+	cfg, err := h.service.ResolveDataSourceConfig(req.DataSourceConfig)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code": 1,
-			"msg":  "Unsupported data source type: " + req.Type,
+			"msg":  "Invalid data source config: " + err.Error(),
 		})
 		return
 	}
-	defer ds.Close()
+	req.DataSourceConfig = cfg
 
-	// 连接数据源
-	if err := ds.Connect(req.DataSourceConfig); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+	// 从连接管理器获取复用的数据源
+	ds, release, err := h.service.Connections.Acquire(req.DataSourceConfig)
+	if err != nil {
+		c.JSON(acquireErrorStatus(err), gin.H{
 			"code": 1,
 			"msg":  "Failed to connect: " + err.Error(),
 		})
 		return
 	}
+	defer release()
 
 	// 列出表
 	tables, err := ds.ListTables(req.Database)
@@ -375,41 +392,47 @@ func (h *APIHandler) CompareHandler(c *gin.Context) {
 		return
 	}
 
-	// 连接源库
-	srcDS, err := h.service.Factory.NewDataSource(model.DataSourceType(req.SourceConfig.Type))
+	// 解析已保存连接
+	srcCfg, err := h.service.ResolveDataSourceConfig(req.SourceConfig)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"code": 1,
-			"msg":  "Unsupported source data source type: " + req.SourceConfig.Type,
+			"msg":  "Invalid source config: " + err.Error(),
 		})
 		return
 	}
-	defer srcDS.Close()
-	if err := srcDS.Connect(req.SourceConfig); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+	req.SourceConfig = srcCfg
+	tgtCfg, err := h.service.ResolveDataSourceConfig(req.TargetConfig)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
 			"code": 1,
-			"msg":  "源库连接失败: " + err.Error(),
+			"msg":  "Invalid target config: " + err.Error(),
 		})
 		return
 	}
+	req.TargetConfig = tgtCfg
 
-	// 连接目标库
-	tgtDS, err := h.service.Factory.NewDataSource(model.DataSourceType(req.TargetConfig.Type))
+	// 连接源库
+	srcDS, releaseSrc, err := h.service.Connections.Acquire(req.SourceConfig)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
+		c.JSON(acquireErrorStatus(err), gin.H{
 			"code": 1,
-			"msg":  "Unsupported target data source type: " + req.TargetConfig.Type,
+			"msg":  "源库连接失败: " + err.Error(),
 		})
 		return
 	}
-	defer tgtDS.Close()
-	if err := tgtDS.Connect(req.TargetConfig); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+	defer releaseSrc()
+
+	// 连接目标库
+	tgtDS, releaseTgt, err := h.service.Connections.Acquire(req.TargetConfig)
+	if err != nil {
+		c.JSON(acquireErrorStatus(err), gin.H{
 			"code": 1,
 			"msg":  "目标库连接失败: " + err.Error(),
 		})
 		return
 	}
+	defer releaseTgt()
 
 	// 获取目标库所有表
 	tgtTables, err := tgtDS.ListTables(req.Database)
@@ -478,13 +501,31 @@ func (h *APIHandler) CompareHandler(c *gin.Context) {
 				continue
 			}
 		}
-		results = append(results, datamigrate.TableCompareResult{
+		result := datamigrate.TableCompareResult{
 			Table:          table,
 			ExistsInSource: srcExists,
 			ExistsInTarget: tgtExists,
 			RowCountSource: srcCount,
 			RowCountTarget: tgtCount,
-		})
+		}
+		// 深度对比：两端都存在的表按主键抽样对比字段
+		if req.Mode == datamigrate.CompareModeDeep && srcExists && tgtExists {
+			deep, err := h.service.DeepCompareTable(srcDS, tgtDS, datamigrate.DeepCompareOptions{
+				SourceDB:   req.Database,
+				TargetDB:   req.Database,
+				Table:      tbl,
+				SampleSize: req.SampleSize,
+				MaxDiffs:   req.MaxDiffs,
+				SourceRows: srcCount,
+				TargetRows: tgtCount,
+			})
+			if err != nil {
+				h.logger.Warn("Deep compare failed", zap.String("table", tbl), zap.Error(err))
+				deep = &datamigrate.DeepCompareResult{Error: err.Error()}
+			}
+			result.Deep = deep
+		}
+		results = append(results, result)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -499,15 +540,33 @@ func (h *APIHandler) CompareHandler(c *gin.Context) {
 	})
 }
 
+// PoolStatsHandler 返回数据源连接管理器的统计信息
+func (h *APIHandler) PoolStatsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "success",
+		"data": h.service.Connections.Stats(),
+	})
+}
+
+// acquireErrorStatus 不支持的数据源类型返回400，其余连接错误返回500
+func acquireErrorStatus(err error) int {
+	if errors.Is(err, coreError.ErrUnsupportedDataSource) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 // validateCreateRequest 验证创建请求
 func (h *APIHandler) validateCreateRequest(req *datamigrate.CreateMigrationRequest) error {
-	if req.SourceConfig.Type == "" {
+	if req.SourceConfig.Type == "" && req.SourceConnectionID == "" && req.SourceConfig.ConnectionID == "" {
 		return coreError.ErrInvalidConfig
 	}
-	if req.TargetConfig.Type == "" {
+	if req.TargetConfig.Type == "" && req.TargetConnectionID == "" && req.TargetConfig.ConnectionID == "" {
 		return coreError.ErrInvalidConfig
 	}
-	if req.Database == "" {
+	// 至少需要指定源库或表选择规则之一
+	if req.Database == "" && len(req.Databases) == 0 && len(req.Tables) == 0 {
 		return coreError.ErrInvalidConfig
 	}
 	if req.BatchSize <= 0 {
@@ -0,0 +1,192 @@
+package datamigrate
+
+import (
+	"errors"
+	"net/http"
+
+	coreError "opscore/error"
+	"opscore/internal/model"
+	"opscore/internal/service/datamigrate"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CreateConnectionHandler 保存数据源连接
+func (h *APIHandler) CreateConnectionHandler(c *gin.Context) {
+	var req datamigrate.ConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1,
+			"msg":  "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	conn, err := h.service.CreateConnection(&req)
+	if err != nil {
+		h.logger.Error("Failed to create connection", zap.String("name", req.Name), zap.Error(err))
+		c.JSON(connectionErrorStatus(err), gin.H{
+			"code": 1,
+			"msg":  "Failed to create connection: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "success",
+		"data": datamigrate.ToConnectionResponse(conn),
+	})
+}
+
+// ListConnectionsHandler 列出所有数据源连接
+func (h *APIHandler) ListConnectionsHandler(c *gin.Context) {
+	conns, err := h.service.ListConnections()
+	if err != nil {
+		h.logger.Error("Failed to list connections", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"code": 1,
+			"msg":  "Failed to list connections: " + err.Error(),
+		})
+		return
+	}
+
+	resp := make([]datamigrate.ConnectionResponse, len(conns))
+	for i := range conns {
+		resp[i] = datamigrate.ToConnectionResponse(&conns[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "success",
+		"data": resp,
+	})
+}
+
+// GetConnectionHandler 获取单个数据源连接
+func (h *APIHandler) GetConnectionHandler(c *gin.Context) {
+	connectionID := c.Param("connectionId")
+	conn, err := h.service.GetConnection(connectionID)
+	if err != nil {
+		h.logger.Error("Failed to get connection", zap.String("connection_id", connectionID), zap.Error(err))
+		c.JSON(connectionErrorStatus(err), gin.H{
+			"code": 1,
+			"msg":  "Failed to get connection: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "success",
+		"data": datamigrate.ToConnectionResponse(conn),
+	})
+}
+
+// UpdateConnectionHandler 更新数据源连接
+func (h *APIHandler) UpdateConnectionHandler(c *gin.Context) {
+	connectionID := c.Param("connectionId")
+	var req datamigrate.ConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1,
+			"msg":  "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	conn, err := h.service.UpdateConnection(connectionID, &req)
+	if err != nil {
+		h.logger.Error("Failed to update connection", zap.String("connection_id", connectionID), zap.Error(err))
+		c.JSON(connectionErrorStatus(err), gin.H{
+			"code": 1,
+			"msg":  "Failed to update connection: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "success",
+		"data": datamigrate.ToConnectionResponse(conn),
+	})
+}
+
+// DeleteConnectionHandler 删除数据源连接
+func (h *APIHandler) DeleteConnectionHandler(c *gin.Context) {
+	connectionID := c.Param("connectionId")
+	if err := h.service.DeleteConnection(connectionID); err != nil {
+		h.logger.Error("Failed to delete connection", zap.String("connection_id", connectionID), zap.Error(err))
+		c.JSON(connectionErrorStatus(err), gin.H{
+			"code": 1,
+			"msg":  "Failed to delete connection: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "Connection deleted successfully",
+	})
+}
+
+// TestSavedConnectionHandler 测试已保存的数据源连接
+func (h *APIHandler) TestSavedConnectionHandler(c *gin.Context) {
+	connectionID := c.Param("connectionId")
+	config, err := h.service.ResolveDataSourceConfig(datamigrate.DataSourceConfig{ConnectionID: connectionID})
+	if err != nil {
+		c.JSON(connectionErrorStatus(err), gin.H{
+			"code": 1,
+			"msg":  "Invalid connection: " + err.Error(),
+		})
+		return
+	}
+
+	ds, err := h.service.Factory.NewDataSource(model.DataSourceType(config.Type))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1,
+			"msg":  "Unsupported data source type: " + config.Type,
+		})
+		return
+	}
+	defer ds.Close()
+
+	if err := ds.Connect(config); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 1,
+			"msg":  "Connection failed: " + err.Error(),
+		})
+		return
+	}
+
+	if err := ds.TestConnection(); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"code": 1,
+			"msg":  "Connection test failed: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "Connection test successful",
+	})
+}
+
+// connectionErrorStatus 根据连接相关错误返回合适的HTTP状态码
+func connectionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, coreError.ErrConnectionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, coreError.ErrConnectionNameExists):
+		return http.StatusConflict
+	case errors.Is(err, coreError.ErrInvalidConfig):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,73 @@
+package datamigrate
+
+import (
+	"errors"
+	"net/http"
+
+	coreError "opscore/error"
+	"opscore/internal/service/datamigrate"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MapSchemaHandler 预览源表转换到目标方言后的表结构及有损映射告警
+func (h *APIHandler) MapSchemaHandler(c *gin.Context) {
+	var req datamigrate.SchemaMapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Error("Failed to bind JSON", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1,
+			"msg":  "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+	if req.Database == "" || req.Table == "" || req.TargetType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1,
+			"msg":  "database, table and target_type are required",
+		})
+		return
+	}
+
+	srcCfg, err := h.service.ResolveDataSourceConfig(req.SourceConfig)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code": 1,
+			"msg":  "Invalid source config: " + err.Error(),
+		})
+		return
+	}
+	ds, release, err := h.service.Connections.Acquire(srcCfg)
+	if err != nil {
+		c.JSON(acquireErrorStatus(err), gin.H{
+			"code": 1,
+			"msg":  "源库连接失败: " + err.Error(),
+		})
+		return
+	}
+	defer release()
+
+	resp, err := h.service.MapTableSchema(ds, &req)
+	if err != nil {
+		h.logger.Error("Failed to map table schema", zap.String("database", req.Database), zap.String("table", req.Table), zap.Error(err))
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, coreError.ErrTableNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, coreError.ErrUnsupportedDataSource):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"code": 1,
+			"msg":  "Failed to map table schema: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code": 0,
+		"msg":  "success",
+		"data": resp,
+	})
+}
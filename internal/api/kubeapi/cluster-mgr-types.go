@@ -1,41 +1,128 @@
 package kubeapi
 
 import (
-	"time" 
+	"time"
+
+	"opscore/internal/service/kubernetes"
 )
 
 // K8sAddClusterRequest 代表添加新 Kubernetes 集群的请求负载。
 type K8sAddClusterRequest struct {
 	ClusterName string `json:"cluster_name" binding:"required"`
 	Comment     string `json:"comment,omitempty"` // 可选的描述信息
-	KubeConfig  string `json:"kube_config" binding:"required"`
+	K8sClusterCredentials
 	// ClusterType string `json:"cluster_type,omitempty"` // 如果需要用户定义的类型，可以添加
 }
 
+// K8sClusterCredentials 集群凭证，auth_type 决定需要哪些字段：
+// kubeconfig（默认）需要 kube_config；token 需要 server、token 和 ca_data；
+// serviceaccount 需要 bootstrap_kube_config，仅用于创建 ServiceAccount，不会保存；
+// incluster 不需要其他字段，仅在 opscore 运行于集群内时可用。
+type K8sClusterCredentials struct {
+	AuthType                string `json:"auth_type,omitempty"`
+	KubeConfig              string `json:"kube_config,omitempty"`
+	Server                  string `json:"server,omitempty"`
+	Token                   string `json:"token,omitempty"`
+	CAData                  string `json:"ca_data,omitempty"`
+	Insecure                bool   `json:"insecure,omitempty"`
+	BootstrapKubeConfig     string `json:"bootstrap_kube_config,omitempty"`
+	ServiceAccountNamespace string `json:"service_account_namespace,omitempty"` // 默认 opscore-system
+	ServiceAccountName      string `json:"service_account_name,omitempty"`      // 默认 opscore
+	ClusterRole             string `json:"cluster_role,omitempty"`              // 绑定的 ClusterRole，默认为只读加运维操作权限的 opscore:operator
+}
+
+// empty 未提供任何凭证字段
+func (c K8sClusterCredentials) empty() bool {
+	return c == K8sClusterCredentials{}
+}
+
+// toService 转换为业务层的凭证结构
+func (c K8sClusterCredentials) toService() kubernetes.ClusterCredentials {
+	return kubernetes.ClusterCredentials{
+		AuthType:                c.AuthType,
+		KubeConfig:              c.KubeConfig,
+		Server:                  c.Server,
+		Token:                   c.Token,
+		CAData:                  c.CAData,
+		Insecure:                c.Insecure,
+		BootstrapKubeConfig:     c.BootstrapKubeConfig,
+		ServiceAccountNamespace: c.ServiceAccountNamespace,
+		ServiceAccountName:      c.ServiceAccountName,
+		ClusterRole:             c.ClusterRole,
+	}
+}
+
 // K8sClusterResponse 代表成功添加或获取集群后返回的数据。
 // 这个结构参考了 web/lib/api.js 中的 mock 数据。
 type K8sClusterResponse struct {
-	ID          uint      `json:"id"`           // 数据库主键 ID (来自 gorm.Model)
-	ClusterName string    `json:"cluster_name"` // 集群名称
-	Comment     string    `json:"comment"`      // 备注/描述
-	ClusterID   string    `json:"cluster_id"`   // 后端生成的唯一字符串ID (例如 UUID)
-	Version     string    `json:"version"`      // Kubernetes 版本
-	Status      string    `json:"status"`       // 集群状态 (例如 "connected", "error")
-	AddedAt     time.Time `json:"added_at"`     // 添加时间 (来自 gorm.Model.CreatedAt)
+	ID            uint       `json:"id"`                        // 数据库主键 ID (来自 gorm.Model)
+	ClusterName   string     `json:"cluster_name"`              // 集群名称
+	Comment       string     `json:"comment"`                   // 备注/描述
+	ClusterID     string     `json:"cluster_id"`                // 后端生成的唯一字符串ID (例如 UUID)
+	AuthType      string     `json:"auth_type"`                 // 认证方式
+	Version       string     `json:"version"`                   // Kubernetes 版本
+	Status        string     `json:"status"`                    // 集群状态 (例如 "connected", "degraded", "unreachable")
+	AddedAt       time.Time  `json:"added_at"`                  // 添加时间 (来自 gorm.Model.CreatedAt)
+	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"` // 最近一次健康检查时间
+	CertExpiresAt *time.Time `json:"cert_expires_at,omitempty"` // kubeconfig 凭证过期时间
 	// ClusterType string `json:"cluster_type,omitempty"`
 }
 
-// K8sClusterRequestList 结构保持，但其内部类型更新为新的请求类型。
-// 如果用于批量添加，其字段应为 []K8sAddClusterRequest。
-type K8sClusterRequestList struct {
-	Clusters []K8sAddClusterRequest `json:"clusters"`
+// K8sUpdateClusterRequest 代表更新 Kubernetes 集群的请求负载，未提供的字段保持不变。
+type K8sUpdateClusterRequest struct {
+	ClusterName *string `json:"cluster_name,omitempty"`
+	Comment     *string `json:"comment,omitempty"`
+	// 提供任意凭证字段即表示更换凭证，更换后会重新校验连通性并刷新版本
+	K8sClusterCredentials
 }
 
+// K8sReencryptResponse 代表重新加密集群凭证和数据源密码的结果。
+type K8sReencryptResponse struct {
+	Reencrypted int            `json:"reencrypted"` // 本次重新加密的记录总数
+	Tables      map[string]int `json:"tables"`      // 按表统计的重新加密记录数
+	KeyID       string         `json:"key_id"`      // 当前主密钥标识
+}
 
+// K8sTestClusterResponse 代表重新测试集群连接的结果。
+type K8sTestClusterResponse struct {
+	K8sClusterResponse
+	Error string `json:"error,omitempty"`
+}
 
+// K8sClusterRequestList 代表批量添加集群的请求负载，各集群并发校验。
+type K8sClusterRequestList struct {
+	Clusters []K8sAddClusterRequest `json:"clusters" binding:"required,min=1,dive"`
+}
 
+// K8sListContextsRequest 代表解析 kubeconfig 上下文的请求负载。
+type K8sListContextsRequest struct {
+	KubeConfig string `json:"kube_config" binding:"required"`
+}
 
+// K8sImportClustersRequest 代表从多上下文 kubeconfig 导入集群的请求负载。
+type K8sImportClustersRequest struct {
+	KubeConfig string             `json:"kube_config" binding:"required"` // 证书和令牌须内嵌，不支持文件路径引用
+	Contexts   []K8sImportContext `json:"contexts" binding:"required,min=1,dive"`
+}
 
+// K8sImportContext 代表选中导入的上下文，cluster_name 为空时使用上下文名称。
+type K8sImportContext struct {
+	Context     string `json:"context" binding:"required"`
+	ClusterName string `json:"cluster_name,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+}
 
+// K8sClusterRegistrationResult 代表批量注册中单个集群的结果。
+type K8sClusterRegistrationResult struct {
+	ClusterName string              `json:"cluster_name"`
+	Context     string              `json:"context,omitempty"`
+	Cluster     *K8sClusterResponse `json:"cluster,omitempty"`
+	Error       string              `json:"error,omitempty"`
+}
 
-
+// K8sBatchClustersResponse 代表批量注册的汇总结果。
+type K8sBatchClustersResponse struct {
+	Succeeded int                            `json:"succeeded"`
+	Failed    int                            `json:"failed"`
+	Results   []K8sClusterRegistrationResult `json:"results"`
+}
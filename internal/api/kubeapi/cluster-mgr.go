@@ -1,11 +1,19 @@
 package kubeapi
 
 import (
+	"errors"
 	"net/http"
-	"opscore/internal/service/kubernetes" // 引入业务逻辑包
-	"opscore/internal/log"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
+	coreError "opscore/error"
+	"opscore/internal/crypto"
+	"opscore/internal/db"
+	"opscore/internal/log"
+	"opscore/internal/model"
+	"opscore/internal/service/datamigrate"
+	"opscore/internal/service/kubernetes" // 引入业务逻辑包
 	// "time" // 如果 K8sClusterResponse 中的 AddedAt 需要手动设置且不是来自 gorm.Model
 )
 
@@ -21,23 +29,15 @@ func AddK8sClusterHandler(c *gin.Context) {
 		return
 	}
 
-	createdClusterMetadata, err := kubernetes.AddCluster(req.ClusterName, req.Comment, req.KubeConfig)
+	createdClusterMetadata, err := kubernetes.AddCluster(req.ClusterName, req.Comment, req.toService())
 	if err != nil {
-		// 根据错误类型可以返回更具体的HTTP状态码，例如400表示kubeconfig无效，500表示内部错误
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "添加集群失败: " + err.Error()})
+		// 凭证无效或连接失败返回400，其余返回500
+		c.JSON(clusterErrorStatus(err), gin.H{"error": "添加集群失败: " + err.Error()})
 		return
 	}
 
 	// 构建成功的响应
-	response := K8sClusterResponse{
-		ID:          createdClusterMetadata.ID,        
-		ClusterName: createdClusterMetadata.ClusterName,
-		Comment:     createdClusterMetadata.Comment,
-		ClusterID:   createdClusterMetadata.ClusterID, // 后端生成的唯一字符串ID
-		Version:     createdClusterMetadata.Version,
-		Status:      createdClusterMetadata.Status,
-		AddedAt:     createdClusterMetadata.CreatedAt, // 来自 gorm.Model
-	}
+	response := newClusterResponse(createdClusterMetadata)
 
 	logger.Info("成功添加新的Kubernetes集群", zap.String("clusterName", response.ClusterName), zap.String("clusterID", response.ClusterID))
 	c.JSON(http.StatusCreated, response) // 返回 201 Created 状态码
@@ -47,7 +47,7 @@ func AddK8sClusterHandler(c *gin.Context) {
 func ListK8sClustersHandler(c *gin.Context) {
 	logger := log.GetLogger()
 
-	clusterMetadatas, err := kubernetes.GetAllClusters() 
+	clusterMetadatas, err := kubernetes.GetAllClusters()
 	if err != nil {
 		logger.Error("从数据库获取集群失败", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "检索集群失败: " + err.Error()})
@@ -57,19 +57,258 @@ func ListK8sClustersHandler(c *gin.Context) {
 	// 将 K8sClusterMetaData 转换为 K8sClusterResponse
 	// K8sClusterResponse 定义在 internal/api/kubernetes-types.go
 	responses := make([]K8sClusterResponse, len(clusterMetadatas))
-	for i, meta := range clusterMetadatas {
-		responses[i] = K8sClusterResponse{
-			ID:          meta.ID,        // 来自 gorm.Model
-			ClusterName: meta.ClusterName,
-			Comment:     meta.Comment,
-			ClusterID:   meta.ClusterID, // 后端生成的唯一字符串ID
-			Version:     meta.Version,
-			Status:      meta.Status,
-			AddedAt:     meta.CreatedAt, // 来自 gorm.Model
-		}
+	for i := range clusterMetadatas {
+		responses[i] = newClusterResponse(&clusterMetadatas[i])
 	}
 
 	logger.Info("成功检索到Kubernetes集群列表", zap.Int("count", len(responses)))
 	c.JSON(http.StatusOK, responses)
 }
 
+// newClusterResponse 将集群元数据转换为响应结构
+func newClusterResponse(meta *model.K8sClusterMetaData) K8sClusterResponse {
+	return K8sClusterResponse{
+		ID:            meta.ID,
+		ClusterName:   meta.ClusterName,
+		Comment:       meta.Comment,
+		ClusterID:     meta.ClusterID,
+		AuthType:      meta.AuthType,
+		Version:       meta.Version,
+		Status:        meta.Status,
+		AddedAt:       meta.CreatedAt,
+		LastCheckedAt: meta.LastCheckedAt,
+		CertExpiresAt: meta.CertExpiresAt,
+	}
+}
+
+// clusterErrorStatus 集群不存在返回404，kubeconfig 校验失败返回400，其余返回500
+func clusterErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, coreError.ErrClusterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, coreError.ErrInvalidKubeConfig):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetK8sClusterHandler 处理获取单个 Kubernetes 集群的 API 请求。
+func GetK8sClusterHandler(c *gin.Context) {
+	logger := log.GetLogger()
+	clusterID := c.Param("clusterId")
+
+	cluster, err := kubernetes.GetClusterByClusterID(clusterID)
+	if err != nil {
+		logger.Error("获取集群失败", zap.String("clusterID", clusterID), zap.Error(err))
+		c.JSON(clusterErrorStatus(err), gin.H{"error": "获取集群失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, newClusterResponse(cluster))
+}
+
+// UpdateK8sClusterHandler 处理更新 Kubernetes 集群的 API 请求。
+// 提供新的凭证时会重新校验，校验失败返回 400 且不修改已保存的配置。
+func UpdateK8sClusterHandler(c *gin.Context) {
+	logger := log.GetLogger()
+	clusterID := c.Param("clusterId")
+
+	var req K8sUpdateClusterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("无法绑定更新集群请求的JSON", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求负载: " + err.Error()})
+		return
+	}
+
+	input := kubernetes.UpdateClusterInput{
+		ClusterName: req.ClusterName,
+		Comment:     req.Comment,
+	}
+	if !req.K8sClusterCredentials.empty() {
+		creds := req.toService()
+		input.Credentials = &creds
+	}
+	cluster, err := kubernetes.UpdateCluster(clusterID, input)
+	if err != nil {
+		c.JSON(clusterErrorStatus(err), gin.H{"error": "更新集群失败: " + err.Error()})
+		return
+	}
+
+	logger.Info("成功更新Kubernetes集群", zap.String("clusterID", cluster.ClusterID))
+	c.JSON(http.StatusOK, newClusterResponse(cluster))
+}
+
+// DeleteK8sClusterHandler 处理删除 Kubernetes 集群的 API 请求。
+// 默认软删除，查询参数 purge=true 时彻底删除。
+func DeleteK8sClusterHandler(c *gin.Context) {
+	logger := log.GetLogger()
+	clusterID := c.Param("clusterId")
+	purge, _ := strconv.ParseBool(c.DefaultQuery("purge", "false"))
+
+	if err := kubernetes.DeleteCluster(clusterID, purge); err != nil {
+		c.JSON(clusterErrorStatus(err), gin.H{"error": "删除集群失败: " + err.Error()})
+		return
+	}
+
+	logger.Info("成功删除Kubernetes集群", zap.String("clusterID", clusterID), zap.Bool("purge", purge))
+	c.JSON(http.StatusOK, gin.H{"message": "集群已删除", "purged": purge})
+}
+
+// TestK8sClusterConnectionHandler 处理重新测试 Kubernetes 集群连接的 API 请求。
+// 连接失败时返回 502，响应中包含更新后的集群状态和错误信息。
+func TestK8sClusterConnectionHandler(c *gin.Context) {
+	logger := log.GetLogger()
+	clusterID := c.Param("clusterId")
+
+	cluster, err := kubernetes.TestCluster(clusterID)
+	if cluster == nil {
+		c.JSON(clusterErrorStatus(err), gin.H{"error": "测试集群连接失败: " + err.Error()})
+		return
+	}
+
+	resp := K8sTestClusterResponse{K8sClusterResponse: newClusterResponse(cluster)}
+	if err != nil {
+		logger.Warn("集群连接测试失败", zap.String("clusterID", clusterID), zap.Error(err))
+		resp.Error = err.Error()
+		c.JSON(http.StatusBadGateway, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// GetK8sClusterHistoryHandler 处理获取集群健康检查历史的 API 请求，limit 默认 100。
+func GetK8sClusterHistoryHandler(c *gin.Context) {
+	logger := log.GetLogger()
+	clusterID := c.Param("clusterId")
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+
+	history, err := kubernetes.GetClusterStatusHistory(clusterID, limit)
+	if err != nil {
+		logger.Error("获取集群状态历史失败", zap.String("clusterID", clusterID), zap.Error(err))
+		c.JSON(clusterErrorStatus(err), gin.H{"error": "获取集群状态历史失败: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// ReencryptK8sClustersHandler 使用当前主密钥重新加密所有集群凭证以及数据源连接和迁移任务中的密码，
+// 密钥轮换后调用，成功后即可从配置中移除旧密钥。
+func ReencryptK8sClustersHandler(c *gin.Context) {
+	logger := log.GetLogger()
+
+	count, err := kubernetes.ReencryptClusterCredentials()
+	if err != nil {
+		logger.Error("重新加密集群凭证失败", zap.Error(err), zap.Int("reencrypted", count))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重新加密集群凭证失败: " + err.Error()})
+		return
+	}
+	tables, err := datamigrate.ReencryptCredentials()
+	if err != nil {
+		logger.Error("重新加密数据源凭证失败", zap.Error(err), zap.Any("reencrypted", tables))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重新加密数据源凭证失败: " + err.Error()})
+		return
+	}
+	tables[db.DBInstance.TableName(&model.K8sClusterMetaData{})] = count
+	keyID, err := crypto.KeyID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	total := 0
+	for _, n := range tables {
+		total += n
+	}
+	c.JSON(http.StatusOK, K8sReencryptResponse{Reencrypted: total, Tables: tables, KeyID: keyID})
+}
+
+// BatchAddK8sClustersHandler 处理批量添加 Kubernetes 集群的 API 请求，各集群并发校验。
+// 全部成功返回 201，部分失败返回 207 并在结果中给出每个集群的错误。
+func BatchAddK8sClustersHandler(c *gin.Context) {
+	logger := log.GetLogger()
+	var req K8sClusterRequestList
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("无法绑定批量添加集群请求的JSON", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求负载: " + err.Error()})
+		return
+	}
+
+	registrations := make([]kubernetes.ClusterRegistration, len(req.Clusters))
+	for i, item := range req.Clusters {
+		registrations[i] = kubernetes.ClusterRegistration{
+			ClusterName: item.ClusterName,
+			Comment:     item.Comment,
+			Credentials: item.toService(),
+		}
+	}
+	writeRegistrationResults(c, kubernetes.AddClusters(registrations))
+}
+
+// ListKubeConfigContextsHandler 解析 kubeconfig 并列出其中的上下文，供导入前选择。
+func ListKubeConfigContextsHandler(c *gin.Context) {
+	var req K8sListContextsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求负载: " + err.Error()})
+		return
+	}
+
+	contexts, err := kubernetes.ListKubeConfigContexts(req.KubeConfig)
+	if err != nil {
+		c.JSON(clusterErrorStatus(err), gin.H{"error": "解析kubeconfig失败: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, contexts)
+}
+
+// ImportK8sClustersHandler 将 kubeconfig 中选中的上下文分别注册为独立集群，
+// 每个集群只保存包含自身上下文的最小 kubeconfig。
+func ImportK8sClustersHandler(c *gin.Context) {
+	logger := log.GetLogger()
+	var req K8sImportClustersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error("无法绑定导入集群请求的JSON", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的请求负载: " + err.Error()})
+		return
+	}
+
+	selected := make([]kubernetes.ImportContext, len(req.Contexts))
+	for i, item := range req.Contexts {
+		selected[i] = kubernetes.ImportContext{
+			Context:     item.Context,
+			ClusterName: item.ClusterName,
+			Comment:     item.Comment,
+		}
+	}
+	results, err := kubernetes.ImportClusters(req.KubeConfig, selected)
+	if err != nil {
+		c.JSON(clusterErrorStatus(err), gin.H{"error": "导入集群失败: " + err.Error()})
+		return
+	}
+	writeRegistrationResults(c, results)
+}
+
+// writeRegistrationResults 输出批量注册结果
+func writeRegistrationResults(c *gin.Context, results []kubernetes.ClusterRegistrationResult) {
+	resp := K8sBatchClustersResponse{Results: make([]K8sClusterRegistrationResult, len(results))}
+	for i, r := range results {
+		item := K8sClusterRegistrationResult{ClusterName: r.ClusterName, Context: r.Context}
+		if r.Err != nil {
+			item.Error = r.Err.Error()
+			resp.Failed++
+		} else {
+			cluster := newClusterResponse(r.Cluster)
+			item.Cluster = &cluster
+			resp.Succeeded++
+		}
+		resp.Results[i] = item
+	}
+
+	status := http.StatusCreated
+	if resp.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, resp)
+}
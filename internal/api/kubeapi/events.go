@@ -0,0 +1,81 @@
+package kubeapi
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"opscore/internal/log"
+	"opscore/internal/service/kubernetes"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ListEventsHandler 列出事件，查询参数 namespace 为空时列出所有命名空间，
+// 可按 kind、name 过滤关联对象，type 可选 Normal、Warning，支持 limit 和 continue 分页
+func ListEventsHandler(c *gin.Context) {
+	eventType := c.Query("type")
+	if eventType != "" && eventType != corev1.EventTypeNormal && eventType != corev1.EventTypeWarning {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "invalid type parameter, expected Normal or Warning", "data": nil})
+		return
+	}
+	query := kubernetes.EventQuery{
+		Kind:     c.Query("kind"),
+		Name:     c.Query("name"),
+		Type:     eventType,
+		Continue: c.Query("continue"),
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "invalid limit parameter", "data": nil})
+			return
+		}
+		query.Limit = limit
+	}
+
+	events, err := kubernetes.ListEvents(c.Param("clusterID"), c.Query("namespace"), query)
+	if err != nil {
+		log.GetLogger().Error("ListEventsHandler: failed to list events", zap.Error(err))
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": events})
+}
+
+// ObjectEventsHandler 列出单个资源对象的事件
+func ObjectEventsHandler(c *gin.Context) {
+	events, err := kubernetes.ListObjectEvents(c.Param("clusterID"), c.Param("resourceType"), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		log.GetLogger().Error("ObjectEventsHandler: failed to list events", zap.Error(err))
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": events})
+}
+
+// WorkloadTimelineHandler 返回工作负载的时间线，合并事件、版本发布和容器重启。
+// 查询参数 since 为 RFC3339 时间或 Go duration（如 24h），为空时返回全部
+func WorkloadTimelineHandler(c *gin.Context) {
+	var since time.Time
+	if v := c.Query("since"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			since = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, v); err == nil {
+			since = t
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "invalid since parameter, expected RFC3339 or duration", "data": nil})
+			return
+		}
+	}
+
+	timeline, err := kubernetes.GetWorkloadTimeline(c.Param("clusterID"), c.Param("resourceType"), c.Param("namespace"), c.Param("name"), since)
+	if err != nil {
+		log.GetLogger().Error("WorkloadTimelineHandler: failed to build timeline", zap.Error(err))
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": timeline})
+}
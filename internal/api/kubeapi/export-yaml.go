@@ -1,6 +1,7 @@
 package kubeapi
 
 import (
+	"fmt"
 	"net/http"
 	"opscore/internal/service/kubernetes"
 	"opscore/internal/log"
@@ -14,6 +15,15 @@ type ExportResourcesRequest struct {
 	ClusterID     string   `json:"clusterId"`
 	Namespace     string   `json:"namespace"`
 	ResourceTypes []string `json:"resourceTypes"`
+	// Format 导出格式：json（默认，YAML 字符串数组）、yaml、tar.gz、zip、helm、kustomize，除 json 外均以文件下载返回
+	Format       string   `json:"format"`
+	ChartName    string   `json:"chartName"`
+	ChartVersion string   `json:"chartVersion"`
+	Overlays     []string `json:"overlays"`
+	// SecretPolicy Secret 处理策略，为空时原样导出但跳过系统类型的 Secret
+	SecretPolicy *kubernetes.SecretPolicy `json:"secretPolicy"`
+	// Filter 按标签、名称和对象列表筛选，可选跟踪工作负载引用的对象
+	Filter *kubernetes.ResourceFilter `json:"filter"`
 }
 
 type ExportResourcesResponse struct {
@@ -33,11 +43,29 @@ func ExportResourcesHandler(c *gin.Context) {
 		return
 	}
 	logger.Info("ExportResourcesHandler", zap.Any("req", req))
-	data, err := kubernetes.ExportResources(req.ClusterID, req.Namespace, req.ResourceTypes)
+	if req.Format != "" && req.Format != kubernetes.ExportFormatJSON {
+		archive, err := kubernetes.ExportResourceArchive(req.ClusterID, req.Namespace, req.ResourceTypes, kubernetes.ExportOptions{
+			Format:       req.Format,
+			ChartName:    req.ChartName,
+			ChartVersion: req.ChartVersion,
+			Overlays:     req.Overlays,
+			SecretPolicy: req.SecretPolicy,
+			Filter:       req.Filter,
+		})
+		if err != nil {
+			logger.Error("ExportResourcesHandler", zap.Error(err))
+			c.JSON(resourceErrorStatus(err), ExportResourcesResponse{Code: 1, Msg: err.Error(), Data: nil})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archive.FileName))
+		c.Data(http.StatusOK, archive.ContentType, archive.Data)
+		return
+	}
+	data, err := kubernetes.ExportResources(req.ClusterID, req.Namespace, req.ResourceTypes, req.SecretPolicy, req.Filter)
 	if err != nil {
 		logger.Error("ExportResourcesHandler", zap.Error(err))
 		resp = ExportResourcesResponse{Code: 1, Msg: err.Error(), Data: nil}
-		c.JSON(http.StatusInternalServerError, resp)
+		c.JSON(resourceErrorStatus(err), resp)
 		return
 	}
 	// data 转换为 string
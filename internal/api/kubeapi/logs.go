@@ -0,0 +1,193 @@
+package kubeapi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"opscore/internal/log"
+	"opscore/internal/service/kubernetes"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// 未指定 tailLines 和起始时间时的默认行数，避免一次返回过多日志
+	defaultPodLogTailLines       int64 = 1000
+	defaultAggregateLogTailLines int64 = 200
+)
+
+// 日志接口的查询参数：container、tailLines、sinceTime（RFC3339）、sinceSeconds、previous、timestamps，
+// follow=true 时通过 SSE 持续推送，download=true 时以附件形式下载。
+
+// parseLogOptions 解析日志查询参数
+func parseLogOptions(c *gin.Context, defaultTail int64) (kubernetes.LogOptions, error) {
+	opts := kubernetes.LogOptions{
+		Container:  c.Query("container"),
+		Previous:   c.Query("previous") == "true",
+		Follow:     c.Query("follow") == "true",
+		Timestamps: c.Query("timestamps") == "true",
+	}
+	if v := c.Query("tailLines"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("invalid tailLines parameter")
+		}
+		opts.TailLines = &n
+	}
+	if v := c.Query("sinceSeconds"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n <= 0 {
+			return opts, fmt.Errorf("invalid sinceSeconds parameter")
+		}
+		opts.SinceSeconds = &n
+	}
+	if v := c.Query("sinceTime"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return opts, fmt.Errorf("invalid sinceTime parameter, expected RFC3339")
+		}
+		opts.SinceTime = &t
+	}
+	if opts.TailLines == nil && opts.SinceSeconds == nil && opts.SinceTime == nil && !opts.Follow {
+		opts.TailLines = &defaultTail
+	}
+	return opts, nil
+}
+
+// isPodResource 判断资源类型是否为 Pod
+func isPodResource(resourceType string) bool {
+	switch resourceType {
+	case "pods", "pod", "po":
+		return true
+	}
+	return false
+}
+
+// logFileName 下载日志时的文件名
+func logFileName(parts ...string) string {
+	name := ""
+	for _, p := range parts {
+		if p != "" {
+			name += p + "-"
+		}
+	}
+	return name + time.Now().Format("20060102150405") + ".log"
+}
+
+// setSSEHeaders 设置 SSE 响应头
+func setSSEHeaders(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+}
+
+// PodLogsHandler 读取单个 Pod 的日志，路径为 /kubernetes/:clusterID/namespaces/:namespace/pods/:name/logs
+func PodLogsHandler(c *gin.Context) {
+	logger := log.GetLogger()
+	if !isPodResource(c.Param("resourceType")) {
+		c.JSON(http.StatusNotFound, gin.H{"code": 1, "msg": "logs are only available for pods", "data": nil})
+		return
+	}
+	opts, err := parseLogOptions(c, defaultPodLogTailLines)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": err.Error(), "data": nil})
+		return
+	}
+	download := c.Query("download") == "true"
+	if download {
+		opts.Follow = false
+	}
+
+	pod := c.Param("name")
+	stream, err := kubernetes.StreamPodLogs(c.Request.Context(), c.Param("clusterID"), c.Param("namespace"), pod, opts)
+	if err != nil {
+		writeResourceError(c, err)
+		return
+	}
+	defer stream.Close()
+
+	switch {
+	case download:
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, logFileName(pod, opts.Container)))
+		c.Status(http.StatusOK)
+		if _, err := io.Copy(c.Writer, stream); err != nil {
+			logger.Warn("PodLogsHandler: log download interrupted", zap.Error(err), zap.String("pod", pod))
+		}
+	case opts.Follow:
+		setSSEHeaders(c)
+		c.Status(http.StatusOK)
+		scanner := bufio.NewScanner(stream)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			c.SSEvent("log", scanner.Text())
+			c.Writer.Flush()
+		}
+		c.SSEvent("end", "")
+		c.Writer.Flush()
+	default:
+		data, err := io.ReadAll(stream)
+		if err != nil {
+			writeResourceError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": gin.H{"logs": string(data)}})
+	}
+}
+
+// AggregateLogsHandler 聚合命名空间内匹配 labelSelector 的所有 Pod 日志并按时间戳交错，
+// 路径为 /kubernetes/:clusterID/logs?namespace=&labelSelector=
+func AggregateLogsHandler(c *gin.Context) {
+	labelSelector := c.Query("labelSelector")
+	if labelSelector == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "labelSelector is required", "data": nil})
+		return
+	}
+	opts, err := parseLogOptions(c, defaultAggregateLogTailLines)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": err.Error(), "data": nil})
+		return
+	}
+	download := c.Query("download") == "true"
+	if download {
+		opts.Follow = false
+	}
+
+	namespace := c.Query("namespace")
+	lines, err := kubernetes.AggregateLogs(c.Request.Context(), c.Param("clusterID"), namespace, labelSelector, opts)
+	if err != nil {
+		writeResourceError(c, err)
+		return
+	}
+
+	switch {
+	case download:
+		c.Header("Content-Type", "text/plain; charset=utf-8")
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, logFileName(namespace, "aggregated")))
+		c.Status(http.StatusOK)
+		for line := range lines {
+			fmt.Fprintf(c.Writer, "%s %s/%s %s\n", line.Timestamp.Format(time.RFC3339Nano), line.Pod, line.Container, line.Message)
+		}
+	case opts.Follow:
+		setSSEHeaders(c)
+		c.Status(http.StatusOK)
+		for line := range lines {
+			c.SSEvent("log", line)
+			c.Writer.Flush()
+		}
+		c.SSEvent("end", "")
+		c.Writer.Flush()
+	default:
+		collected := []kubernetes.LogLine{}
+		for line := range lines {
+			collected = append(collected, line)
+		}
+		c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": gin.H{"lines": collected}})
+	}
+}
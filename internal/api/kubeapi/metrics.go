@@ -0,0 +1,46 @@
+package kubeapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"opscore/internal/log"
+	"opscore/internal/service/kubernetes"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TopNodesHandler 返回节点资源使用量，未安装 metrics-server 时 metricsAvailable 为 false
+func TopNodesHandler(c *gin.Context) {
+	nodes, err := kubernetes.ListNodeUsage(c.Param("clusterID"))
+	if err != nil {
+		log.GetLogger().Error("TopNodesHandler: failed to get node usage", zap.Error(err))
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": nodes})
+}
+
+// TopPodsHandler 返回使用量最高的 Pod，查询参数 namespace 为空时统计所有命名空间，
+// sortBy 可选 cpu、memory，limit 默认 10
+func TopPodsHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "invalid limit parameter", "data": nil})
+		return
+	}
+	sortBy := c.DefaultQuery("sortBy", kubernetes.SortByCPU)
+	if sortBy != kubernetes.SortByCPU && sortBy != kubernetes.SortByMemory {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "invalid sortBy parameter", "data": nil})
+		return
+	}
+
+	pods, err := kubernetes.TopPods(c.Param("clusterID"), c.Query("namespace"), sortBy, limit)
+	if err != nil {
+		log.GetLogger().Error("TopPodsHandler: failed to get pod usage", zap.Error(err))
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": pods})
+}
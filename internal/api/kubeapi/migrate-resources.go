@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"opscore/internal/service/kubernetes"
 	"opscore/internal/log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -16,6 +17,21 @@ type MigrateResourcesRequest struct {
 	SourceNamespace      string   `json:"sourceNamespace" binding:"required"`
 	DestNamespace        string   `json:"destNamespace" binding:"required"`
 	ResourceTypes        []string `json:"resourceTypes"`
+	// Filter 按标签、名称和对象列表筛选，可选跟踪工作负载引用的对象
+	Filter *kubernetes.ResourceFilter `json:"filter"`
+	// SecretPolicy Secret 处理策略，迁移只支持 include、redact、skip 以及 sealed 加密
+	SecretPolicy *kubernetes.SecretPolicy `json:"secretPolicy"`
+	// DryRun 只在目标集群做服务端预演，返回每个对象的变更类型（create/update/unchanged）和 YAML 差异
+	DryRun bool `json:"dryRun"`
+	// ForceConflicts 服务端应用时与其他字段管理者冲突则强制接管
+	ForceConflicts bool `json:"forceConflicts"`
+	// ImmutableStrategies 按 Kind 指定不可变字段有变化时的处理方式：fail、skip、recreate
+	ImmutableStrategies map[string]string `json:"immutableStrategies"`
+	// TransformRules 应用前按顺序执行的转换规则：jsonPatch、set、remove、replace、registry
+	TransformRules []kubernetes.TransformRule `json:"transformRules"`
+	// WaitForReady 每个阶段应用后等待工作负载就绪，ReadyTimeoutSeconds 为单个工作负载的超时时间，默认 300
+	WaitForReady        bool `json:"waitForReady"`
+	ReadyTimeoutSeconds int  `json:"readyTimeoutSeconds"`
 }
 
 // MigrateResourcesResponse 定义资源迁移的响应结构
@@ -50,7 +66,8 @@ func MigrateResourcesHandler(c *gin.Context) {
 		zap.String("destinationClusterId", req.DestinationClusterID),
 		zap.String("sourceNamespace", req.SourceNamespace),
 		zap.String("destNamespace", req.DestNamespace),
-		zap.Strings("resourceTypes", req.ResourceTypes))
+		zap.Strings("resourceTypes", req.ResourceTypes),
+		zap.Bool("dryRun", req.DryRun))
 
 	// 调用业务逻辑层执行资源迁移
 	results, err := kubernetes.MigrateResources(
@@ -59,12 +76,22 @@ func MigrateResourcesHandler(c *gin.Context) {
 		req.SourceNamespace,
 		req.DestNamespace,
 		req.ResourceTypes,
+		kubernetes.MigrateOptions{
+			Filter:              req.Filter,
+			SecretPolicy:        req.SecretPolicy,
+			DryRun:              req.DryRun,
+			ForceConflicts:      req.ForceConflicts,
+			ImmutableStrategies: req.ImmutableStrategies,
+			TransformRules:      req.TransformRules,
+			WaitForReady:        req.WaitForReady,
+			ReadyTimeout:        time.Duration(req.ReadyTimeoutSeconds) * time.Second,
+		},
 	)
 
 	if err != nil {
 		logger.Error("MigrateResourcesHandler: 资源迁移失败", zap.Error(err))
 		resp = MigrateResourcesResponse{Code: 1, Msg: err.Error(), Data: nil}
-		c.JSON(http.StatusInternalServerError, resp)
+		c.JSON(resourceErrorStatus(err), resp)
 		return
 	}
 
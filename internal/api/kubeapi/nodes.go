@@ -0,0 +1,109 @@
+package kubeapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"opscore/internal/log"
+	"opscore/internal/service/kubernetes"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// K8sDrainRequest 节点排空请求
+type K8sDrainRequest struct {
+	TimeoutSeconds     int    `json:"timeoutSeconds"`
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds"`
+	DeleteEmptyDirData bool   `json:"deleteEmptyDirData"`
+	Force              bool   `json:"force"`
+}
+
+// ListNodesHandler 列出集群节点
+func ListNodesHandler(c *gin.Context) {
+	nodes, err := kubernetes.ListNodes(c.Param("clusterID"))
+	if err != nil {
+		log.GetLogger().Error("ListNodesHandler: failed to list nodes", zap.Error(err))
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": nodes})
+}
+
+// GetNodeHandler 获取单个节点
+func GetNodeHandler(c *gin.Context) {
+	node, err := kubernetes.GetNode(c.Param("clusterID"), c.Param("node"))
+	if err != nil {
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": node})
+}
+
+// CordonNodeHandler 封锁节点，禁止新的 Pod 调度到该节点
+func CordonNodeHandler(c *gin.Context) {
+	setNodeSchedulable(c, true)
+}
+
+// UncordonNodeHandler 解除节点封锁
+func UncordonNodeHandler(c *gin.Context) {
+	setNodeSchedulable(c, false)
+}
+
+func setNodeSchedulable(c *gin.Context, unschedulable bool) {
+	node, err := kubernetes.CordonNode(c.Param("clusterID"), c.Param("node"), unschedulable)
+	if err != nil {
+		log.GetLogger().Error("failed to set node schedulable", zap.Error(err), zap.String("node", c.Param("node")), zap.Bool("unschedulable", unschedulable))
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": node})
+}
+
+// DrainNodeHandler 封锁并异步排空节点，返回 202 和排空任务，进度通过 drains 接口查询
+func DrainNodeHandler(c *gin.Context) {
+	var req K8sDrainRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "Invalid request body: " + err.Error(), "data": nil})
+			return
+		}
+	}
+	task, err := kubernetes.StartNodeDrain(c.Param("clusterID"), c.Param("node"), kubernetes.DrainOptions{
+		TimeoutSeconds:     req.TimeoutSeconds,
+		GracePeriodSeconds: req.GracePeriodSeconds,
+		DeleteEmptyDirData: req.DeleteEmptyDirData,
+		Force:              req.Force,
+	}, operationActor(c))
+	if err != nil {
+		log.GetLogger().Error("DrainNodeHandler: failed to start drain", zap.Error(err), zap.String("node", c.Param("node")))
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"code": 0, "msg": "drain started", "data": task})
+}
+
+// ListDrainTasksHandler 列出排空任务，可用查询参数 node 过滤
+func ListDrainTasksHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "invalid limit parameter", "data": nil})
+		return
+	}
+	tasks, err := kubernetes.ListDrainTasks(c.Param("clusterID"), c.Query("node"), limit)
+	if err != nil {
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": tasks})
+}
+
+// GetDrainTaskHandler 查询排空任务进度
+func GetDrainTaskHandler(c *gin.Context) {
+	task, err := kubernetes.GetDrainTask(c.Param("clusterID"), c.Param("taskId"))
+	if err != nil {
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": task})
+}
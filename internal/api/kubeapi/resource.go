@@ -0,0 +1,132 @@
+package kubeapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	coreError "opscore/error"
+	"opscore/internal/log"
+	"opscore/internal/service/kubernetes"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// 通用资源接口，命名空间级资源使用 /kubernetes/:clusterID/namespaces/:namespace/:resourceType[/:name]，
+// 集群级资源以及跨命名空间列出使用 /kubernetes/:clusterID/resources/:resourceType[/:name]。
+// resourceType 支持复数、单数、简称和 deployments.apps 形式。
+
+// resourceErrorStatus 将资源请求错误映射为 HTTP 状态码，Kubernetes API 错误沿用其状态码
+func resourceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, coreError.ErrClusterNotFound), errors.Is(err, coreError.ErrResourceTypeNotFound),
+		errors.Is(err, coreError.ErrTerminalSessionNotFound), errors.Is(err, coreError.ErrRevisionNotFound),
+		errors.Is(err, coreError.ErrDrainTaskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, coreError.ErrResourceScope), errors.Is(err, coreError.ErrInvalidResource):
+		return http.StatusBadRequest
+	}
+	var status apierrors.APIStatus
+	if errors.As(err, &status) && status.Status().Code != 0 {
+		return int(status.Status().Code)
+	}
+	return http.StatusInternalServerError
+}
+
+func writeResourceError(c *gin.Context, err error) {
+	c.JSON(resourceErrorStatus(err), gin.H{"code": 1, "msg": err.Error(), "data": nil})
+}
+
+// ListAPIResourcesHandler 列出集群支持的资源类型，包括 CRD
+func ListAPIResourcesHandler(c *gin.Context) {
+	resources, err := kubernetes.ListAPIResources(c.Param("clusterID"))
+	if err != nil {
+		log.GetLogger().Error("ListAPIResourcesHandler: failed to discover resources", zap.Error(err))
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": resources})
+}
+
+// ListResourcesHandler 列出资源，支持 labelSelector、fieldSelector 以及 limit/continue 分页
+func ListResourcesHandler(c *gin.Context) {
+	limitStr := c.DefaultQuery("limit", "20")
+	limit, err := strconv.ParseInt(limitStr, 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "invalid limit parameter", "data": nil})
+		return
+	}
+
+	list, err := kubernetes.ListResources(c.Param("clusterID"), c.Param("resourceType"), c.Param("namespace"), kubernetes.ResourceQuery{
+		LabelSelector: c.Query("labelSelector"),
+		FieldSelector: c.Query("fieldSelector"),
+		Limit:         limit,
+		Continue:      c.Query("continue"),
+	})
+	if err != nil {
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": list})
+}
+
+// GetResourceHandler 获取单个资源
+func GetResourceHandler(c *gin.Context) {
+	obj, err := kubernetes.GetResource(c.Param("clusterID"), c.Param("resourceType"), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": obj.Object})
+}
+
+// CreateResourceHandler 创建资源，请求体为资源对象的 JSON
+func CreateResourceHandler(c *gin.Context) {
+	obj, ok := bindResource(c)
+	if !ok {
+		return
+	}
+	created, err := kubernetes.CreateResource(c.Param("clusterID"), c.Param("resourceType"), c.Param("namespace"), obj)
+	if err != nil {
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"code": 0, "msg": "success", "data": created.Object})
+}
+
+// UpdateResourceHandler 更新资源，请求体为完整的资源对象
+func UpdateResourceHandler(c *gin.Context) {
+	obj, ok := bindResource(c)
+	if !ok {
+		return
+	}
+	updated, err := kubernetes.UpdateResource(c.Param("clusterID"), c.Param("resourceType"), c.Param("namespace"), c.Param("name"), obj)
+	if err != nil {
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": updated.Object})
+}
+
+// DeleteResourceHandler 删除资源，查询参数 propagationPolicy 可选 Foreground、Background、Orphan
+func DeleteResourceHandler(c *gin.Context) {
+	err := kubernetes.DeleteResource(c.Param("clusterID"), c.Param("resourceType"), c.Param("namespace"), c.Param("name"), c.Query("propagationPolicy"))
+	if err != nil {
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": nil})
+}
+
+// bindResource 将请求体解析为资源对象
+func bindResource(c *gin.Context) (*unstructured.Unstructured, bool) {
+	var body map[string]interface{}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "无效的资源对象: " + err.Error(), "data": nil})
+		return nil, false
+	}
+	return &unstructured.Unstructured{Object: body}, true
+}
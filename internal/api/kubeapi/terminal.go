@@ -0,0 +1,305 @@
+package kubeapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"opscore/config"
+	"opscore/internal/log"
+	"opscore/internal/service/kubernetes"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// 终端 WebSocket 消息类型
+const (
+	terminalMsgInput   = "input"   // 客户端输入，data 为按键内容
+	terminalMsgResize  = "resize"  // 客户端窗口大小变化，cols/rows
+	terminalMsgSession = "session" // 会话建立，sessionId 可用于查看录像
+	terminalMsgOutput  = "output"  // 终端输出
+	terminalMsgError   = "error"   // 错误信息
+	terminalMsgExit    = "exit"    // 会话结束，data 为原因
+)
+
+// terminalMessage 终端 WebSocket 消息
+type terminalMessage struct {
+	Type      string `json:"type"`
+	Data      string `json:"data,omitempty"`
+	Cols      uint16 `json:"cols,omitempty"`
+	Rows      uint16 `json:"rows,omitempty"`
+	SessionID string `json:"sessionId,omitempty"`
+}
+
+var terminalUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin:     checkTerminalOrigin,
+}
+
+// checkTerminalOrigin 校验 WebSocket 握手的 Origin。浏览器不对 WebSocket 握手做 CORS 限制，
+// 任意页面都能借用已登录运维人员的身份打开终端，因此只放行 allowedOrigins 中配置的来源，
+// 未配置时一律拒绝。没有 Origin 头的非浏览器客户端不受影响。
+func checkTerminalOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range config.GetConfig().Kubernetes.Terminal.AllowedOrigins {
+		if strings.EqualFold(strings.TrimRight(allowed, "/"), origin) {
+			return true
+		}
+	}
+	log.GetLogger().Warn("拒绝终端 WebSocket 握手：来源不在白名单中", zap.String("origin", origin))
+	return false
+}
+
+// wsTerminal 将 WebSocket 连接适配为 remotecommand 所需的输入输出和窗口大小队列，
+// 同时写入会话录像并在无输入超时后结束会话
+type wsTerminal struct {
+	conn     *websocket.Conn
+	recorder *kubernetes.AsciicastRecorder
+	writeMu  sync.Mutex
+
+	stdinReader *io.PipeReader
+	stdinWriter *io.PipeWriter
+	sizeCh      chan remotecommand.TerminalSize
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	idle   *time.Timer
+
+	reasonOnce sync.Once
+	reason     string
+
+	// partial 上一次输出末尾不完整的 UTF-8 字节，与下一段输出拼接后再发送和录制，
+	// 否则多字节字符被拆分到两次读取时会在 JSON 编码中变成 U+FFFD
+	partialMu sync.Mutex
+	partial   []byte
+}
+
+func newWSTerminal(conn *websocket.Conn, recorder *kubernetes.AsciicastRecorder, idleTimeout time.Duration) *wsTerminal {
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	t := &wsTerminal{
+		conn:        conn,
+		recorder:    recorder,
+		stdinReader: pr,
+		stdinWriter: pw,
+		sizeCh:      make(chan remotecommand.TerminalSize, 4),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+	t.idle = time.AfterFunc(idleTimeout, func() {
+		t.stop(fmt.Sprintf("idle timeout after %s", idleTimeout))
+	})
+	return t
+}
+
+// readLoop 读取客户端消息，连接断开时结束会话
+func (t *wsTerminal) readLoop(idleTimeout time.Duration) {
+	for {
+		_, data, err := t.conn.ReadMessage()
+		if err != nil {
+			t.stop("client disconnected")
+			return
+		}
+		var msg terminalMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case terminalMsgInput:
+			t.idle.Reset(idleTimeout)
+			t.recorder.Input([]byte(msg.Data))
+			if _, err := t.stdinWriter.Write([]byte(msg.Data)); err != nil {
+				return
+			}
+		case terminalMsgResize:
+			if msg.Cols == 0 || msg.Rows == 0 {
+				continue
+			}
+			t.recorder.Resize(msg.Cols, msg.Rows)
+			select {
+			case t.sizeCh <- remotecommand.TerminalSize{Width: msg.Cols, Height: msg.Rows}:
+			default:
+			}
+		}
+	}
+}
+
+// stop 结束会话，只记录第一次的原因
+func (t *wsTerminal) stop(reason string) {
+	t.reasonOnce.Do(func() {
+		t.reason = reason
+		t.idle.Stop()
+		t.cancel()
+		t.stdinWriter.Close()
+	})
+}
+
+func (t *wsTerminal) Read(p []byte) (int, error) {
+	return t.stdinReader.Read(p)
+}
+
+func (t *wsTerminal) Write(p []byte) (int, error) {
+	t.partialMu.Lock()
+	data := append(t.partial, p...)
+	cut := completeUTF8Prefix(data)
+	t.partial = append([]byte(nil), data[cut:]...)
+	t.partialMu.Unlock()
+
+	if err := t.output(data[:cut]); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// flushPartial 会话结束时发送剩余的不完整字节
+func (t *wsTerminal) flushPartial() {
+	t.partialMu.Lock()
+	data := t.partial
+	t.partial = nil
+	t.partialMu.Unlock()
+	t.output(data)
+}
+
+func (t *wsTerminal) output(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+	t.recorder.Output(data)
+	return t.send(terminalMessage{Type: terminalMsgOutput, Data: string(data)})
+}
+
+// completeUTF8Prefix 返回 data 中以完整 UTF-8 字符结尾的前缀长度，
+// 末尾被截断的多字节字符留到下一次输出；非法字节不会等待，原样发送
+func completeUTF8Prefix(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(data)
+}
+
+// Next 返回下一次窗口大小变化，会话结束时返回 nil
+func (t *wsTerminal) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-t.sizeCh:
+		return &size
+	case <-t.ctx.Done():
+		return nil
+	}
+}
+
+func (t *wsTerminal) send(msg terminalMessage) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return t.conn.WriteJSON(msg)
+}
+
+// PodTerminalHandler 打开 Pod 交互式终端（WebSocket）。查询参数 namespace、pod 必填，
+// container 为空时使用第一个容器，shell 可选 bash、sh、ash、zsh、auto，cols/rows 为初始窗口大小。
+// 每个会话都会录制为 asciicast 文件。
+func PodTerminalHandler(c *gin.Context) {
+	logger := log.GetLogger()
+	if !checkTerminalOrigin(c.Request) {
+		c.JSON(http.StatusForbidden, gin.H{"code": 1, "msg": "origin not allowed", "data": nil})
+		return
+	}
+	namespace, pod := c.Query("namespace"), c.Query("pod")
+	if namespace == "" || pod == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "namespace and pod are required", "data": nil})
+		return
+	}
+	cols, _ := strconv.ParseUint(c.DefaultQuery("cols", "80"), 10, 16)
+	rows, _ := strconv.ParseUint(c.DefaultQuery("rows", "24"), 10, 16)
+
+	session, err := kubernetes.StartTerminalSession(c.Param("clusterID"), kubernetes.TerminalOptions{
+		Namespace: namespace,
+		Pod:       pod,
+		Container: c.Query("container"),
+		Shell:     c.Query("shell"),
+		Actor:     operationActor(c),
+		Width:     uint16(cols),
+		Height:    uint16(rows),
+	})
+	if err != nil {
+		writeResourceError(c, err)
+		return
+	}
+
+	conn, err := terminalUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logger.Error("PodTerminalHandler: websocket upgrade failed", zap.Error(err))
+		session.Finish("websocket upgrade failed: " + err.Error())
+		return
+	}
+	defer conn.Close()
+
+	idleTimeout := kubernetes.TerminalIdleTimeout()
+	term := newWSTerminal(conn, session.Recorder, idleTimeout)
+	term.send(terminalMessage{Type: terminalMsgSession, SessionID: session.Record.SessionID})
+	if cols > 0 && rows > 0 {
+		term.sizeCh <- remotecommand.TerminalSize{Width: uint16(cols), Height: uint16(rows)}
+	}
+	go term.readLoop(idleTimeout)
+
+	execErr := session.Exec(term.ctx, term)
+	term.flushPartial()
+	if execErr != nil {
+		term.stop(execErr.Error())
+	} else {
+		term.stop("shell exited")
+	}
+	// 空闲超时或客户端断开先于 shell 退出时，以先发生的原因为准
+	if execErr != nil && term.reason == execErr.Error() {
+		term.send(terminalMessage{Type: terminalMsgError, Data: execErr.Error()})
+	}
+	term.send(terminalMessage{Type: terminalMsgExit, Data: term.reason})
+	session.Finish(term.reason)
+}
+
+// ListTerminalSessionsHandler 列出集群的终端会话审计记录，limit 默认 50
+func ListTerminalSessionsHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "invalid limit parameter", "data": nil})
+		return
+	}
+	sessions, err := kubernetes.ListTerminalSessions(c.Param("clusterID"), limit)
+	if err != nil {
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": sessions})
+}
+
+// DownloadTerminalRecordingHandler 下载终端会话的 asciicast 录像
+func DownloadTerminalRecordingHandler(c *gin.Context) {
+	session, err := kubernetes.GetTerminalSession(c.Param("clusterID"), c.Param("sessionId"))
+	if err != nil {
+		writeResourceError(c, err)
+		return
+	}
+	if _, err := os.Stat(session.RecordingPath); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"code": 1, "msg": "recording file not found", "data": nil})
+		return
+	}
+	c.FileAttachment(session.RecordingPath, session.SessionID+".cast")
+}
@@ -0,0 +1,174 @@
+package kubeapi
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"opscore/config"
+	"opscore/internal/log"
+	"opscore/internal/model"
+	"opscore/internal/service/kubernetes"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// 工作负载操作接口，路径为 /kubernetes/:clusterID/namespaces/:namespace/:resourceType/:name/<action>，
+// resourceType 支持 deployments、statefulsets、daemonsets 及其简称
+
+// K8sScaleRequest 调整副本数请求
+type K8sScaleRequest struct {
+	Replicas *int32 `json:"replicas" binding:"required"`
+}
+
+// K8sSetImageRequest 修改容器镜像请求
+type K8sSetImageRequest struct {
+	Container string `json:"container" binding:"required"`
+	Image     string `json:"image" binding:"required"`
+}
+
+// K8sRollbackRequest 回滚请求，revision 为 0 或不填时回滚到上一个版本
+type K8sRollbackRequest struct {
+	Revision int64 `json:"revision"`
+}
+
+// operationActor 从请求中获取操作人：BasicAuth 用户优先，其次是网关透传的用户头。
+// 用户头只在请求来自可信网关时采信，否则任何客户端都能伪造审计记录中的操作人，
+// 此时操作人记为 anonymous，客户端地址使用 TCP 对端地址而不是可伪造的 X-Forwarded-For
+func operationActor(c *gin.Context) kubernetes.OperationActor {
+	if user := c.GetString(gin.AuthUserKey); user != "" {
+		return kubernetes.OperationActor{User: user, ClientIP: c.ClientIP()}
+	}
+	if !fromTrustedProxy(c) {
+		return kubernetes.OperationActor{User: "anonymous", ClientIP: c.RemoteIP()}
+	}
+
+	user := c.GetHeader("X-Opscore-User")
+	if user == "" {
+		user = c.GetHeader("X-Forwarded-User")
+	}
+	if user == "" {
+		user = "anonymous"
+	}
+	return kubernetes.OperationActor{User: user, ClientIP: c.ClientIP()}
+}
+
+// fromTrustedProxy 判断请求是否来自 security.trustedProxies 中的地址，或携带了匹配的 security.proxySecret
+func fromTrustedProxy(c *gin.Context) bool {
+	security := config.GetConfig().Security
+	if security.ProxySecret != "" {
+		secret := c.GetHeader("X-Opscore-Proxy-Secret")
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(security.ProxySecret)) == 1 {
+			return true
+		}
+	}
+
+	remote := net.ParseIP(c.RemoteIP())
+	if remote == nil {
+		return false
+	}
+	for _, entry := range security.TrustedProxies {
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.Equal(remote) {
+				return true
+			}
+			continue
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil && network.Contains(remote) {
+			return true
+		}
+	}
+	return false
+}
+
+func writeOperationResult(c *gin.Context, operation *model.K8sWorkloadOperation, err error) {
+	if err != nil {
+		log.GetLogger().Error("workload operation failed", zap.Error(err), zap.String("path", c.FullPath()))
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": operation})
+}
+
+// ScaleWorkloadHandler 调整 Deployment 或 StatefulSet 的副本数
+func ScaleWorkloadHandler(c *gin.Context) {
+	var req K8sScaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "Invalid request body: " + err.Error(), "data": nil})
+		return
+	}
+	operation, err := kubernetes.ScaleWorkload(c.Param("clusterID"), c.Param("resourceType"), c.Param("namespace"), c.Param("name"), *req.Replicas, operationActor(c))
+	writeOperationResult(c, operation, err)
+}
+
+// RestartWorkloadHandler 滚动重启工作负载
+func RestartWorkloadHandler(c *gin.Context) {
+	operation, err := kubernetes.RestartWorkload(c.Param("clusterID"), c.Param("resourceType"), c.Param("namespace"), c.Param("name"), operationActor(c))
+	writeOperationResult(c, operation, err)
+}
+
+// SetWorkloadImageHandler 修改容器镜像
+func SetWorkloadImageHandler(c *gin.Context) {
+	var req K8sSetImageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "Invalid request body: " + err.Error(), "data": nil})
+		return
+	}
+	operation, err := kubernetes.SetWorkloadImage(c.Param("clusterID"), c.Param("resourceType"), c.Param("namespace"), c.Param("name"), req.Container, req.Image, operationActor(c))
+	writeOperationResult(c, operation, err)
+}
+
+// ListWorkloadRevisionsHandler 列出工作负载的历史版本
+func ListWorkloadRevisionsHandler(c *gin.Context) {
+	revisions, err := kubernetes.ListWorkloadRevisions(c.Param("clusterID"), c.Param("resourceType"), c.Param("namespace"), c.Param("name"))
+	if err != nil {
+		log.GetLogger().Error("ListWorkloadRevisionsHandler: failed to list revisions", zap.Error(err))
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": revisions})
+}
+
+// RollbackWorkloadHandler 回滚工作负载到指定版本
+func RollbackWorkloadHandler(c *gin.Context) {
+	var req K8sRollbackRequest
+	// 请求体可以为空，表示回滚到上一个版本
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "Invalid request body: " + err.Error(), "data": nil})
+			return
+		}
+	}
+	operation, err := kubernetes.RollbackWorkload(c.Param("clusterID"), c.Param("resourceType"), c.Param("namespace"), c.Param("name"), req.Revision, operationActor(c))
+	writeOperationResult(c, operation, err)
+}
+
+// ListWorkloadOperationsHandler 列出工作负载操作记录。挂在对象路径下时只返回该对象的记录，
+// 挂在 /kubernetes/:clusterID/operations 时可用查询参数 namespace、kind、name 过滤
+func ListWorkloadOperationsHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"code": 1, "msg": "invalid limit parameter", "data": nil})
+		return
+	}
+	clusterID := c.Param("clusterID")
+	namespace, kind, name := c.Query("namespace"), c.Query("kind"), c.Query("name")
+	if resourceType := c.Param("resourceType"); resourceType != "" {
+		if kind, err = kubernetes.WorkloadKind(clusterID, resourceType); err != nil {
+			writeResourceError(c, err)
+			return
+		}
+		namespace, name = c.Param("namespace"), c.Param("name")
+	}
+
+	operations, err := kubernetes.ListWorkloadOperations(clusterID, namespace, kind, name, limit)
+	if err != nil {
+		log.GetLogger().Error("ListWorkloadOperationsHandler: failed to list operations", zap.Error(err))
+		writeResourceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"code": 0, "msg": "success", "data": operations})
+}
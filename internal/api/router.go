@@ -92,11 +92,22 @@ func SetupRouter() *gin.Engine {
 		//集群间资源迁移
 		k8sClusterRoutes.POST("/migrate-resources", kubeapi.MigrateResourcesHandler)
 
-		// 未来可以添加其他集群相关的路由:
-		// k8sClusterRoutes.GET("/:clusterId", api.GetK8sClusterHandler)
-		// k8sClusterRoutes.PUT("/:clusterId", api.UpdateK8sClusterHandler)
-		// k8sClusterRoutes.DELETE("/:clusterId", api.DeleteK8sClusterHandler)
-		// k8sClusterRoutes.POST("/:clusterId/test", api.TestK8sClusterConnectionHandler) // 前端已有mock
+		// 批量添加集群，以及从多上下文 kubeconfig 中选择上下文导入
+		k8sClusterRoutes.POST("/batch", kubeapi.BatchAddK8sClustersHandler)
+		k8sClusterRoutes.POST("/import/contexts", kubeapi.ListKubeConfigContextsHandler)
+		k8sClusterRoutes.POST("/import", kubeapi.ImportK8sClustersHandler)
+
+		// 密钥轮换后使用当前主密钥重新加密所有集群凭证
+		k8sClusterRoutes.POST("/reencrypt", kubeapi.ReencryptK8sClustersHandler)
+
+		// 单个集群的查询、更新、删除（?purge=true 彻底删除）和重新测试连接
+		k8sClusterRoutes.GET("/:clusterId", kubeapi.GetK8sClusterHandler)
+		k8sClusterRoutes.PUT("/:clusterId", kubeapi.UpdateK8sClusterHandler)
+		k8sClusterRoutes.DELETE("/:clusterId", kubeapi.DeleteK8sClusterHandler)
+		k8sClusterRoutes.POST("/:clusterId/test", kubeapi.TestK8sClusterConnectionHandler)
+
+		// 集群健康检查历史
+		k8sClusterRoutes.GET("/:clusterId/history", kubeapi.GetK8sClusterHistoryHandler)
 	}
 
 	kubernetesRoutes := r.Group("/kubernetes")
@@ -104,6 +115,59 @@ func SetupRouter() *gin.Engine {
 		// GET /kubernetes/namespaces - 获取所有命名空间
 		kubernetesRoutes.GET("/:clusterID/namespaces", kubeapi.GetNamespaces)
 		kubernetesRoutes.GET("/listpods", kubeapi.GetPodsInNamespace)
+
+		// 基于发现接口和动态客户端的通用资源接口，覆盖内置类型和 CRD
+		kubernetesRoutes.GET("/:clusterID/api-resources", kubeapi.ListAPIResourcesHandler)
+		kubernetesRoutes.GET("/:clusterID/namespaces/:namespace/:resourceType", kubeapi.ListResourcesHandler)
+		kubernetesRoutes.POST("/:clusterID/namespaces/:namespace/:resourceType", kubeapi.CreateResourceHandler)
+		kubernetesRoutes.GET("/:clusterID/namespaces/:namespace/:resourceType/:name", kubeapi.GetResourceHandler)
+		kubernetesRoutes.PUT("/:clusterID/namespaces/:namespace/:resourceType/:name", kubeapi.UpdateResourceHandler)
+		kubernetesRoutes.DELETE("/:clusterID/namespaces/:namespace/:resourceType/:name", kubeapi.DeleteResourceHandler)
+		// Pod 日志，支持 follow（SSE）、previous 和下载
+		kubernetesRoutes.GET("/:clusterID/namespaces/:namespace/:resourceType/:name/logs", kubeapi.PodLogsHandler)
+		// 资源对象的事件，以及工作负载的事件、发布和重启时间线
+		kubernetesRoutes.GET("/:clusterID/namespaces/:namespace/:resourceType/:name/events", kubeapi.ObjectEventsHandler)
+		kubernetesRoutes.GET("/:clusterID/namespaces/:namespace/:resourceType/:name/timeline", kubeapi.WorkloadTimelineHandler)
+		// 工作负载操作：扩缩容、滚动重启、修改镜像、版本回滚，操作记录包含操作人和前后差异
+		kubernetesRoutes.POST("/:clusterID/namespaces/:namespace/:resourceType/:name/scale", kubeapi.ScaleWorkloadHandler)
+		kubernetesRoutes.POST("/:clusterID/namespaces/:namespace/:resourceType/:name/restart", kubeapi.RestartWorkloadHandler)
+		kubernetesRoutes.POST("/:clusterID/namespaces/:namespace/:resourceType/:name/image", kubeapi.SetWorkloadImageHandler)
+		kubernetesRoutes.GET("/:clusterID/namespaces/:namespace/:resourceType/:name/revisions", kubeapi.ListWorkloadRevisionsHandler)
+		kubernetesRoutes.POST("/:clusterID/namespaces/:namespace/:resourceType/:name/rollback", kubeapi.RollbackWorkloadHandler)
+		kubernetesRoutes.GET("/:clusterID/namespaces/:namespace/:resourceType/:name/operations", kubeapi.ListWorkloadOperationsHandler)
+		// 集群级资源，以及跨所有命名空间列出命名空间级资源
+		kubernetesRoutes.GET("/:clusterID/resources/:resourceType", kubeapi.ListResourcesHandler)
+		kubernetesRoutes.POST("/:clusterID/resources/:resourceType", kubeapi.CreateResourceHandler)
+		kubernetesRoutes.GET("/:clusterID/resources/:resourceType/:name", kubeapi.GetResourceHandler)
+		kubernetesRoutes.PUT("/:clusterID/resources/:resourceType/:name", kubeapi.UpdateResourceHandler)
+		kubernetesRoutes.DELETE("/:clusterID/resources/:resourceType/:name", kubeapi.DeleteResourceHandler)
+		kubernetesRoutes.GET("/:clusterID/resources/:resourceType/:name/events", kubeapi.ObjectEventsHandler)
+
+		// 事件列表，Warning 事件单独计数便于排查
+		kubernetesRoutes.GET("/:clusterID/events", kubeapi.ListEventsHandler)
+		// 工作负载操作审计记录
+		kubernetesRoutes.GET("/:clusterID/operations", kubeapi.ListWorkloadOperationsHandler)
+
+		// 节点清单、封锁/解除封锁，以及基于 Eviction API 的异步排空
+		kubernetesRoutes.GET("/:clusterID/nodes", kubeapi.ListNodesHandler)
+		kubernetesRoutes.GET("/:clusterID/nodes/:node", kubeapi.GetNodeHandler)
+		kubernetesRoutes.POST("/:clusterID/nodes/:node/cordon", kubeapi.CordonNodeHandler)
+		kubernetesRoutes.POST("/:clusterID/nodes/:node/uncordon", kubeapi.UncordonNodeHandler)
+		kubernetesRoutes.POST("/:clusterID/nodes/:node/drain", kubeapi.DrainNodeHandler)
+		kubernetesRoutes.GET("/:clusterID/drains", kubeapi.ListDrainTasksHandler)
+		kubernetesRoutes.GET("/:clusterID/drains/:taskId", kubeapi.GetDrainTaskHandler)
+
+		// 按标签选择器聚合多个 Pod 的日志
+		kubernetesRoutes.GET("/:clusterID/logs", kubeapi.AggregateLogsHandler)
+
+		// Pod 交互式终端（WebSocket）及会话审计录像
+		kubernetesRoutes.GET("/:clusterID/terminal", kubeapi.PodTerminalHandler)
+		kubernetesRoutes.GET("/:clusterID/terminal/sessions", kubeapi.ListTerminalSessionsHandler)
+		kubernetesRoutes.GET("/:clusterID/terminal/sessions/:sessionId/recording", kubeapi.DownloadTerminalRecordingHandler)
+
+		// 基于 metrics.k8s.io 的节点和 Pod 资源使用量
+		kubernetesRoutes.GET("/:clusterID/top/nodes", kubeapi.TopNodesHandler)
+		kubernetesRoutes.GET("/:clusterID/top/pods", kubeapi.TopPodsHandler)
 		//kubernetesRoutes.GET("/namespaces/:namespace/services", kubernetes.GetServicesInNamespace)
 		//kubernetesRoutes.GET("/namespaces/:namespace/deployments", kubernetes.GetDeploymentsInNamespace)
 		//kubernetesRoutes.GET("/namespaces/:namespace/statefulsets", kubernetes.GetStatefulSetsInNamespace)
@@ -142,6 +206,20 @@ func SetupRouter() *gin.Engine {
 
 		// 数据对比
 		dataMigrateRoutes.POST("/compare", dataMigrateHandler.CompareHandler)
+
+		// 已保存的数据源连接
+		dataMigrateRoutes.GET("/connections", dataMigrateHandler.ListConnectionsHandler)
+		dataMigrateRoutes.POST("/connections", dataMigrateHandler.CreateConnectionHandler)
+		dataMigrateRoutes.GET("/connections/:connectionId", dataMigrateHandler.GetConnectionHandler)
+		dataMigrateRoutes.PUT("/connections/:connectionId", dataMigrateHandler.UpdateConnectionHandler)
+		dataMigrateRoutes.DELETE("/connections/:connectionId", dataMigrateHandler.DeleteConnectionHandler)
+		dataMigrateRoutes.POST("/connections/:connectionId/test", dataMigrateHandler.TestSavedConnectionHandler)
+
+		// 跨方言表结构转换预览
+		dataMigrateRoutes.POST("/schema/map", dataMigrateHandler.MapSchemaHandler)
+
+		// 连接池统计
+		dataMigrateRoutes.GET("/pool/stats", dataMigrateHandler.PoolStatsHandler)
 	}
 
 	// VMware 路由示例 (来自现有代码)
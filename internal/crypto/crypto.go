@@ -0,0 +1,211 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"opscore/config"
+)
+
+// 密文前缀，用于区分密文与历史遗留的明文。
+// v1 不记录密钥标识，解密时依次尝试所有密钥；v2 格式为 enc:v2:<keyID>:<base64>
+const (
+	encryptedPrefixV1 = "enc:v1:"
+	encryptedPrefixV2 = "enc:v2:"
+)
+
+// EnvEncryptionKey 主密钥环境变量，优先级高于配置文件
+const EnvEncryptionKey = "OPSCORE_ENCRYPTION_KEY"
+
+// EnvPreviousEncryptionKeys 轮换前使用过的旧主密钥，多个以逗号分隔，仅用于解密
+const EnvPreviousEncryptionKeys = "OPSCORE_PREVIOUS_ENCRYPTION_KEYS"
+
+// ErrKeyNotConfigured 未配置主密钥
+var ErrKeyNotConfigured = errors.New("encryption key is not configured, set " + EnvEncryptionKey + " or security.encryptionKey")
+
+// dataKey 由主密钥派生的 AES 密钥及其标识
+type dataKey struct {
+	id  string
+	key []byte
+}
+
+func deriveKey(secret string) dataKey {
+	// 对任意长度的主密钥做 SHA-256，得到 AES-256 所需的 32 字节密钥
+	sum := sha256.Sum256([]byte(secret))
+	// 密钥标识取派生密钥的摘要前缀，不会泄露密钥本身
+	idSum := sha256.Sum256(sum[:])
+	return dataKey{id: hex.EncodeToString(idSum[:4]), key: sum[:]}
+}
+
+// masterKey 获取当前主密钥，环境变量优先，其次为配置文件
+func masterKey() (dataKey, error) {
+	key := os.Getenv(EnvEncryptionKey)
+	if key == "" {
+		key = config.GetConfig().Security.EncryptionKey
+	}
+	if key == "" {
+		return dataKey{}, ErrKeyNotConfigured
+	}
+	return deriveKey(key), nil
+}
+
+// decryptionKeys 返回可用于解密的全部密钥，当前主密钥在前
+func decryptionKeys() ([]dataKey, error) {
+	current, err := masterKey()
+	if err != nil {
+		return nil, err
+	}
+	keys := []dataKey{current}
+	previous := config.GetConfig().Security.PreviousEncryptionKeys
+	if env := os.Getenv(EnvPreviousEncryptionKeys); env != "" {
+		previous = strings.Split(env, ",")
+	}
+	for _, secret := range previous {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			keys = append(keys, deriveKey(secret))
+		}
+	}
+	return keys, nil
+}
+
+// KeyID 返回当前主密钥的标识
+func KeyID() (string, error) {
+	key, err := masterKey()
+	if err != nil {
+		return "", err
+	}
+	return key.id, nil
+}
+
+// IsEncrypted 判断字符串是否为本包生成的密文
+func IsEncrypted(s string) bool {
+	return strings.HasPrefix(s, encryptedPrefixV1) || strings.HasPrefix(s, encryptedPrefixV2)
+}
+
+// NeedsReencrypt 判断值是否需要用当前主密钥重新加密：历史明文、v1 密文或由旧密钥加密的密文
+func NeedsReencrypt(s string) (bool, error) {
+	if s == "" {
+		return false, nil
+	}
+	if !strings.HasPrefix(s, encryptedPrefixV2) {
+		return true, nil
+	}
+	current, err := masterKey()
+	if err != nil {
+		return false, err
+	}
+	keyID, _, _ := strings.Cut(strings.TrimPrefix(s, encryptedPrefixV2), ":")
+	return keyID != current.id, nil
+}
+
+// Reencrypt 解密后使用当前主密钥重新加密，明文直接加密
+func Reencrypt(s string) (string, error) {
+	plaintext, err := Decrypt(s)
+	if err != nil {
+		return "", err
+	}
+	return Encrypt(plaintext)
+}
+
+// Encrypt 使用 AES-256-GCM 加密明文，返回带前缀的 base64 密文
+func Encrypt(plaintext string) (string, error) {
+	if plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+	key, err := masterKey()
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key.key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedPrefixV2 + key.id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 生成的密文；不带前缀的字符串视为历史明文原样返回。
+// 当前主密钥和旧密钥都可用于解密，便于密钥轮换期间平滑过渡
+func Decrypt(ciphertext string) (string, error) {
+	if !IsEncrypted(ciphertext) {
+		return ciphertext, nil
+	}
+	keys, err := decryptionKeys()
+	if err != nil {
+		return "", err
+	}
+
+	encoded := strings.TrimPrefix(ciphertext, encryptedPrefixV1)
+	if strings.HasPrefix(ciphertext, encryptedPrefixV2) {
+		keyID, rest, ok := strings.Cut(strings.TrimPrefix(ciphertext, encryptedPrefixV2), ":")
+		if !ok {
+			return "", errors.New("malformed ciphertext")
+		}
+		encoded = rest
+		keys = filterKeys(keys, keyID)
+		if len(keys) == 0 {
+			return "", fmt.Errorf("no encryption key with id %s, add it to %s", keyID, EnvPreviousEncryptionKeys)
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode ciphertext: %w", err)
+	}
+
+	var lastErr error
+	for _, key := range keys {
+		plaintext, err := open(key.key, data)
+		if err == nil {
+			return plaintext, nil
+		}
+		lastErr = err
+	}
+	return "", fmt.Errorf("failed to decrypt: %w", lastErr)
+}
+
+func filterKeys(keys []dataKey, id string) []dataKey {
+	var matched []dataKey
+	for _, k := range keys {
+		if k.id == id {
+			matched = append(matched, k)
+		}
+	}
+	return matched
+}
+
+func open(key, data []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
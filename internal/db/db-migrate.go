@@ -17,12 +17,42 @@ func Migrate() error {
 		return err
 	}
 
+	var h model.K8sClusterStatusHistory
+	if err := db.DB.AutoMigrate(&h); err != nil {
+		logger.Error("Failed to migrate cluster status history database", zap.Error(err))
+		return err
+	}
+
+	var ts model.K8sTerminalSession
+	if err := db.DB.AutoMigrate(&ts); err != nil {
+		logger.Error("Failed to migrate terminal session database", zap.Error(err))
+		return err
+	}
+
+	var wo model.K8sWorkloadOperation
+	if err := db.DB.AutoMigrate(&wo); err != nil {
+		logger.Error("Failed to migrate workload operation database", zap.Error(err))
+		return err
+	}
+
+	var dt model.K8sNodeDrainTask
+	if err := db.DB.AutoMigrate(&dt); err != nil {
+		logger.Error("Failed to migrate node drain task database", zap.Error(err))
+		return err
+	}
+
 	var m model.MigrationTask
 	if err := db.DB.AutoMigrate(&m); err != nil {
 		logger.Error("Failed to migrate migration task database", zap.Error(err))
 		return err
 	}
 
+	var conn model.DataSourceConnection
+	if err := db.DB.AutoMigrate(&conn); err != nil {
+		logger.Error("Failed to migrate datasource connection database", zap.Error(err))
+		return err
+	}
+
 	return nil
 
 }
@@ -57,3 +57,12 @@ func NewGlobalDB() (*DB, error) {
 func (d *DB) GetGlobalDB() *gorm.DB {
 	return DBInstance.DB
 }
+
+// TableName 返回模型对应的数据表名，解析失败时返回空字符串
+func (d *DB) TableName(value interface{}) string {
+	stmt := &gorm.Statement{DB: d.DB}
+	if err := stmt.Parse(value); err != nil {
+		return ""
+	}
+	return stmt.Schema.Table
+}
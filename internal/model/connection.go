@@ -0,0 +1,19 @@
+package model
+
+import "gorm.io/gorm"
+
+// DataSourceConnection 已保存的数据源连接配置，密码加密后存储
+type DataSourceConnection struct {
+	gorm.Model
+	ConnectionID string         `json:"connection_id" gorm:"uniqueIndex;type:varchar(255)"`
+	Name         string         `json:"name" gorm:"index;type:varchar(255)"`
+	Type         DataSourceType `json:"type"`
+	Host         string         `json:"host"`
+	Port         int            `json:"port"`
+	Database     string         `json:"database"`
+	Username     string         `json:"username"`
+	Password     string         `json:"-" gorm:"type:text"` // 加密后的密码，不参与 JSON 序列化
+	SSLMode      string         `json:"ssl_mode"`
+	Charset      string         `json:"charset"`
+	Comment      string         `json:"comment"`
+}
@@ -1,14 +1,89 @@
 package model // or the package where K8sClusterMetaData is defined
-import "gorm.io/gorm"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
 
 // K8sClusterMetaData 定义了存储在数据库中的Kubernetes集群元数据。
 type K8sClusterMetaData struct {
-	gorm.Model                    // 包含 ID, CreatedAt, UpdatedAt, DeletedAt
-	ClusterName string `json:"cluster_name"`
-	Comment     string `json:"comment"`
-	KubeConfig  string `json:"kube_config" gorm:"type:text"`    // 使用text类型存储可能较长的kubeconfig
-	ClusterID string `json:"cluster_id" gorm:"uniqueIndex;type:varchar(255)"`
-	Version     string `json:"version"`                       // Kubernetes版本
-	Status      string `json:"status"`                        // 集群状态，例如 "connected", "error"
+	gorm.Model               // 包含 ID, CreatedAt, UpdatedAt, DeletedAt
+	ClusterName   string     `json:"cluster_name"`
+	Comment       string     `json:"comment"`
+	KubeConfig    string     `json:"-" gorm:"type:text"`                // 加密后的kubeconfig，使用text类型存储
+	AuthType      string     `json:"auth_type" gorm:"type:varchar(32)"` // 认证方式，例如 "kubeconfig", "token", "serviceaccount", "incluster"
+	ClusterID     string     `json:"cluster_id" gorm:"uniqueIndex;type:varchar(255)"`
+	Version       string     `json:"version"`         // Kubernetes版本
+	Status        string     `json:"status"`          // 集群状态，例如 "connected", "degraded", "unreachable", "error"
+	LastCheckedAt *time.Time `json:"last_checked_at"` // 最近一次健康检查时间
+	CertExpiresAt *time.Time `json:"cert_expires_at"` // kubeconfig 客户端证书或令牌的过期时间
+}
+
+// K8sClusterStatusHistory 集群健康检查历史记录
+type K8sClusterStatusHistory struct {
+	gorm.Model
+	ClusterID     string     `json:"cluster_id" gorm:"index;type:varchar(255)"`
+	Status        string     `json:"status"`
+	Version       string     `json:"version"`
+	NodesTotal    int        `json:"nodes_total"`
+	NodesReady    int        `json:"nodes_ready"`
+	LatencyMs     int64      `json:"latency_ms"`
+	CertExpiresAt *time.Time `json:"cert_expires_at"`
+	Message       string     `json:"message" gorm:"type:text"`
+	CheckedAt     time.Time  `json:"checked_at" gorm:"index"`
+}
+
+// 终端会话、工作负载操作和节点排空记录中的 User 来自 BasicAuth 用户，或来自可信网关
+// （security.trustedProxies / security.proxySecret）透传的 X-Opscore-User、X-Forwarded-User 请求头。
+// 这些接口本身没有认证，未经可信网关的请求一律记为 anonymous，只能依据 ClientIP 追溯
+
+// K8sTerminalSession Pod 终端会话审计记录
+type K8sTerminalSession struct {
+	gorm.Model
+	SessionID     string     `json:"session_id" gorm:"uniqueIndex;type:varchar(64)"`
+	ClusterID     string     `json:"cluster_id" gorm:"index;type:varchar(255)"`
+	Namespace     string     `json:"namespace"`
+	Pod           string     `json:"pod"`
+	Container     string     `json:"container"`
+	Command       string     `json:"command"`
+	User          string     `json:"user" gorm:"index;type:varchar(255)"`
+	ClientIP      string     `json:"client_ip"`
+	RecordingPath string     `json:"-"`
+	StartedAt     time.Time  `json:"started_at" gorm:"index"`
+	EndedAt       *time.Time `json:"ended_at"`
+	ExitReason    string     `json:"exit_reason" gorm:"type:text"`
+}
+
+// K8sWorkloadOperation 工作负载操作审计记录，Diff 为操作前后 spec 的 unified diff
+type K8sWorkloadOperation struct {
+	gorm.Model
+	ClusterID string `json:"cluster_id" gorm:"index;type:varchar(255)"`
+	Namespace string `json:"namespace" gorm:"index"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name" gorm:"index"`
+	Action    string `json:"action"` // scale、restart、rollback、set-image
+	Params    string `json:"params" gorm:"type:text"`
+	User      string `json:"user"`
+	ClientIP  string `json:"client_ip"`
+	Diff      string `json:"diff" gorm:"type:text"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error" gorm:"type:text"`
+}
+
+// K8sNodeDrainTask 节点排空任务，Status 为 running、succeeded、failed 或 timeout
+type K8sNodeDrainTask struct {
+	gorm.Model
+	TaskID         string      `json:"task_id" gorm:"uniqueIndex;type:varchar(64)"`
+	ClusterID      string      `json:"cluster_id" gorm:"index;type:varchar(255)"`
+	NodeName       string      `json:"node_name" gorm:"index"`
+	Status         string      `json:"status"`
+	TotalPods      int         `json:"total_pods"`
+	EvictedPods    int         `json:"evicted_pods"`
+	PendingPods    StringSlice `json:"pending_pods" gorm:"type:json"` // 尚未完成驱逐的 Pod，格式 namespace/name
+	SkippedPods    StringSlice `json:"skipped_pods" gorm:"type:json"` // 跳过的 DaemonSet Pod 和静态 Pod
+	TimeoutSeconds int         `json:"timeout_seconds"`
+	User           string      `json:"user"`
+	StartTime      *time.Time  `json:"start_time"`
+	EndTime        *time.Time  `json:"end_time"`
+	ErrorMessage   string      `json:"error_message" gorm:"type:text"`
 }
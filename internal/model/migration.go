@@ -32,6 +32,29 @@ func (s StringSlice) Value() (driver.Value, error) {
 	return json.Marshal(s)
 }
 
+// StringMap 用于 GORM JSON 存储 map[string]string
+type StringMap map[string]string
+
+func (m *StringMap) Scan(value interface{}) error {
+	if value == nil {
+		*m = nil
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("Scan source is not []byte or string")
+		}
+		bytes = []byte(str)
+	}
+	return json.Unmarshal(bytes, m)
+}
+
+func (m StringMap) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
 type MigrationLog struct {
 	gorm.Model
 	TaskID    string    `json:"task_id"`
@@ -87,24 +110,29 @@ type MigrationSummary struct {
 // MigrationTask 迁移任务模型
 type MigrationTask struct {
 	gorm.Model
-	TaskID         string          `json:"task_id" gorm:"uniqueIndex;type:varchar(255)"`
-	SourceConfig   string          `json:"source_config" gorm:"type:text"`
-	TargetConfig   string          `json:"target_config" gorm:"type:text"`
-	Database       StringSlice     `json:"database" gorm:"type:json"`
-	Tables         string          `json:"tables" gorm:"type:json"`
-	Status         MigrationStatus `json:"status"`
-	Progress       float64         `json:"progress"` // 0-100
-	TotalRows      int64           `json:"total_rows"`
-	MigratedRows   int64           `json:"migrated_rows"`
-	FailedRows     int64           `json:"failed_rows"`
-	StartTime      *time.Time      `json:"start_time"`
-	EndTime        *time.Time      `json:"end_time"`
-	ErrorMessage   string          `json:"error_message"`
-	Logs           []string        `json:"logs" gorm:"type:text"`
-	BatchSize      int             `json:"batch_size"`
-	CreateSchema   bool            `json:"create_schema"`   // 是否创建表结构
-	TruncateTarget bool            `json:"truncate_target"` // 是否清空目标表
-	OnlySyncSchema bool            `json:"only_sync_schema"`
+	TaskID             string          `json:"task_id" gorm:"uniqueIndex;type:varchar(255)"`
+	SourceConnectionID string          `json:"source_connection_id"`           // 引用已保存的源连接
+	TargetConnectionID string          `json:"target_connection_id"`           // 引用已保存的目标连接
+	SourceConfig       string          `json:"source_config" gorm:"type:text"` // 内联配置，密码加密存储
+	TargetConfig       string          `json:"target_config" gorm:"type:text"`
+	Database           StringSlice     `json:"database" gorm:"type:json"`
+	Tables             string          `json:"tables" gorm:"type:json"`           // 包含规则，支持 db.table、glob、/regex/ 及 ! 排除
+	Exclude            StringSlice     `json:"exclude" gorm:"type:json"`          // 排除规则
+	DatabaseMapping    StringMap       `json:"database_mapping" gorm:"type:json"` // 源库到目标库的重命名映射
+	TypeOverrides      StringMap       `json:"type_overrides" gorm:"type:json"`   // 跨方言建表时用户指定的列类型
+	Status             MigrationStatus `json:"status"`
+	Progress           float64         `json:"progress"` // 0-100
+	TotalRows          int64           `json:"total_rows"`
+	MigratedRows       int64           `json:"migrated_rows"`
+	FailedRows         int64           `json:"failed_rows"`
+	StartTime          *time.Time      `json:"start_time"`
+	EndTime            *time.Time      `json:"end_time"`
+	ErrorMessage       string          `json:"error_message"`
+	Logs               []string        `json:"logs" gorm:"type:text"`
+	BatchSize          int             `json:"batch_size"`
+	CreateSchema       bool            `json:"create_schema"`   // 是否创建表结构
+	TruncateTarget     bool            `json:"truncate_target"` // 是否清空目标表
+	OnlySyncSchema     bool            `json:"only_sync_schema"`
 }
 
 // MigrationStatus 迁移任务状态
@@ -125,18 +153,20 @@ const (
 	DataSourceTypeMySQL      DataSourceType = "mysql"
 	DataSourceTypePostgreSQL DataSourceType = "postgresql"
 	DataSourceTypeMongoDB    DataSourceType = "mongodb"
+	DataSourceTypeSQLite     DataSourceType = "sqlite"
 	DataSourceTypeMinIO      DataSourceType = "minio"
 )
 
 // DataSourceConfig 数据源配置
 type DataSourceConfig struct {
-	Type     DataSourceType `json:"type"`
-	Host     string         `json:"host"`
-	Port     int            `json:"port"`
-	Database string         `json:"database"`
-	Username string         `json:"username"`
-	Password string         `json:"password"`
-	SSLMode  string         `json:"ssl_mode,omitempty"`
-	Charset  string         `json:"charset,omitempty"`
-	Timeout  time.Duration  `json:"timeout,omitempty"`
+	ConnectionID string         `json:"connection_id,omitempty"` // 已保存连接的ID，设置后其余字段以连接配置为准
+	Type         DataSourceType `json:"type"`
+	Host         string         `json:"host"`
+	Port         int            `json:"port"`
+	Database     string         `json:"database"`
+	Username     string         `json:"username"`
+	Password     string         `json:"password"`
+	SSLMode      string         `json:"ssl_mode,omitempty"`
+	Charset      string         `json:"charset,omitempty"`
+	Timeout      time.Duration  `json:"timeout,omitempty"`
 }
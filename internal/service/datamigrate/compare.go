@@ -0,0 +1,274 @@
+package datamigrate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	coreError "opscore/error"
+)
+
+const (
+	// CompareModeCount 只对比表是否存在和行数
+	CompareModeCount = "count"
+	// CompareModeDeep 在行数对比基础上按主键抽样对比字段
+	CompareModeDeep = "deep"
+
+	defaultCompareSampleSize = 100
+	maxCompareSampleSize     = 1000
+	defaultCompareMaxDiffs   = 50
+)
+
+// 抽样行的对比状态
+const (
+	RowDiffMissingInTarget = "missing_in_target"
+	RowDiffMissingInSource = "missing_in_source"
+	RowDiffMismatch        = "mismatch"
+)
+
+// ColumnRange 列在源库和目标库中的取值范围
+type ColumnRange struct {
+	Column    string      `json:"column"`
+	SourceMin interface{} `json:"source_min"`
+	SourceMax interface{} `json:"source_max"`
+	TargetMin interface{} `json:"target_min"`
+	TargetMax interface{} `json:"target_max"`
+	Equal     bool        `json:"equal"`
+}
+
+// FieldDiff 单个字段的差异
+type FieldDiff struct {
+	Column string      `json:"column"`
+	Source interface{} `json:"source"`
+	Target interface{} `json:"target"`
+}
+
+// RowDiff 单行的差异
+type RowDiff struct {
+	Key    map[string]interface{} `json:"key"`
+	Status string                 `json:"status"`
+	Fields []FieldDiff            `json:"fields,omitempty"`
+}
+
+// DeepCompareResult 单表深度对比报告
+type DeepCompareResult struct {
+	PrimaryKey      []string      `json:"primary_key"`
+	SampledSource   int           `json:"sampled_source"`
+	SampledTarget   int           `json:"sampled_target"`
+	MatchedRows     int           `json:"matched_rows"`
+	MismatchedRows  int           `json:"mismatched_rows"`
+	MissingInTarget int           `json:"missing_in_target"`
+	MissingInSource int           `json:"missing_in_source"`
+	KeyRanges       []ColumnRange `json:"key_ranges"`
+	TimestampRanges []ColumnRange `json:"timestamp_ranges"`
+	Diffs           []RowDiff     `json:"diffs"`
+	Truncated       bool          `json:"truncated"` // 差异行超过 max_diffs，只返回了前一部分
+	Error           string        `json:"error,omitempty"`
+}
+
+// DeepCompareOptions 深度对比参数
+type DeepCompareOptions struct {
+	SourceDB   string
+	TargetDB   string
+	Table      string
+	SampleSize int
+	MaxDiffs   int
+	// SourceRows/TargetRows 为已统计的行数，用于估算抽样比例
+	SourceRows int64
+	TargetRows int64
+}
+
+// DeepCompareTable 按主键从源表和目标表各抽样一批行，逐字段对比，
+// 并统计主键列和时间列的最小/最大值
+func (s *MigrationService) DeepCompareTable(sourceDS, targetDS DataSource, opts DeepCompareOptions) (*DeepCompareResult, error) {
+	src, ok := sourceDS.(RowSampler)
+	if !ok {
+		return nil, coreError.ErrCompareNotSupported
+	}
+	tgt, ok := targetDS.(RowSampler)
+	if !ok {
+		return nil, coreError.ErrCompareNotSupported
+	}
+	if opts.SampleSize <= 0 {
+		opts.SampleSize = defaultCompareSampleSize
+	}
+	if opts.SampleSize > maxCompareSampleSize {
+		opts.SampleSize = maxCompareSampleSize
+	}
+	if opts.MaxDiffs <= 0 {
+		opts.MaxDiffs = defaultCompareMaxDiffs
+	}
+	if opts.TargetDB == "" {
+		opts.TargetDB = opts.SourceDB
+	}
+
+	keyCols, err := src.GetPrimaryKey(opts.SourceDB, opts.Table)
+	if err != nil {
+		return nil, err
+	}
+	if len(keyCols) == 0 {
+		return nil, coreError.ErrNoPrimaryKey
+	}
+	result := &DeepCompareResult{PrimaryKey: keyCols}
+
+	// 主键和时间列的取值范围
+	for _, col := range keyCols {
+		result.KeyRanges = append(result.KeyRanges, compareColumnRange(src, tgt, opts, col))
+	}
+	tsCols, err := src.GetTimestampColumns(opts.SourceDB, opts.Table)
+	if err != nil {
+		return nil, err
+	}
+	for _, col := range tsCols {
+		result.TimestampRanges = append(result.TimestampRanges, compareColumnRange(src, tgt, opts, col))
+	}
+
+	// 源库抽样，按主键到目标库查找对应行
+	srcSample, err := src.SampleRows(opts.SourceDB, opts.Table, keyCols, opts.SampleSize, opts.SourceRows)
+	if err != nil {
+		return nil, err
+	}
+	tgtMatched, err := tgt.ReadRowsByKeys(opts.TargetDB, opts.Table, keyCols, rowKeys(srcSample, keyCols))
+	if err != nil {
+		return nil, err
+	}
+	result.SampledSource = len(srcSample)
+	tgtIndex := indexRows(tgtMatched, keyCols)
+	for _, row := range srcSample {
+		target, found := tgtIndex[rowKeyString(row, keyCols)]
+		if !found {
+			result.MissingInTarget++
+			result.addDiff(opts.MaxDiffs, RowDiff{Key: rowKeyMap(row, keyCols), Status: RowDiffMissingInTarget})
+			continue
+		}
+		fields := diffRow(row, target)
+		if len(fields) == 0 {
+			result.MatchedRows++
+			continue
+		}
+		result.MismatchedRows++
+		result.addDiff(opts.MaxDiffs, RowDiff{Key: rowKeyMap(row, keyCols), Status: RowDiffMismatch, Fields: fields})
+	}
+
+	// 目标库抽样，只检查源库中是否存在，字段差异已在上一步覆盖
+	tgtSample, err := tgt.SampleRows(opts.TargetDB, opts.Table, keyCols, opts.SampleSize, opts.TargetRows)
+	if err != nil {
+		return nil, err
+	}
+	srcMatched, err := src.ReadRowsByKeys(opts.SourceDB, opts.Table, keyCols, rowKeys(tgtSample, keyCols))
+	if err != nil {
+		return nil, err
+	}
+	result.SampledTarget = len(tgtSample)
+	srcIndex := indexRows(srcMatched, keyCols)
+	for _, row := range tgtSample {
+		if _, found := srcIndex[rowKeyString(row, keyCols)]; !found {
+			result.MissingInSource++
+			result.addDiff(opts.MaxDiffs, RowDiff{Key: rowKeyMap(row, keyCols), Status: RowDiffMissingInSource})
+		}
+	}
+
+	return result, nil
+}
+
+// addDiff 记录差异行，超过上限时只标记截断
+func (r *DeepCompareResult) addDiff(limit int, diff RowDiff) {
+	if len(r.Diffs) >= limit {
+		r.Truncated = true
+		return
+	}
+	r.Diffs = append(r.Diffs, diff)
+}
+
+// compareColumnRange 统计列在两端的取值范围，单端查询失败时对应值留空
+func compareColumnRange(src, tgt RowSampler, opts DeepCompareOptions, column string) ColumnRange {
+	cr := ColumnRange{Column: column}
+	if minVal, maxVal, err := src.GetColumnRange(opts.SourceDB, opts.Table, column); err == nil {
+		cr.SourceMin, cr.SourceMax = normalizeValue(minVal), normalizeValue(maxVal)
+	}
+	if minVal, maxVal, err := tgt.GetColumnRange(opts.TargetDB, opts.Table, column); err == nil {
+		cr.TargetMin, cr.TargetMax = normalizeValue(minVal), normalizeValue(maxVal)
+	}
+	cr.Equal = valueString(cr.SourceMin) == valueString(cr.TargetMin) && valueString(cr.SourceMax) == valueString(cr.TargetMax)
+	return cr
+}
+
+// diffRow 逐字段对比两行，返回按列名排序的差异
+func diffRow(source, target Row) []FieldDiff {
+	columns := make(map[string]struct{}, len(source))
+	for col := range source {
+		columns[col] = struct{}{}
+	}
+	for col := range target {
+		columns[col] = struct{}{}
+	}
+
+	var diffs []FieldDiff
+	for col := range columns {
+		sv, tv := normalizeValue(source[col]), normalizeValue(target[col])
+		if valueString(sv) != valueString(tv) {
+			diffs = append(diffs, FieldDiff{Column: col, Source: sv, Target: tv})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Column < diffs[j].Column })
+	return diffs
+}
+
+// rowKeys 提取行的主键值，作为 ReadRowsByKeys 的参数
+func rowKeys(rows []Row, keyCols []string) [][]interface{} {
+	keys := make([][]interface{}, 0, len(rows))
+	for _, row := range rows {
+		key := make([]interface{}, len(keyCols))
+		for i, col := range keyCols {
+			key[i] = row[col]
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// indexRows 按主键字符串索引行
+func indexRows(rows []Row, keyCols []string) map[string]Row {
+	index := make(map[string]Row, len(rows))
+	for _, row := range rows {
+		index[rowKeyString(row, keyCols)] = row
+	}
+	return index
+}
+
+func rowKeyString(row Row, keyCols []string) string {
+	parts := make([]string, len(keyCols))
+	for i, col := range keyCols {
+		parts[i] = valueString(normalizeValue(row[col]))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func rowKeyMap(row Row, keyCols []string) map[string]interface{} {
+	key := make(map[string]interface{}, len(keyCols))
+	for _, col := range keyCols {
+		key[col] = normalizeValue(row[col])
+	}
+	return key
+}
+
+// normalizeValue 统一驱动返回的值类型，便于比较和 JSON 输出
+func normalizeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case []byte:
+		return string(val)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	default:
+		return val
+	}
+}
+
+// valueString 将归一化后的值转为可比较的字符串，NULL 与空字符串区分开
+func valueString(v interface{}) string {
+	if v == nil {
+		return "\x00NULL"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,403 @@
+package datamigrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	coreError "opscore/error"
+	"opscore/internal/crypto"
+	"opscore/internal/db"
+	"opscore/internal/log"
+	"opscore/internal/model"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// MaskedPassword API 响应中替代真实密码的占位符
+const MaskedPassword = "******"
+
+// ConnectionRequest 创建或更新数据源连接的请求
+type ConnectionRequest struct {
+	Name     string               `json:"name"`
+	Type     model.DataSourceType `json:"type"`
+	Host     string               `json:"host"`
+	Port     int                  `json:"port"`
+	Database string               `json:"database"`
+	Username string               `json:"username"`
+	Password string               `json:"password"` // 更新时为空或为占位符表示保持原密码不变
+	SSLMode  string               `json:"ssl_mode"`
+	Charset  string               `json:"charset"`
+	Comment  string               `json:"comment"`
+}
+
+// ConnectionResponse 数据源连接响应，密码已脱敏
+type ConnectionResponse struct {
+	ConnectionID string               `json:"connection_id"`
+	Name         string               `json:"name"`
+	Type         model.DataSourceType `json:"type"`
+	Host         string               `json:"host"`
+	Port         int                  `json:"port"`
+	Database     string               `json:"database"`
+	Username     string               `json:"username"`
+	Password     string               `json:"password"`
+	SSLMode      string               `json:"ssl_mode"`
+	Charset      string               `json:"charset"`
+	Comment      string               `json:"comment"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+}
+
+// ToConnectionResponse 将连接模型转换为脱敏后的响应
+func ToConnectionResponse(conn *model.DataSourceConnection) ConnectionResponse {
+	resp := ConnectionResponse{
+		ConnectionID: conn.ConnectionID,
+		Name:         conn.Name,
+		Type:         conn.Type,
+		Host:         conn.Host,
+		Port:         conn.Port,
+		Database:     conn.Database,
+		Username:     conn.Username,
+		SSLMode:      conn.SSLMode,
+		Charset:      conn.Charset,
+		Comment:      conn.Comment,
+		CreatedAt:    conn.CreatedAt,
+		UpdatedAt:    conn.UpdatedAt,
+	}
+	if conn.Password != "" {
+		resp.Password = MaskedPassword
+	}
+	return resp
+}
+
+// CreateConnection 保存新的数据源连接，密码加密后入库
+func (s *MigrationService) CreateConnection(req *ConnectionRequest) (*model.DataSourceConnection, error) {
+	if req.Name == "" || req.Type == "" || req.Host == "" {
+		return nil, coreError.ErrInvalidConfig
+	}
+	if err := s.checkConnectionName(req.Name, ""); err != nil {
+		return nil, err
+	}
+
+	password, err := crypto.Encrypt(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt password: %w", err)
+	}
+
+	conn := &model.DataSourceConnection{
+		ConnectionID: uuid.New().String(),
+		Name:         req.Name,
+		Type:         req.Type,
+		Host:         req.Host,
+		Port:         req.Port,
+		Database:     req.Database,
+		Username:     req.Username,
+		Password:     password,
+		SSLMode:      req.SSLMode,
+		Charset:      req.Charset,
+		Comment:      req.Comment,
+	}
+	if err := s.db.Create(conn).Error; err != nil {
+		return nil, fmt.Errorf("failed to create connection: %w", err)
+	}
+
+	s.logger.Info("Created datasource connection", zap.String("connection_id", conn.ConnectionID), zap.String("name", conn.Name))
+	return conn, nil
+}
+
+// ListConnections 列出所有已保存的数据源连接
+func (s *MigrationService) ListConnections() ([]model.DataSourceConnection, error) {
+	var conns []model.DataSourceConnection
+	if err := s.db.Order("name ASC").Find(&conns).Error; err != nil {
+		return nil, fmt.Errorf("failed to list connections: %w", err)
+	}
+	return conns, nil
+}
+
+// GetConnection 根据连接ID获取数据源连接
+func (s *MigrationService) GetConnection(connectionID string) (*model.DataSourceConnection, error) {
+	var conn model.DataSourceConnection
+	if err := s.db.Where("connection_id = ?", connectionID).First(&conn).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, coreError.ErrConnectionNotFound
+		}
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	return &conn, nil
+}
+
+// UpdateConnection 更新数据源连接，密码为空或为占位符时保留原密码
+func (s *MigrationService) UpdateConnection(connectionID string, req *ConnectionRequest) (*model.DataSourceConnection, error) {
+	conn, err := s.GetConnection(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name == "" || req.Type == "" || req.Host == "" {
+		return nil, coreError.ErrInvalidConfig
+	}
+	if err := s.checkConnectionName(req.Name, connectionID); err != nil {
+		return nil, err
+	}
+
+	conn.Name = req.Name
+	conn.Type = req.Type
+	conn.Host = req.Host
+	conn.Port = req.Port
+	conn.Database = req.Database
+	conn.Username = req.Username
+	conn.SSLMode = req.SSLMode
+	conn.Charset = req.Charset
+	conn.Comment = req.Comment
+	if req.Password != "" && req.Password != MaskedPassword {
+		password, err := crypto.Encrypt(req.Password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt password: %w", err)
+		}
+		conn.Password = password
+	}
+
+	if err := s.db.Save(conn).Error; err != nil {
+		return nil, fmt.Errorf("failed to update connection: %w", err)
+	}
+	s.Connections.Invalidate(connectionID)
+
+	s.logger.Info("Updated datasource connection", zap.String("connection_id", conn.ConnectionID), zap.String("name", conn.Name))
+	return conn, nil
+}
+
+// DeleteConnection 删除数据源连接
+func (s *MigrationService) DeleteConnection(connectionID string) error {
+	conn, err := s.GetConnection(connectionID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(conn).Error; err != nil {
+		return fmt.Errorf("failed to delete connection: %w", err)
+	}
+	s.Connections.Invalidate(connectionID)
+	s.logger.Info("Deleted datasource connection", zap.String("connection_id", connectionID))
+	return nil
+}
+
+// checkConnectionName 检查连接名称是否已被其他连接占用
+func (s *MigrationService) checkConnectionName(name, excludeID string) error {
+	var count int64
+	query := s.db.Model(&model.DataSourceConnection{}).Where("name = ?", name)
+	if excludeID != "" {
+		query = query.Where("connection_id <> ?", excludeID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check connection name: %w", err)
+	}
+	if count > 0 {
+		return coreError.ErrConnectionNameExists
+	}
+	return nil
+}
+
+// ResolveDataSourceConfig 解析数据源配置：引用了已保存连接时加载连接并解密密码，
+// 请求中显式指定的 Database 优先于连接中保存的默认库
+func (s *MigrationService) ResolveDataSourceConfig(cfg DataSourceConfig) (DataSourceConfig, error) {
+	if cfg.ConnectionID == "" {
+		password, err := crypto.Decrypt(cfg.Password)
+		if err != nil {
+			return cfg, fmt.Errorf("failed to decrypt password: %w", err)
+		}
+		cfg.Password = password
+		return cfg, nil
+	}
+
+	conn, err := s.GetConnection(cfg.ConnectionID)
+	if err != nil {
+		return cfg, err
+	}
+	password, err := crypto.Decrypt(conn.Password)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to decrypt password: %w", err)
+	}
+
+	resolved := DataSourceConfig{
+		ConnectionID: conn.ConnectionID,
+		Type:         DataSourceType(conn.Type),
+		Host:         conn.Host,
+		Port:         conn.Port,
+		Database:     conn.Database,
+		Username:     conn.Username,
+		Password:     password,
+		SSLMode:      conn.SSLMode,
+		Charset:      conn.Charset,
+		Timeout:      cfg.Timeout,
+	}
+	if cfg.Database != "" {
+		resolved.Database = cfg.Database
+	}
+	return resolved, nil
+}
+
+// sealTaskConfig 生成任务中持久化的数据源配置：
+// 引用连接时只保存不含密码的连接快照，内联配置则加密密码后保存
+func (s *MigrationService) sealTaskConfig(connectionID string, cfg model.DataSourceConfig) (string, string, error) {
+	if connectionID == "" {
+		connectionID = cfg.ConnectionID
+	}
+
+	if connectionID != "" {
+		conn, err := s.GetConnection(connectionID)
+		if err != nil {
+			return "", "", err
+		}
+		snapshot := model.DataSourceConfig{
+			ConnectionID: conn.ConnectionID,
+			Type:         conn.Type,
+			Host:         conn.Host,
+			Port:         conn.Port,
+			Database:     conn.Database,
+			Username:     conn.Username,
+			SSLMode:      conn.SSLMode,
+			Charset:      conn.Charset,
+			Timeout:      cfg.Timeout,
+		}
+		if cfg.Database != "" {
+			snapshot.Database = cfg.Database
+		}
+		data, _ := json.Marshal(snapshot)
+		return connectionID, string(data), nil
+	}
+
+	password, err := crypto.Encrypt(cfg.Password)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt password: %w", err)
+	}
+	cfg.Password = password
+	data, _ := json.Marshal(cfg)
+	return "", string(data), nil
+}
+
+// MaskTask 返回密码已脱敏的任务副本，用于 API 响应
+func MaskTask(task *model.MigrationTask) *model.MigrationTask {
+	masked := *task
+	masked.SourceConfig = maskConfigJSON(task.SourceConfig)
+	masked.TargetConfig = maskConfigJSON(task.TargetConfig)
+	return &masked
+}
+
+// maskConfigJSON 将配置 JSON 中的密码替换为占位符
+func maskConfigJSON(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	var cfg model.DataSourceConfig
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return ""
+	}
+	if cfg.Password != "" {
+		cfg.Password = MaskedPassword
+	}
+	data, _ := json.Marshal(cfg)
+	return string(data)
+}
+
+// fromModelConfig 将持久化的 model.DataSourceConfig 转换为数据源使用的配置
+func fromModelConfig(cfg model.DataSourceConfig) DataSourceConfig {
+	return DataSourceConfig{
+		ConnectionID: cfg.ConnectionID,
+		Type:         DataSourceType(cfg.Type),
+		Host:         cfg.Host,
+		Port:         cfg.Port,
+		Database:     cfg.Database,
+		Username:     cfg.Username,
+		Password:     cfg.Password,
+		SSLMode:      cfg.SSLMode,
+		Charset:      cfg.Charset,
+		Timeout:      cfg.Timeout,
+	}
+}
+
+// ReencryptCredentials 使用当前主密钥重新加密数据源连接密码和任务内联配置中的密码，
+// 包括软删除的记录，返回按表统计的处理数量。密钥轮换后与集群凭证一起调用，完成后即可移除旧密钥
+func ReencryptCredentials() (map[string]int, error) {
+	logger := log.GetLogger()
+	gdb := db.DBInstance.DB.Unscoped().Session(&gorm.Session{})
+	counts := map[string]int{}
+
+	var conns []model.DataSourceConnection
+	if err := gdb.Find(&conns).Error; err != nil {
+		return counts, err
+	}
+	connTable := db.DBInstance.TableName(&model.DataSourceConnection{})
+	counts[connTable] = 0
+	for i := range conns {
+		conn := &conns[i]
+		needed, err := crypto.NeedsReencrypt(conn.Password)
+		if err != nil {
+			return counts, err
+		}
+		if !needed {
+			continue
+		}
+		encrypted, err := crypto.Reencrypt(conn.Password)
+		if err != nil {
+			return counts, fmt.Errorf("failed to re-encrypt password of connection %s: %w", conn.ConnectionID, err)
+		}
+		if err := gdb.Model(conn).UpdateColumn("password", encrypted).Error; err != nil {
+			return counts, err
+		}
+		counts[connTable]++
+	}
+
+	var tasks []model.MigrationTask
+	if err := gdb.Select("id", "task_id", "source_config", "target_config").Find(&tasks).Error; err != nil {
+		return counts, err
+	}
+	taskTable := db.DBInstance.TableName(&model.MigrationTask{})
+	counts[taskTable] = 0
+	for i := range tasks {
+		task := &tasks[i]
+		updates := map[string]interface{}{}
+		for column, raw := range map[string]string{"source_config": task.SourceConfig, "target_config": task.TargetConfig} {
+			sealed, changed, err := reencryptConfigJSON(raw)
+			if err != nil {
+				return counts, fmt.Errorf("failed to re-encrypt %s of task %s: %w", column, task.TaskID, err)
+			}
+			if changed {
+				updates[column] = sealed
+			}
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		if err := gdb.Model(task).UpdateColumns(updates).Error; err != nil {
+			return counts, err
+		}
+		counts[taskTable]++
+	}
+
+	logger.Info("已重新加密数据源凭证", zap.Any("counts", counts))
+	return counts, nil
+}
+
+// reencryptConfigJSON 重新加密任务配置 JSON 中的密码，引用连接的配置不含密码，无需处理
+func reencryptConfigJSON(raw string) (string, bool, error) {
+	if raw == "" {
+		return raw, false, nil
+	}
+	var cfg model.DataSourceConfig
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return raw, false, nil
+	}
+	needed, err := crypto.NeedsReencrypt(cfg.Password)
+	if err != nil || !needed {
+		return raw, false, err
+	}
+	if cfg.Password, err = crypto.Reencrypt(cfg.Password); err != nil {
+		return raw, false, err
+	}
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return raw, false, err
+	}
+	return string(data), true, nil
+}
@@ -1,6 +1,7 @@
 package datamigrate
 
 import (
+	"database/sql"
 	coreError "opscore/error"
 	"opscore/internal/model"
 	"time"
@@ -13,37 +14,58 @@ const (
 	DataSourceTypeMySQL      DataSourceType = "mysql"
 	DataSourceTypePostgreSQL DataSourceType = "postgresql"
 	DataSourceTypeMongoDB    DataSourceType = "mongodb"
+	DataSourceTypeSQLite     DataSourceType = "sqlite"
 	DataSourceTypeMinIO      DataSourceType = "minio"
 )
 
 // DataSourceConfig 数据源配置
 type DataSourceConfig struct {
-	Type     DataSourceType `json:"type"`
-	Host     string         `json:"host"`
-	Port     int            `json:"port"`
-	Database string         `json:"database"`
-	Username string         `json:"username"`
-	Password string         `json:"password"`
-	SSLMode  string         `json:"ssl_mode,omitempty"`
-	Charset  string         `json:"charset,omitempty"`
-	Timeout  time.Duration  `json:"timeout,omitempty"`
+	ConnectionID string         `json:"connection_id,omitempty"` // 已保存连接的ID，设置后其余字段以连接配置为准
+	Type         DataSourceType `json:"type"`
+	Host         string         `json:"host"`
+	Port         int            `json:"port"`
+	Database     string         `json:"database"`
+	Username     string         `json:"username"`
+	Password     string         `json:"password"`
+	SSLMode      string         `json:"ssl_mode,omitempty"`
+	Charset      string         `json:"charset,omitempty"`
+	Timeout      time.Duration  `json:"timeout,omitempty"`
+	MaxOpenConns int            `json:"-"` // 连接池最大打开连接数，0 表示使用默认值
+	MaxIdleConns int            `json:"-"` // 连接池最大空闲连接数，0 表示使用默认值
 }
 
 // ColumnInfo 列信息
 type ColumnInfo struct {
-	Name         string `json:"name"`
-	Type         string `json:"type"`
-	IsNullable   bool   `json:"is_nullable"`
-	DefaultValue string `json:"default_value"`
-	Comment      string `json:"comment"`
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	IsNullable    bool   `json:"is_nullable"`
+	DefaultValue  string `json:"default_value"`
+	Comment       string `json:"comment"`
+	AutoIncrement bool   `json:"auto_increment,omitempty"`
+	OnUpdate      string `json:"on_update,omitempty"` // 行更新时自动写入的表达式，如 CURRENT_TIMESTAMP
+}
+
+// IndexColumn 索引中的一列，Length 为前缀索引长度，0 表示整列
+type IndexColumn struct {
+	Name   string `json:"name"`
+	Length int    `json:"length,omitempty"`
+}
+
+// IndexInfo 主键以外的索引
+type IndexInfo struct {
+	Name    string        `json:"name"`
+	Columns []IndexColumn `json:"columns"`
+	Unique  bool          `json:"unique"`
+	Type    string        `json:"type,omitempty"` // FULLTEXT、SPATIAL 等特殊索引类型，普通索引为空
 }
 
 // TableSchema 表结构信息
 type TableSchema struct {
-	Name    string       `json:"name"`
-	Columns []ColumnInfo `json:"columns"`
-	Indexes []string     `json:"indexes"`
-	Comment string       `json:"comment"`
+	Name       string       `json:"name"`
+	Columns    []ColumnInfo `json:"columns"`
+	Indexes    []IndexInfo  `json:"indexes"`
+	Comment    string       `json:"comment"`
+	PrimaryKey []string     `json:"primary_key,omitempty"`
 }
 
 // Row 数据行
@@ -99,10 +121,30 @@ type DataSource interface {
 
 	// Close 关闭连接
 	Close() error
+}
 
+// PoolStatsProvider 可上报底层连接池统计信息的数据源
+type PoolStatsProvider interface {
+	PoolStats() sql.DBStats
 }
 
+// RowSampler 支持按主键抽样读取的数据源，用于深度数据对比
+type RowSampler interface {
+	// GetPrimaryKey 获取主键列
+	GetPrimaryKey(database, table string) ([]string, error)
 
+	// GetTimestampColumns 获取日期时间类型的列
+	GetTimestampColumns(database, table string) ([]string, error)
+
+	// GetColumnRange 获取列的最小值和最大值
+	GetColumnRange(database, table, column string) (interface{}, interface{}, error)
+
+	// SampleRows 按主键顺序抽样读取行
+	SampleRows(database, table string, keyColumns []string, limit int, total int64) ([]Row, error)
+
+	// ReadRowsByKeys 按主键值读取行
+	ReadRowsByKeys(database, table string, keyColumns []string, keys [][]interface{}) ([]Row, error)
+}
 
 // DataSourceFactory 数据源工厂
 type DataSourceFactory struct{}
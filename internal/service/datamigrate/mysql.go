@@ -49,8 +49,15 @@ func (m *MySQLDataSource) Connect(config DataSourceConfig) error {
 		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
 
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
+	maxIdle, maxOpen := 10, 100
+	if config.MaxIdleConns > 0 {
+		maxIdle = config.MaxIdleConns
+	}
+	if config.MaxOpenConns > 0 {
+		maxOpen = config.MaxOpenConns
+	}
+	sqlDB.SetMaxIdleConns(maxIdle)
+	sqlDB.SetMaxOpenConns(maxOpen)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
 	m.db = db
@@ -92,21 +99,132 @@ func (m *MySQLDataSource) ListTables(database string) ([]string, error) {
 	return tables, nil
 }
 
-// GetTableSchema 获取表结构
+// GetTableSchema 获取表结构，表不存在时返回 ErrTableNotFound
 func (m *MySQLDataSource) GetTableSchema(database, table string) (*TableSchema, error) {
-	// 直接用 SHOW CREATE TABLE 判断表是否存在
-	var tableName, createSQL string
-	row := m.db.Raw(fmt.Sprintf("SHOW CREATE TABLE `%s`.`%s`", database, table)).Row()
-	if err := row.Scan(&tableName, &createSQL); err != nil {
-		return nil, fmt.Errorf("failed to get table DDL: %w", err)
+	var columns []struct {
+		ColumnName    string
+		ColumnType    string
+		IsNullable    string
+		ColumnDefault sql.NullString
+		ColumnComment string
+		ColumnKey     string
+		Extra         string
+		DataType      string
+	}
+	err := m.db.Raw(`SELECT COLUMN_NAME AS column_name, COLUMN_TYPE AS column_type, IS_NULLABLE AS is_nullable,
+		COLUMN_DEFAULT AS column_default, COLUMN_COMMENT AS column_comment, COLUMN_KEY AS column_key,
+		EXTRA AS extra, DATA_TYPE AS data_type
+		FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION`, database, table).Scan(&columns).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table columns: %w", err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: %s.%s", coreError.ErrTableNotFound, database, table)
 	}
-	// 这里只返回 TableSchema 的 name 字段，详细解析 DDL 可后续扩展
+
 	schema := &TableSchema{
-		Name: table,
+		Name:    table,
+		Columns: make([]ColumnInfo, 0, len(columns)),
+	}
+	for _, col := range columns {
+		extra := strings.ToLower(col.Extra)
+		info := ColumnInfo{
+			Name:          col.ColumnName,
+			Type:          col.ColumnType,
+			IsNullable:    col.IsNullable == "YES",
+			DefaultValue:  mysqlDefaultExpr(col.ColumnDefault, col.Extra, col.DataType),
+			Comment:       col.ColumnComment,
+			AutoIncrement: strings.Contains(extra, "auto_increment"),
+		}
+		if idx := strings.Index(extra, "on update "); idx >= 0 {
+			info.OnUpdate = col.Extra[idx+len("on update "):]
+		}
+		schema.Columns = append(schema.Columns, info)
 	}
+
+	pk, err := m.GetPrimaryKey(database, table)
+	if err != nil {
+		return nil, err
+	}
+	schema.PrimaryKey = pk
+
+	indexes, err := m.getIndexes(database, table)
+	if err != nil {
+		return nil, err
+	}
+	schema.Indexes = indexes
+
+	var comment string
+	m.db.Raw("SELECT TABLE_COMMENT FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", database, table).Scan(&comment)
+	schema.Comment = comment
 	return schema, nil
 }
 
+// getIndexes 返回主键以外的索引，按索引名和列序号排列。
+// MySQL 8.0 的函数索引没有列名，无法用列定义还原，跳过
+func (m *MySQLDataSource) getIndexes(database, table string) ([]IndexInfo, error) {
+	var stats []struct {
+		IndexName  string
+		NonUnique  int
+		ColumnName sql.NullString
+		SubPart    sql.NullInt64
+		IndexType  string
+	}
+	err := m.db.Raw(`SELECT INDEX_NAME AS index_name, NON_UNIQUE AS non_unique, COLUMN_NAME AS column_name,
+		SUB_PART AS sub_part, INDEX_TYPE AS index_type
+		FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND INDEX_NAME <> 'PRIMARY'
+		ORDER BY INDEX_NAME, SEQ_IN_INDEX`, database, table).Scan(&stats).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get table indexes: %w", err)
+	}
+
+	var indexes []IndexInfo
+	skipped := map[string]bool{}
+	for _, st := range stats {
+		if !st.ColumnName.Valid {
+			skipped[st.IndexName] = true
+			continue
+		}
+		if len(indexes) == 0 || indexes[len(indexes)-1].Name != st.IndexName {
+			info := IndexInfo{Name: st.IndexName, Unique: st.NonUnique == 0}
+			if st.IndexType == "FULLTEXT" || st.IndexType == "SPATIAL" {
+				info.Type = st.IndexType
+			}
+			indexes = append(indexes, info)
+		}
+		last := &indexes[len(indexes)-1]
+		last.Columns = append(last.Columns, IndexColumn{Name: st.ColumnName.String, Length: int(st.SubPart.Int64)})
+	}
+
+	result := indexes[:0]
+	for _, idx := range indexes {
+		if !skipped[idx.Name] {
+			result = append(result, idx)
+		}
+	}
+	return result, nil
+}
+
+// mysqlDefaultExpr 将 information_schema 中的默认值还原为可直接写入 DDL 的表达式
+func mysqlDefaultExpr(def sql.NullString, extra, dataType string) string {
+	if !def.Valid {
+		return ""
+	}
+	upper := strings.ToUpper(def.String)
+	if strings.HasPrefix(upper, "CURRENT_TIMESTAMP") || upper == "NULL" {
+		return def.String
+	}
+	if strings.Contains(strings.ToUpper(extra), "DEFAULT_GENERATED") {
+		return "(" + def.String + ")"
+	}
+	switch dataType {
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint", "decimal", "float", "double", "bit":
+		return def.String
+	}
+	return "'" + strings.ReplaceAll(def.String, "'", "''") + "'"
+}
+
 // ReadRows 读取数据行
 func (m *MySQLDataSource) ReadRows(database, table string, opts ReadOptions) ([]Row, error) {
 	query := fmt.Sprintf("SELECT * FROM `%s`.`%s`", database, table)
@@ -123,6 +241,11 @@ func (m *MySQLDataSource) ReadRows(database, table string, opts ReadOptions) ([]
 	}
 	defer rows.Close()
 
+	return scanRows(rows)
+}
+
+// scanRows 将查询结果扫描为 Row 列表，NULL 值不写入 Row
+func scanRows(rows *sql.Rows) ([]Row, error) {
 	columns, err := rows.Columns()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get columns: %w", err)
@@ -155,7 +278,7 @@ func (m *MySQLDataSource) ReadRows(database, table string, opts ReadOptions) ([]
 		result = append(result, row)
 	}
 
-	return result, nil
+	return result, rows.Err()
 }
 
 // WriteRows写入数据行
@@ -260,12 +383,26 @@ func (m *MySQLDataSource) CreateTable(database string, schema *TableSchema) erro
 			def += fmt.Sprintf(" DEFAULT %s", col.DefaultValue)
 		}
 
+		if col.OnUpdate != "" {
+			def += " ON UPDATE " + col.OnUpdate
+		}
+
+		if col.AutoIncrement {
+			def += " AUTO_INCREMENT"
+		}
+
 		if col.Comment != "" {
-			def += fmt.Sprintf(" COMMENT '%s'", col.Comment)
+			def += fmt.Sprintf(" COMMENT '%s'", strings.ReplaceAll(col.Comment, "'", "''"))
 		}
 
 		columnDefs[i] = def
 	}
+	if len(schema.PrimaryKey) > 0 {
+		columnDefs = append(columnDefs, fmt.Sprintf("PRIMARY KEY (%s)", quoteColumns(schema.PrimaryKey)))
+	}
+	for _, idx := range schema.Indexes {
+		columnDefs = append(columnDefs, indexDefinition(idx))
+	}
 
 	query := fmt.Sprintf("CREATE TABLE `%s`.`%s` (\n  %s\n)",
 		database,
@@ -274,7 +411,7 @@ func (m *MySQLDataSource) CreateTable(database string, schema *TableSchema) erro
 	)
 
 	if schema.Comment != "" {
-		query += fmt.Sprintf(" COMMENT='%s'", schema.Comment)
+		query += fmt.Sprintf(" COMMENT='%s'", strings.ReplaceAll(schema.Comment, "'", "''"))
 	}
 
 	err := m.db.Exec(query).Error
@@ -285,6 +422,25 @@ func (m *MySQLDataSource) CreateTable(database string, schema *TableSchema) erro
 	return nil
 }
 
+// indexDefinition 生成 CREATE TABLE 中的索引定义
+func indexDefinition(idx IndexInfo) string {
+	kind := "KEY"
+	switch {
+	case idx.Type != "":
+		kind = idx.Type + " KEY"
+	case idx.Unique:
+		kind = "UNIQUE KEY"
+	}
+	cols := make([]string, len(idx.Columns))
+	for i, col := range idx.Columns {
+		cols[i] = "`" + col.Name + "`"
+		if col.Length > 0 {
+			cols[i] += fmt.Sprintf("(%d)", col.Length)
+		}
+	}
+	return fmt.Sprintf("%s `%s` (%s)", kind, idx.Name, strings.Join(cols, ", "))
+}
+
 // DropTable 删除表
 func (m *MySQLDataSource) DropTable(database, table string) error {
 	query := fmt.Sprintf("DROP TABLE IF EXISTS `%s`.`%s`", database, table)
@@ -375,6 +531,18 @@ func (m *MySQLDataSource) Close() error {
 	return nil
 }
 
+// PoolStats 返回底层连接池统计信息
+func (m *MySQLDataSource) PoolStats() sql.DBStats {
+	if m.db == nil {
+		return sql.DBStats{}
+	}
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+	return sqlDB.Stats()
+}
+
 // getCharset 获取字符集
 func (m *MySQLDataSource) getCharset() string {
 	if m.config.Charset != "" {
@@ -410,3 +578,91 @@ func (m *MySQLDataSource) GetTableColumns(database, table string) ([]string, err
 	}
 	return columns, nil
 }
+
+// GetPrimaryKey 返回表的主键列，按主键定义顺序排列
+func (m *MySQLDataSource) GetPrimaryKey(database, table string) ([]string, error) {
+	var columns []string
+	err := m.db.Raw(`SELECT COLUMN_NAME FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND CONSTRAINT_NAME = 'PRIMARY'
+		ORDER BY ORDINAL_POSITION`, database, table).Scan(&columns).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get primary key: %w", err)
+	}
+	return columns, nil
+}
+
+// GetTimestampColumns 返回表中的日期时间类型列
+func (m *MySQLDataSource) GetTimestampColumns(database, table string) ([]string, error) {
+	var columns []string
+	err := m.db.Raw(`SELECT COLUMN_NAME FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND DATA_TYPE IN ('datetime', 'timestamp', 'date')
+		ORDER BY ORDINAL_POSITION`, database, table).Scan(&columns).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get timestamp columns: %w", err)
+	}
+	return columns, nil
+}
+
+// GetColumnRange 返回列的最小值和最大值
+func (m *MySQLDataSource) GetColumnRange(database, table, column string) (interface{}, interface{}, error) {
+	query := fmt.Sprintf("SELECT MIN(`%s`), MAX(`%s`) FROM `%s`.`%s`", column, column, database, table)
+	var minVal, maxVal interface{}
+	if err := m.db.Raw(query).Row().Scan(&minVal, &maxVal); err != nil {
+		return nil, nil, fmt.Errorf("failed to get range of %s: %w", column, err)
+	}
+	return minVal, maxVal, nil
+}
+
+// SampleRows 在整个表中均匀随机抽样 limit 行并按主键排序返回，total 为表的总行数，用于估算抽样比例
+func (m *MySQLDataSource) SampleRows(database, table string, keyColumns []string, limit int, total int64) ([]Row, error) {
+	query := fmt.Sprintf("SELECT * FROM `%s`.`%s`", database, table)
+	var args []interface{}
+	if total > int64(limit) {
+		// 按两倍比例过采样保证行数足够，再在过采样结果中随机截取 limit 行，
+		// 避免直接按主键截取导致只抽到主键区间的前半部分
+		ratio := float64(limit) * 2 / float64(total)
+		query = fmt.Sprintf("SELECT * FROM (%s WHERE RAND() < ? ORDER BY RAND() LIMIT ?) AS sampled", query)
+		args = append(args, ratio, limit)
+	}
+	query += fmt.Sprintf(" ORDER BY %s LIMIT ?", quoteColumns(keyColumns))
+	args = append(args, limit)
+
+	rows, err := m.db.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to sample rows: %w", err)
+	}
+	defer rows.Close()
+	return scanRows(rows)
+}
+
+// ReadRowsByKeys 按主键值批量读取行
+func (m *MySQLDataSource) ReadRowsByKeys(database, table string, keyColumns []string, keys [][]interface{}) ([]Row, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	tuple := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(keyColumns)), ", ") + ")"
+	placeholders := make([]string, len(keys))
+	args := make([]interface{}, 0, len(keys)*len(keyColumns))
+	for i, key := range keys {
+		placeholders[i] = tuple
+		args = append(args, key...)
+	}
+	query := fmt.Sprintf("SELECT * FROM `%s`.`%s` WHERE (%s) IN (%s)",
+		database, table, quoteColumns(keyColumns), strings.Join(placeholders, ", "))
+
+	rows, err := m.db.Raw(query, args...).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rows by keys: %w", err)
+	}
+	defer rows.Close()
+	return scanRows(rows)
+}
+
+// quoteColumns 将列名用反引号包裹并以逗号连接
+func quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = "`" + col + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,338 @@
+package datamigrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"opscore/config"
+	"opscore/internal/log"
+	"opscore/internal/model"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPoolMaxOpenConns = 5
+	defaultPoolMaxIdleConns = 2
+	defaultPoolIdleTimeout  = 5 * time.Minute
+	defaultPoolMaxEntries   = 32
+	// poolHealthCheckInterval 复用连接前距离上次健康检查超过该间隔则重新 Ping
+	poolHealthCheckInterval = 30 * time.Second
+)
+
+// pooledDataSource 连接管理器中缓存的一个数据源
+type pooledDataSource struct {
+	key         string
+	ds          DataSource
+	config      DataSourceConfig
+	inUse       int
+	stale       bool // 连接配置已变更，归还后关闭
+	createdAt   time.Time
+	lastUsed    time.Time
+	lastChecked time.Time
+}
+
+// PoolEntryStats 单个缓存数据源的统计信息
+type PoolEntryStats struct {
+	ConnectionID    string               `json:"connection_id,omitempty"`
+	Type            model.DataSourceType `json:"type"`
+	Host            string               `json:"host"`
+	Port            int                  `json:"port"`
+	Username        string               `json:"username"`
+	InUse           int                  `json:"in_use"`
+	OpenConnections int                  `json:"open_connections"`
+	IdleConnections int                  `json:"idle_connections"`
+	WaitCount       int64                `json:"wait_count"`
+	CreatedAt       time.Time            `json:"created_at"`
+	LastUsed        time.Time            `json:"last_used"`
+}
+
+// PoolStats 连接管理器整体统计信息
+type PoolStats struct {
+	Entries              []PoolEntryStats `json:"entries"`
+	TotalOpenConnections int              `json:"total_open_connections"`
+	MaxEntries           int              `json:"max_entries"`
+	MaxOpenConnsPerEntry int              `json:"max_open_conns_per_entry"`
+	IdleTimeout          string           `json:"idle_timeout"`
+	Hits                 int64            `json:"hits"`
+	Misses               int64            `json:"misses"`
+	Evictions            int64            `json:"evictions"`
+	HealthCheckFailures  int64            `json:"health_check_failures"`
+}
+
+// ConnectionManager 按连接配置缓存数据源，供浏览库表、数据对比等 API 复用，
+// 避免每次请求都新建并关闭到生产库的连接
+type ConnectionManager struct {
+	mu      sync.Mutex
+	entries map[string]*pooledDataSource
+	factory *DataSourceFactory
+	logger  *zap.Logger
+
+	maxOpenConns int
+	maxIdleConns int
+	maxEntries   int
+	idleTimeout  time.Duration
+
+	hits                int64
+	misses              int64
+	evictions           int64
+	healthCheckFailures int64
+
+	stopCh chan struct{}
+}
+
+// NewConnectionManager 创建连接管理器并启动空闲回收
+func NewConnectionManager(factory *DataSourceFactory) *ConnectionManager {
+	cfg := config.GetConfig().DataMigrate
+	m := &ConnectionManager{
+		entries:      make(map[string]*pooledDataSource),
+		factory:      factory,
+		logger:       log.GetLogger(),
+		maxOpenConns: defaultPoolMaxOpenConns,
+		maxIdleConns: defaultPoolMaxIdleConns,
+		maxEntries:   defaultPoolMaxEntries,
+		idleTimeout:  defaultPoolIdleTimeout,
+		stopCh:       make(chan struct{}),
+	}
+	if cfg.PoolMaxOpenConns > 0 {
+		m.maxOpenConns = cfg.PoolMaxOpenConns
+	}
+	if cfg.PoolMaxIdleConns > 0 {
+		m.maxIdleConns = cfg.PoolMaxIdleConns
+	}
+	if cfg.PoolMaxEntries > 0 {
+		m.maxEntries = cfg.PoolMaxEntries
+	}
+	if cfg.PoolIdleTimeoutSec > 0 {
+		m.idleTimeout = time.Duration(cfg.PoolIdleTimeoutSec) * time.Second
+	}
+
+	go m.evictLoop()
+	return m
+}
+
+// Acquire 获取一个已连接的数据源，使用完毕后必须调用返回的 release 函数归还，
+// 调用方不应自行 Close 返回的数据源
+func (m *ConnectionManager) Acquire(cfg DataSourceConfig) (DataSource, func(), error) {
+	key := poolKey(cfg)
+
+	m.mu.Lock()
+	entry, ok := m.entries[key]
+	if ok && !entry.stale {
+		entry.inUse++
+		needCheck := time.Since(entry.lastChecked) > poolHealthCheckInterval
+		m.hits++
+		m.mu.Unlock()
+
+		if !needCheck {
+			return entry.ds, m.releaseFunc(entry), nil
+		}
+		err := entry.ds.TestConnection()
+		m.mu.Lock()
+		if err == nil {
+			entry.lastChecked = time.Now()
+			m.mu.Unlock()
+			return entry.ds, m.releaseFunc(entry), nil
+		}
+		m.logger.Warn("Pooled datasource failed health check, reconnecting",
+			zap.String("host", cfg.Host), zap.Int("port", cfg.Port), zap.Error(err))
+		m.healthCheckFailures++
+		entry.inUse--
+		m.removeLocked(entry)
+		m.mu.Unlock()
+	} else {
+		m.misses++
+		m.mu.Unlock()
+	}
+
+	// 新建连接放在锁外，避免慢连接阻塞其他请求
+	ds, err := m.factory.NewDataSource(model.DataSourceType(cfg.Type))
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg.MaxOpenConns = m.maxOpenConns
+	cfg.MaxIdleConns = m.maxIdleConns
+	if err := ds.Connect(cfg); err != nil {
+		ds.Close()
+		return nil, nil, err
+	}
+	if err := ds.TestConnection(); err != nil {
+		ds.Close()
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	entry = &pooledDataSource{
+		key:         key,
+		ds:          ds,
+		config:      cfg,
+		inUse:       1,
+		createdAt:   now,
+		lastUsed:    now,
+		lastChecked: now,
+	}
+
+	m.mu.Lock()
+	if existing, ok := m.entries[key]; ok && !existing.stale {
+		// 并发请求已经建立了相同的连接，复用已有的并关闭新建的
+		existing.inUse++
+		m.mu.Unlock()
+		ds.Close()
+		return existing.ds, m.releaseFunc(existing), nil
+	}
+	m.entries[key] = entry
+	m.evictOverflowLocked()
+	m.mu.Unlock()
+
+	m.logger.Info("Opened pooled datasource", zap.String("host", cfg.Host), zap.Int("port", cfg.Port), zap.String("connection_id", cfg.ConnectionID))
+	return ds, m.releaseFunc(entry), nil
+}
+
+// Invalidate 使引用指定已保存连接的缓存失效，正在使用的连接在归还后关闭
+func (m *ConnectionManager) Invalidate(connectionID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, entry := range m.entries {
+		if entry.config.ConnectionID == connectionID {
+			m.removeLocked(entry)
+		}
+	}
+}
+
+// Stats 返回连接管理器的统计信息
+func (m *ConnectionManager) Stats() PoolStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := PoolStats{
+		MaxEntries:           m.maxEntries,
+		MaxOpenConnsPerEntry: m.maxOpenConns,
+		IdleTimeout:          m.idleTimeout.String(),
+		Hits:                 m.hits,
+		Misses:               m.misses,
+		Evictions:            m.evictions,
+		HealthCheckFailures:  m.healthCheckFailures,
+		Entries:              make([]PoolEntryStats, 0, len(m.entries)),
+	}
+	for _, entry := range m.entries {
+		es := PoolEntryStats{
+			ConnectionID: entry.config.ConnectionID,
+			Type:         model.DataSourceType(entry.config.Type),
+			Host:         entry.config.Host,
+			Port:         entry.config.Port,
+			Username:     entry.config.Username,
+			InUse:        entry.inUse,
+			CreatedAt:    entry.createdAt,
+			LastUsed:     entry.lastUsed,
+		}
+		if p, ok := entry.ds.(PoolStatsProvider); ok {
+			dbStats := p.PoolStats()
+			es.OpenConnections = dbStats.OpenConnections
+			es.IdleConnections = dbStats.Idle
+			es.WaitCount = dbStats.WaitCount
+		}
+		stats.TotalOpenConnections += es.OpenConnections
+		stats.Entries = append(stats.Entries, es)
+	}
+	sort.Slice(stats.Entries, func(i, j int) bool {
+		return stats.Entries[i].LastUsed.After(stats.Entries[j].LastUsed)
+	})
+	return stats
+}
+
+// Close 停止空闲回收并关闭所有缓存连接
+func (m *ConnectionManager) Close() {
+	close(m.stopCh)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, entry := range m.entries {
+		m.removeLocked(entry)
+	}
+}
+
+// releaseFunc 生成归还连接的函数，重复调用无副作用
+func (m *ConnectionManager) releaseFunc(entry *pooledDataSource) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			entry.inUse--
+			entry.lastUsed = time.Now()
+			if entry.stale && entry.inUse <= 0 {
+				m.closeEntry(entry)
+			}
+		})
+	}
+}
+
+// evictLoop 定期回收空闲超时的连接
+func (m *ConnectionManager) evictLoop() {
+	ticker := time.NewTicker(m.idleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.mu.Lock()
+			for _, entry := range m.entries {
+				if entry.inUse <= 0 && time.Since(entry.lastUsed) > m.idleTimeout {
+					m.removeLocked(entry)
+					m.evictions++
+				}
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// evictOverflowLocked 缓存数量超过上限时回收最久未使用的空闲连接，调用方需持有锁
+func (m *ConnectionManager) evictOverflowLocked() {
+	for len(m.entries) > m.maxEntries {
+		var oldest *pooledDataSource
+		for _, entry := range m.entries {
+			if entry.inUse > 0 {
+				continue
+			}
+			if oldest == nil || entry.lastUsed.Before(oldest.lastUsed) {
+				oldest = entry
+			}
+		}
+		if oldest == nil {
+			// 全部在使用中，暂时允许超出上限
+			return
+		}
+		m.removeLocked(oldest)
+		m.evictions++
+	}
+}
+
+// removeLocked 从缓存中移除连接，未在使用的立即关闭，调用方需持有锁
+func (m *ConnectionManager) removeLocked(entry *pooledDataSource) {
+	if current, ok := m.entries[entry.key]; ok && current == entry {
+		delete(m.entries, entry.key)
+	}
+	entry.stale = true
+	if entry.inUse <= 0 {
+		m.closeEntry(entry)
+	}
+}
+
+func (m *ConnectionManager) closeEntry(entry *pooledDataSource) {
+	if err := entry.ds.Close(); err != nil {
+		m.logger.Warn("Failed to close pooled datasource", zap.String("host", entry.config.Host), zap.Error(err))
+	}
+}
+
+// poolKey 根据连接参数生成缓存键，密码参与计算，连接配置变更后自然对应新的缓存项
+func poolKey(cfg DataSourceConfig) string {
+	raw := fmt.Sprintf("%s|%s|%s|%d|%s|%s|%s|%s|%s",
+		cfg.ConnectionID, cfg.Type, cfg.Host, cfg.Port, cfg.Username, cfg.Password, cfg.Database, cfg.Charset, cfg.SSLMode)
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
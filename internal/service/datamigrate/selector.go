@@ -0,0 +1,236 @@
+package datamigrate
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// systemDatabases 通配库名时默认跳过的系统库
+var systemDatabases = map[string]struct{}{
+	"information_schema": {},
+	"performance_schema": {},
+	"mysql":              {},
+	"sys":                {},
+}
+
+// TableRef 解析后的待迁移表，包含源库与目标库
+type TableRef struct {
+	SourceDB string `json:"source_db"`
+	Table    string `json:"table"`
+	TargetDB string `json:"target_db"`
+}
+
+// tablePattern 表选择规则，格式为 [库.]表：
+// 库、表部分支持 glob 通配（orders_*），表部分以 /.../ 包裹时按正则匹配，
+// 省略库名时作用于任务显式选择的所有库
+type tablePattern struct {
+	raw     string
+	dbGlob  string
+	tblGlob string
+	tblRe   *regexp.Regexp
+}
+
+// parseTablePattern 解析单条表选择规则
+func parseTablePattern(raw string) (*tablePattern, error) {
+	p := &tablePattern{raw: raw}
+	tablePart := raw
+	if !strings.HasPrefix(raw, "/") {
+		if parts := strings.SplitN(raw, ".", 2); len(parts) == 2 {
+			p.dbGlob = parts[0]
+			tablePart = parts[1]
+		}
+	}
+	if tablePart == "" {
+		return nil, fmt.Errorf("invalid table pattern: %s", raw)
+	}
+
+	if len(tablePart) > 1 && strings.HasPrefix(tablePart, "/") && strings.HasSuffix(tablePart, "/") {
+		re, err := regexp.Compile(tablePart[1 : len(tablePart)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid table regex %s: %w", raw, err)
+		}
+		p.tblRe = re
+	} else {
+		if _, err := path.Match(tablePart, ""); err != nil {
+			return nil, fmt.Errorf("invalid table glob %s: %w", raw, err)
+		}
+		p.tblGlob = tablePart
+	}
+	if p.dbGlob != "" {
+		if _, err := path.Match(p.dbGlob, ""); err != nil {
+			return nil, fmt.Errorf("invalid database glob %s: %w", raw, err)
+		}
+	}
+	return p, nil
+}
+
+// hasDBGlob 库名部分是否包含通配符
+func (p *tablePattern) hasDBGlob() bool {
+	return p.dbGlob != "" && isGlob(p.dbGlob)
+}
+
+// matchDB 判断库名是否匹配；未指定库名的规则只匹配显式选择的库
+func (p *tablePattern) matchDB(db string, explicit bool) bool {
+	if p.dbGlob == "" {
+		return explicit
+	}
+	ok, _ := path.Match(p.dbGlob, db)
+	return ok
+}
+
+// match 判断 db.table 是否匹配该规则
+func (p *tablePattern) match(db, table string, explicit bool) bool {
+	if !p.matchDB(db, explicit) {
+		return false
+	}
+	if p.tblRe != nil {
+		return p.tblRe.MatchString(table)
+	}
+	ok, _ := path.Match(p.tblGlob, table)
+	return ok
+}
+
+func isGlob(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// TableSelector 根据库列表、包含/排除规则和库名映射解析出要迁移的表
+type TableSelector struct {
+	Databases       []string
+	Include         []string
+	Exclude         []string
+	DatabaseMapping map[string]string
+}
+
+// Resolve 在源数据源上展开规则，返回排序后的待迁移表列表，以及未匹配到任何表的包含规则
+func (sel *TableSelector) Resolve(ds DataSource) ([]TableRef, []string, error) {
+	var includes, excludes []*tablePattern
+	for _, raw := range sel.Include {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		// 以 ! 开头的规则视为排除规则，例如 !*_bak
+		target := &includes
+		if strings.HasPrefix(raw, "!") {
+			raw = strings.TrimPrefix(raw, "!")
+			target = &excludes
+		}
+		p, err := parseTablePattern(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		*target = append(*target, p)
+	}
+	for _, raw := range sel.Exclude {
+		raw = strings.TrimPrefix(strings.TrimSpace(raw), "!")
+		if raw == "" {
+			continue
+		}
+		p, err := parseTablePattern(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		excludes = append(excludes, p)
+	}
+
+	// 候选库：显式选择的库 + 包含规则中出现的库
+	explicitDBs := make(map[string]struct{})
+	for _, db := range sel.Databases {
+		if db = strings.TrimSpace(db); db != "" {
+			explicitDBs[db] = struct{}{}
+		}
+	}
+	candidates := make(map[string]struct{})
+	for db := range explicitDBs {
+		candidates[db] = struct{}{}
+	}
+	var needAllDBs bool
+	for _, p := range includes {
+		if p.hasDBGlob() {
+			needAllDBs = true
+		} else if p.dbGlob != "" {
+			candidates[p.dbGlob] = struct{}{}
+		}
+	}
+	if needAllDBs {
+		allDBs, err := ds.ListDatabases()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list databases: %w", err)
+		}
+		for _, db := range allDBs {
+			if _, system := systemDatabases[db]; system {
+				continue
+			}
+			for _, p := range includes {
+				if p.hasDBGlob() && p.matchDB(db, false) {
+					candidates[db] = struct{}{}
+					break
+				}
+			}
+		}
+	}
+
+	dbNames := make([]string, 0, len(candidates))
+	for db := range candidates {
+		dbNames = append(dbNames, db)
+	}
+	sort.Strings(dbNames)
+
+	matched := make(map[*tablePattern]bool)
+	var refs []TableRef
+	for _, db := range dbNames {
+		tables, err := ds.ListTables(db)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list tables of %s: %w", db, err)
+		}
+		_, explicit := explicitDBs[db]
+		sort.Strings(tables)
+		for _, table := range tables {
+			included := len(includes) == 0 && explicit
+			for _, p := range includes {
+				if p.match(db, table, explicit) {
+					included = true
+					matched[p] = true
+				}
+			}
+			if !included {
+				continue
+			}
+			excluded := false
+			for _, p := range excludes {
+				if p.match(db, table, true) {
+					excluded = true
+					break
+				}
+			}
+			if excluded {
+				continue
+			}
+			refs = append(refs, TableRef{
+				SourceDB: db,
+				Table:    table,
+				TargetDB: sel.targetDB(db),
+			})
+		}
+	}
+
+	var unmatched []string
+	for _, p := range includes {
+		if !matched[p] {
+			unmatched = append(unmatched, p.raw)
+		}
+	}
+	return refs, unmatched, nil
+}
+
+// targetDB 返回源库对应的目标库名，未配置映射时与源库同名
+func (sel *TableSelector) targetDB(sourceDB string) string {
+	if dst, ok := sel.DatabaseMapping[sourceDB]; ok && dst != "" {
+		return dst
+	}
+	return sourceDB
+}
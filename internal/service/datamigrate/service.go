@@ -24,15 +24,19 @@ type MigrationService struct {
 	Tasks     map[string]*model.MigrationTask
 	taskMutex sync.RWMutex
 	Factory   *DataSourceFactory
+	// Connections 供浏览库表、数据对比等短请求复用的连接管理器
+	Connections *ConnectionManager
 }
 
 // NewMigrationService 创建迁移服务实例
 func NewMigrationService() *MigrationService {
+	factory := &DataSourceFactory{}
 	return &MigrationService{
-		db:      db.DBInstance.DB,
-		logger:  log.GetLogger(),
-		Tasks:   make(map[string]*model.MigrationTask),
-		Factory: &DataSourceFactory{},
+		db:          db.DBInstance.DB,
+		logger:      log.GetLogger(),
+		Tasks:       make(map[string]*model.MigrationTask),
+		Factory:     factory,
+		Connections: NewConnectionManager(factory),
 	}
 }
 
@@ -53,9 +57,18 @@ func (s *MigrationService) CreateMigrationTask(req *CreateMigrationRequest) (*mo
 			dbSet[db] = struct{}{}
 		}
 	}
-	// 2. 从 req.Tables 里提取 db.table
+	// 2. 从 req.Databases 提取
+	for _, db := range req.Databases {
+		if db = strings.TrimSpace(db); db != "" {
+			dbSet[db] = struct{}{}
+		}
+	}
+	// 3. 从 req.Tables 里提取 db.table，带通配符的库名在执行时展开
 	for _, t := range req.Tables {
-		if parts := strings.SplitN(t, ".", 2); len(parts) == 2 {
+		if strings.HasPrefix(t, "!") || strings.HasPrefix(t, "/") {
+			continue
+		}
+		if parts := strings.SplitN(t, ".", 2); len(parts) == 2 && !isGlob(parts[0]) {
 			dbSet[parts[0]] = struct{}{}
 		}
 	}
@@ -65,21 +78,32 @@ func (s *MigrationService) CreateMigrationTask(req *CreateMigrationRequest) (*mo
 		dbList = append(dbList, db)
 	}
 
-	// 创建任务
-	sourceConfigStr, _ := json.Marshal(req.SourceConfig)
-	targetConfigStr, _ := json.Marshal(req.TargetConfig)
+	// 创建任务，数据源密码不以明文落库
+	sourceConnID, sourceConfigStr, err := s.sealTaskConfig(req.SourceConnectionID, req.SourceConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source config: %w", err)
+	}
+	targetConnID, targetConfigStr, err := s.sealTaskConfig(req.TargetConnectionID, req.TargetConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target config: %w", err)
+	}
 	task := &model.MigrationTask{
-		TaskID:         taskID,
-		SourceConfig:   string(sourceConfigStr),
-		TargetConfig:   string(targetConfigStr),
-		Database:       dbList,
-		Tables:         string(tablesJson),
-		Status:         model.MigrationStatusPending,
-		Progress:       0,
-		BatchSize:      req.BatchSize,
-		CreateSchema:   req.CreateSchema,
-		TruncateTarget: req.TruncateTarget,
-		OnlySyncSchema: req.OnlySyncSchema,
+		TaskID:             taskID,
+		SourceConnectionID: sourceConnID,
+		TargetConnectionID: targetConnID,
+		SourceConfig:       sourceConfigStr,
+		TargetConfig:       targetConfigStr,
+		Database:           dbList,
+		Tables:             string(tablesJson),
+		Exclude:            req.Exclude,
+		DatabaseMapping:    req.DatabaseMapping,
+		TypeOverrides:      req.TypeOverrides,
+		Status:             model.MigrationStatusPending,
+		Progress:           0,
+		BatchSize:          req.BatchSize,
+		CreateSchema:       req.CreateSchema,
+		TruncateTarget:     req.TruncateTarget,
+		OnlySyncSchema:     req.OnlySyncSchema,
 	}
 
 	// 保存到数据库
@@ -175,28 +199,16 @@ func (s *MigrationService) executeMigration(taskID string) {
 	}
 	defer targetDS.Close()
 
-	// 连接数据源
-	localSrcCfg := DataSourceConfig{
-		Type:     DataSourceType(srcCfg.Type),
-		Host:     srcCfg.Host,
-		Port:     srcCfg.Port,
-		Database: srcCfg.Database,
-		Username: srcCfg.Username,
-		Password: srcCfg.Password,
-		SSLMode:  srcCfg.SSLMode,
-		Charset:  srcCfg.Charset,
-		Timeout:  srcCfg.Timeout,
-	}
-	localTgtCfg := DataSourceConfig{
-		Type:     DataSourceType(tgtCfg.Type),
-		Host:     tgtCfg.Host,
-		Port:     tgtCfg.Port,
-		Database: tgtCfg.Database,
-		Username: tgtCfg.Username,
-		Password: tgtCfg.Password,
-		SSLMode:  tgtCfg.SSLMode,
-		Charset:  tgtCfg.Charset,
-		Timeout:  tgtCfg.Timeout,
+	// 连接数据源，解析已保存连接并解密密码
+	localSrcCfg, err := s.ResolveDataSourceConfig(fromModelConfig(srcCfg))
+	if err != nil {
+		s.updateTaskStatus(taskID, model.MigrationStatusFailed, fmt.Sprintf("Failed to resolve source config: %v", err))
+		return
+	}
+	localTgtCfg, err := s.ResolveDataSourceConfig(fromModelConfig(tgtCfg))
+	if err != nil {
+		s.updateTaskStatus(taskID, model.MigrationStatusFailed, fmt.Sprintf("Failed to resolve target config: %v", err))
+		return
 	}
 
 	if err := sourceDS.Connect(localSrcCfg); err != nil {
@@ -205,7 +217,7 @@ func (s *MigrationService) executeMigration(taskID string) {
 	}
 
 	if err := targetDS.Connect(localTgtCfg); err != nil {
-		s.logger.Error("Failed to connect target", zap.Error(err), zap.Any("localTgtCfg", localTgtCfg))
+		s.logger.Error("Failed to connect target", zap.Error(err), zap.String("host", localTgtCfg.Host), zap.String("database", localTgtCfg.Database))
 		if strings.Contains(err.Error(), "Unknown database") {
 			// 自动创建数据库
 			if localTgtCfg.Database == "" {
@@ -237,37 +249,36 @@ func (s *MigrationService) executeMigration(taskID string) {
 		return
 	}
 
-	// 获取要迁移的表列表
-	var tables []string
+	// 按库列表、包含/排除规则解析要迁移的表
+	var include []string
 	if task.Tables != "" {
-		if err := json.Unmarshal([]byte(task.Tables), &tables); err != nil {
+		if err := json.Unmarshal([]byte(task.Tables), &include); err != nil {
 			s.updateTaskStatus(taskID, model.MigrationStatusFailed, fmt.Sprintf("Failed to parse tables: %v", err))
 			return
 		}
-	} else {
-		// 取第一个数据库名
-		mainDB := ""
-		if len(task.Database) > 0 {
-			mainDB = task.Database[0]
-		}
-		tables, err = sourceDS.ListTables(mainDB)
-		if err != nil {
-			s.updateTaskStatus(taskID, model.MigrationStatusFailed, fmt.Sprintf("Failed to list tables: %v", err))
-			return
-		}
 	}
+	selector := &TableSelector{
+		Databases:       task.Database,
+		Include:         include,
+		Exclude:         task.Exclude,
+		DatabaseMapping: task.DatabaseMapping,
+	}
+	tables, unmatched, err := selector.Resolve(sourceDS)
+	if err != nil {
+		s.updateTaskStatus(taskID, model.MigrationStatusFailed, fmt.Sprintf("Failed to resolve tables: %v", err))
+		return
+	}
+	if len(unmatched) > 0 {
+		s.logger.Warn("Table patterns matched no tables", zap.String("task_id", taskID), zap.Strings("patterns", unmatched))
+	}
+	s.logger.Info("Resolved migration tables", zap.String("task_id", taskID), zap.Int("count", len(tables)))
 
 	// 计算总行数
 	var totalRows int64
-	for _, table := range tables {
-		dbName, tblName, err := parseTableName(table)
-		if err != nil {
-			s.logger.Error("Invalid table name format", zap.String("task_id", taskID), zap.String("table", table), zap.Error(err))
-			continue // 跳过该表
-		}
-		count, err := sourceDS.GetRowCount(dbName, tblName)
+	for _, ref := range tables {
+		count, err := sourceDS.GetRowCount(ref.SourceDB, ref.Table)
 		if err != nil {
-			s.logger.Warn("Failed to get row count", zap.String("table", table), zap.Error(err))
+			s.logger.Warn("Failed to get row count", zap.String("database", ref.SourceDB), zap.String("table", ref.Table), zap.Error(err))
 			continue
 		}
 		totalRows += count
@@ -279,22 +290,18 @@ func (s *MigrationService) executeMigration(taskID string) {
 	var migratedRows int64
 	var failedRows int64
 
-	for i, table := range tables {
-		dbName, tblName, err := parseTableName(table)
-		if err != nil {
-			s.logger.Error("Invalid table name format", zap.String("task_id", taskID), zap.String("table", table), zap.Error(err))
-			continue // 跳过该表
-		}
-		s.updateTaskProgress(taskID, float64(i)/float64(len(tables))*100, totalRows, migratedRows, tblName)
+	for i, ref := range tables {
+		s.updateTaskProgress(taskID, float64(i)/float64(len(tables))*100, totalRows, migratedRows, ref.Table)
 
-		tableResult := s.migrateTable(taskID, sourceDS, targetDS, task, dbName, tblName)
+		tableResult := s.migrateTable(taskID, sourceDS, targetDS, task, ref)
 		migratedRows += tableResult.MigratedRows
 		failedRows += tableResult.FailedRows
 
 		if !tableResult.Success {
 			s.logger.Error("Table migration failed",
 				zap.String("task_id", taskID),
-				zap.String("table", tblName),
+				zap.String("database", ref.SourceDB),
+				zap.String("table", ref.Table),
 				zap.String("error", tableResult.ErrorMessage))
 		}
 	}
@@ -319,43 +326,32 @@ func parseTableName(table string) (string, string, error) {
 	return parts[0], parts[1], nil
 }
 
-// migrateTable 迁移单个表
-func (s *MigrationService) migrateTable(taskID string, sourceDS, targetDS DataSource, task *model.MigrationTask, dbName, tableName string) *model.TableMigrationResult {
-	s.logger.Info("migrateTable", zap.String("task_id", taskID), zap.String("table", tableName), zap.String("database", dbName))
+// migrateTable 迁移单个表，源库与目标库可以不同名
+func (s *MigrationService) migrateTable(taskID string, sourceDS, targetDS DataSource, task *model.MigrationTask, ref TableRef) *model.TableMigrationResult {
+	dbName, tableName, targetDB := ref.SourceDB, ref.Table, ref.TargetDB
+	s.logger.Info("migrateTable", zap.String("task_id", taskID), zap.String("table", tableName), zap.String("database", dbName), zap.String("target_database", targetDB))
+
+	if dbName == "" || targetDB == "" {
+		return &model.TableMigrationResult{
+			TableName:    tableName,
+			Success:      false,
+			ErrorMessage: "Source or target database is not set",
+		}
+	}
 
-	// 新增：每个表迁移前都确保目标库已存在
+	// 每个表迁移前都确保目标库已存在
 	if tgtMy, ok := targetDS.(*MySQLDataSource); ok {
-		errDb := tgtMy.CreateDatabaseIfNotExists(dbName)
+		errDb := tgtMy.CreateDatabaseIfNotExists(targetDB)
 		if errDb != nil {
-			s.logger.Error("Failed to ensure target database exists before migrating table", zap.String("task_id", taskID), zap.String("database", dbName), zap.Error(errDb))
+			s.logger.Error("Failed to ensure target database exists before migrating table", zap.String("task_id", taskID), zap.String("database", targetDB), zap.Error(errDb))
 			return &model.TableMigrationResult{
+				TableName:    tableName,
 				Success:      false,
 				ErrorMessage: fmt.Sprintf("Failed to ensure target database exists: %v", errDb),
 			}
 		}
 	}
 
-	var tgtCfg model.DataSourceConfig
-	if err := json.Unmarshal([]byte(task.TargetConfig), &tgtCfg); err != nil {
-		return &model.TableMigrationResult{
-			Success:      false,
-			ErrorMessage: "Failed to parse target config: " + err.Error(),
-		}
-	}
-
-	if dbName == "" {
-		return &model.TableMigrationResult{
-			Success:      false,
-			ErrorMessage: "Source database is not set",
-		}
-	}
-	if tgtCfg.Database == "" {
-		return &model.TableMigrationResult{
-			Success:      false,
-			ErrorMessage: "",
-		}
-	}
-
 	result := &model.TableMigrationResult{
 		TableName: tableName,
 		StartTime: time.Now(),
@@ -379,7 +375,7 @@ func (s *MigrationService) migrateTable(taskID string, sourceDS, targetDS DataSo
 
 	// 检查目标表是否存在
 	tableExists := true
-	_, err = targetDS.GetTableSchema(dbName, tableName)
+	_, err = targetDS.GetTableSchema(targetDB, tableName)
 	if err != nil {
 		tableExists = false
 	}
@@ -387,16 +383,11 @@ func (s *MigrationService) migrateTable(taskID string, sourceDS, targetDS DataSo
 	if !tableExists {
 		if task.CreateSchema {
 			s.logger.Info("Target table does not exist, auto create", zap.String("task_id", taskID), zap.String("database", dbName), zap.String("table", tableName))
-			if srcMy, ok1 := sourceDS.(*MySQLDataSource); ok1 {
-				if tgtMy, ok2 := targetDS.(*MySQLDataSource); ok2 {
-					err := tgtMy.CreateTableFromSource(srcMy, dbName, tableName, dbName)
-					if err != nil {
-						s.logger.Error("Failed to create target table by DDL", zap.String("task_id", taskID), zap.String("table", tableName), zap.String("database", dbName), zap.Error(err))
-						result.Success = false
-						result.ErrorMessage = fmt.Sprintf("Failed to create target table by DDL: %v", err)
-						return result
-					}
-				}
+			if err := s.createTargetTable(taskID, sourceDS, targetDS, task, ref, sourceSchema); err != nil {
+				s.logger.Error("Failed to create target table", zap.String("task_id", taskID), zap.String("table", tableName), zap.String("database", targetDB), zap.Error(err))
+				result.Success = false
+				result.ErrorMessage = fmt.Sprintf("Failed to create target table: %v", err)
+				return result
 			}
 		} else {
 			s.logger.Info("Target table does not exist and create_schema is false", zap.String("task_id", taskID), zap.String("table", tableName))
@@ -408,34 +399,17 @@ func (s *MigrationService) migrateTable(taskID string, sourceDS, targetDS DataSo
 		// 表已存在
 		if task.TruncateTarget {
 			s.logger.Info("Truncate target table", zap.String("task_id", taskID), zap.String("table", tableName))
-			if err := targetDS.DropTable(dbName, tableName); err != nil {
+			if err := targetDS.DropTable(targetDB, tableName); err != nil {
 				result.Success = false
 				result.ErrorMessage = fmt.Sprintf("Failed to truncate target table: %v", err)
 				return result
 			}
 			// 重建表结构
 			if task.CreateSchema {
-				if srcMy, ok1 := sourceDS.(*MySQLDataSource); ok1 {
-					if tgtMy, ok2 := targetDS.(*MySQLDataSource); ok2 {
-						err := tgtMy.CreateTableFromSource(srcMy, dbName, tableName, dbName)
-						if err != nil {
-							result.Success = false
-							result.ErrorMessage = fmt.Sprintf("Failed to recreate target table by DDL: %v", err)
-							return result
-						}
-					} else {
-						if err := targetDS.CreateTable(dbName, sourceSchema); err != nil {
-							result.Success = false
-							result.ErrorMessage = fmt.Sprintf("Failed to recreate target table: %v", err)
-							return result
-						}
-					}
-				} else {
-					if err := targetDS.CreateTable(dbName, sourceSchema); err != nil {
-						result.Success = false
-						result.ErrorMessage = fmt.Sprintf("Failed to recreate target table: %v", err)
-						return result
-					}
+				if err := s.createTargetTable(taskID, sourceDS, targetDS, task, ref, sourceSchema); err != nil {
+					result.Success = false
+					result.ErrorMessage = fmt.Sprintf("Failed to recreate target table: %v", err)
+					return result
 				}
 			}
 		}
@@ -482,7 +456,7 @@ func (s *MigrationService) migrateTable(taskID string, sourceDS, targetDS DataSo
 		}
 
 		// 写入数据
-		err = targetDS.WriteRows(dbName, tableName, rows, WriteOptions{
+		err = targetDS.WriteRows(targetDB, tableName, rows, WriteOptions{
 			BatchSize: batchSize,
 		})
 		if err != nil {
@@ -511,6 +485,32 @@ func (s *MigrationService) migrateTable(taskID string, sourceDS, targetDS DataSo
 	return result
 }
 
+// createTargetTable 在目标库创建表：MySQL 之间直接复用源表 DDL，
+// 跨方言时经类型映射转换表结构，有损映射记录告警日志
+func (s *MigrationService) createTargetTable(taskID string, sourceDS, targetDS DataSource, task *model.MigrationTask, ref TableRef, sourceSchema *TableSchema) error {
+	if srcMy, ok := sourceDS.(*MySQLDataSource); ok && len(task.TypeOverrides) == 0 {
+		if tgtMy, ok := targetDS.(*MySQLDataSource); ok {
+			return tgtMy.CreateTableFromSource(srcMy, ref.SourceDB, ref.Table, ref.TargetDB)
+		}
+	}
+
+	mapper, err := NewTypeMapper(dialectOf(sourceDS), dialectOf(targetDS), task.TypeOverrides)
+	if err != nil {
+		return err
+	}
+	mapped, warnings := mapper.MapSchema(sourceSchema)
+	for _, w := range warnings {
+		s.logger.Warn("Lossy type mapping",
+			zap.String("task_id", taskID),
+			zap.String("table", w.Table),
+			zap.String("column", w.Column),
+			zap.String("source_type", w.SourceType),
+			zap.String("target_type", w.TargetType),
+			zap.String("reason", w.Reason))
+	}
+	return targetDS.CreateTable(ref.TargetDB, mapped)
+}
+
 // updateTaskStatus 更新任务状态
 func (s *MigrationService) updateTaskStatus(taskID string, status model.MigrationStatus, errorMessage string) {
 	s.taskMutex.Lock()
@@ -635,14 +635,20 @@ func (s *MigrationService) CancelTask(taskID string) error {
 
 // CreateMigrationRequest 创建迁移任务请求
 type CreateMigrationRequest struct {
-	SourceConfig   model.DataSourceConfig `json:"source_config"`
-	TargetConfig   model.DataSourceConfig `json:"target_config"`
-	Database       string                 `json:"database"`
-	Tables         []string               `json:"tables"`
-	BatchSize      int                    `json:"batch_size"`
-	CreateSchema   bool                   `json:"create_schema"`
-	TruncateTarget bool                   `json:"truncate_target"`
-	OnlySyncSchema bool                   `json:"only_sync_schema"`
+	SourceConnectionID string                 `json:"source_connection_id"` // 已保存的源连接，优先于 SourceConfig
+	TargetConnectionID string                 `json:"target_connection_id"` // 已保存的目标连接，优先于 TargetConfig
+	SourceConfig       model.DataSourceConfig `json:"source_config"`
+	TargetConfig       model.DataSourceConfig `json:"target_config"`
+	Database           string                 `json:"database"`         // 逗号分隔的源库列表，兼容旧版本
+	Databases          []string               `json:"databases"`        // 源库列表
+	Tables             []string               `json:"tables"`           // 包含规则：db.table、orders_*、app./^t_\\d+$/，! 开头为排除
+	Exclude            []string               `json:"exclude"`          // 排除规则，例如 *_bak
+	DatabaseMapping    map[string]string      `json:"database_mapping"` // 源库到目标库的重命名，例如 {"app": "app_staging"}
+	TypeOverrides      map[string]string      `json:"type_overrides"`   // 跨方言建表时按 table.column 或 column 指定目标类型
+	BatchSize          int                    `json:"batch_size"`
+	CreateSchema       bool                   `json:"create_schema"`
+	TruncateTarget     bool                   `json:"truncate_target"`
+	OnlySyncSchema     bool                   `json:"only_sync_schema"`
 }
 
 // CompareRequest 用于数据对比接口
@@ -651,15 +657,19 @@ type CompareRequest struct {
 	TargetConfig DataSourceConfig `json:"target_config"`
 	Database     string           `json:"database"`
 	Tables       []string         `json:"tables"`
+	Mode         string           `json:"mode"`        // count（默认）或 deep
+	SampleSize   int              `json:"sample_size"` // deep 模式下每端抽样行数，默认 100，最大 1000
+	MaxDiffs     int              `json:"max_diffs"`   // deep 模式下每表最多返回的差异行数，默认 50
 }
 
 // TableCompareResult 单表对比结果
 type TableCompareResult struct {
-	Table          string `json:"table"`
-	ExistsInSource bool   `json:"exists_in_source"`
-	ExistsInTarget bool   `json:"exists_in_target"`
-	RowCountSource int64  `json:"row_count_source"`
-	RowCountTarget int64  `json:"row_count_target"`
+	Table          string             `json:"table"`
+	ExistsInSource bool               `json:"exists_in_source"`
+	ExistsInTarget bool               `json:"exists_in_target"`
+	RowCountSource int64              `json:"row_count_source"`
+	RowCountTarget int64              `json:"row_count_target"`
+	Deep           *DeepCompareResult `json:"deep,omitempty"`
 }
 
 // CompareResponse 总体对比结果
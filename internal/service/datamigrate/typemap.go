@@ -0,0 +1,839 @@
+package datamigrate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	coreError "opscore/error"
+)
+
+// SQLKind 方言无关的列类型分类，各方言的类型先解析为 CanonicalType 再渲染为目标方言
+type SQLKind string
+
+const (
+	KindBool        SQLKind = "bool"
+	KindTinyInt     SQLKind = "tinyint"
+	KindSmallInt    SQLKind = "smallint"
+	KindInt         SQLKind = "int"
+	KindBigInt      SQLKind = "bigint"
+	KindDecimal     SQLKind = "decimal"
+	KindFloat       SQLKind = "float"
+	KindDouble      SQLKind = "double"
+	KindChar        SQLKind = "char"
+	KindVarchar     SQLKind = "varchar"
+	KindText        SQLKind = "text"
+	KindBinary      SQLKind = "binary"
+	KindVarBinary   SQLKind = "varbinary"
+	KindBlob        SQLKind = "blob"
+	KindDate        SQLKind = "date"
+	KindTime        SQLKind = "time"
+	KindDateTime    SQLKind = "datetime"
+	KindTimestamp   SQLKind = "timestamp"
+	KindTimestampTZ SQLKind = "timestamptz"
+	KindYear        SQLKind = "year"
+	KindJSON        SQLKind = "json"
+	KindEnum        SQLKind = "enum"
+	KindSet         SQLKind = "set"
+	KindUUID        SQLKind = "uuid"
+	KindBit         SQLKind = "bit"
+	KindInterval    SQLKind = "interval"
+	KindArray       SQLKind = "array"
+	KindUnknown     SQLKind = "unknown"
+)
+
+// CanonicalType 解析后的列类型
+type CanonicalType struct {
+	Kind         SQLKind
+	Length       int      // char/varchar/binary/bit 的长度
+	Precision    int      // decimal 精度或时间类型的小数秒位数
+	Scale        int      // decimal 小数位数
+	HasPrecision bool     // 是否显式指定了精度
+	Unsigned     bool     // MySQL 无符号整数/小数
+	Values       []string // enum/set 的取值
+	Size         string   // text/blob 的尺寸：tiny、medium、long
+	Element      string   // 数组元素的原始类型
+	Raw          string   // 原始类型字符串
+}
+
+// TypeDialect 单个方言的类型解析与渲染，Render 返回目标类型以及有损转换的原因
+type TypeDialect interface {
+	Parse(raw string) CanonicalType
+	Render(t CanonicalType) (string, []string)
+}
+
+var typeDialects = map[DataSourceType]TypeDialect{
+	DataSourceTypeMySQL:      mysqlTypes{},
+	DataSourceTypePostgreSQL: postgresTypes{},
+	DataSourceTypeSQLite:     sqliteTypes{},
+	DataSourceTypeMongoDB:    mongoTypes{},
+}
+
+// RegisterTypeDialect 注册或替换方言的类型映射实现
+func RegisterTypeDialect(dsType DataSourceType, dialect TypeDialect) {
+	typeDialects[dsType] = dialect
+}
+
+// TypeMappingWarning 有损或需要人工确认的类型映射
+type TypeMappingWarning struct {
+	Table      string `json:"table"`
+	Column     string `json:"column"`
+	SourceType string `json:"source_type"`
+	TargetType string `json:"target_type"`
+	Reason     string `json:"reason"`
+}
+
+// TypeMapper 在两个方言之间转换表结构
+type TypeMapper struct {
+	From DataSourceType
+	To   DataSourceType
+	// Overrides 用户指定的目标类型，键为 table.column 或 column，值原样写入目标 DDL
+	Overrides map[string]string
+
+	from TypeDialect
+	to   TypeDialect
+}
+
+// NewTypeMapper 创建类型映射器，任一方言未注册时返回错误
+func NewTypeMapper(from, to DataSourceType, overrides map[string]string) (*TypeMapper, error) {
+	fromDialect, ok := typeDialects[from]
+	if !ok {
+		return nil, fmt.Errorf("%w: no type mapping for source dialect %q", coreError.ErrUnsupportedDataSource, from)
+	}
+	toDialect, ok := typeDialects[to]
+	if !ok {
+		return nil, fmt.Errorf("%w: no type mapping for target dialect %q", coreError.ErrUnsupportedDataSource, to)
+	}
+	return &TypeMapper{From: from, To: to, Overrides: overrides, from: fromDialect, to: toDialect}, nil
+}
+
+// MapSchema 将源表结构转换为目标方言的表结构
+func (m *TypeMapper) MapSchema(schema *TableSchema) (*TableSchema, []TypeMappingWarning) {
+	mapped := &TableSchema{
+		Name:       schema.Name,
+		Indexes:    schema.Indexes,
+		Comment:    schema.Comment,
+		PrimaryKey: schema.PrimaryKey,
+		Columns:    make([]ColumnInfo, 0, len(schema.Columns)),
+	}
+	var warnings []TypeMappingWarning
+	for _, col := range schema.Columns {
+		out, ws := m.MapColumn(schema.Name, col)
+		mapped.Columns = append(mapped.Columns, out)
+		warnings = append(warnings, ws...)
+	}
+	return mapped, warnings
+}
+
+// MapColumn 转换单个列，同方言且没有覆盖时原样返回
+func (m *TypeMapper) MapColumn(table string, col ColumnInfo) (ColumnInfo, []TypeMappingWarning) {
+	out := col
+	if override, ok := m.override(table, col.Name); ok {
+		out.Type = override
+		return out, nil
+	}
+	if m.From == m.To {
+		return out, nil
+	}
+
+	t := m.from.Parse(col.Type)
+	target, reasons := m.to.Render(t)
+	out.Type = target
+
+	var warnings []TypeMappingWarning
+	warn := func(reason string) {
+		warnings = append(warnings, TypeMappingWarning{
+			Table:      table,
+			Column:     col.Name,
+			SourceType: col.Type,
+			TargetType: target,
+			Reason:     reason,
+		})
+	}
+	for _, reason := range reasons {
+		warn(reason)
+	}
+
+	// 默认值只保留字面量和 CURRENT_TIMESTAMP，其余表达式与方言相关
+	if col.DefaultValue != "" {
+		def, ok := portableDefault(col.DefaultValue, t, m.To)
+		if !ok {
+			warn(fmt.Sprintf("default %s is dialect specific and was dropped", col.DefaultValue))
+		}
+		out.DefaultValue = def
+	}
+	return out, warnings
+}
+
+// MongoValidator 根据源表结构生成 MongoDB 集合的 $jsonSchema 校验规则
+func (m *TypeMapper) MongoValidator(schema *TableSchema) map[string]interface{} {
+	properties := make(map[string]interface{}, len(schema.Columns))
+	var required []string
+	for _, col := range schema.Columns {
+		mapped, _ := m.MapColumn(schema.Name, col)
+		prop := map[string]interface{}{}
+		if col.IsNullable {
+			prop["bsonType"] = []string{mapped.Type, "null"}
+		} else {
+			prop["bsonType"] = mapped.Type
+			required = append(required, col.Name)
+		}
+		if t := m.from.Parse(col.Type); t.Kind == KindEnum && len(t.Values) > 0 {
+			prop["enum"] = t.Values
+		}
+		if col.Comment != "" {
+			prop["description"] = col.Comment
+		}
+		properties[col.Name] = prop
+	}
+
+	jsonSchema := map[string]interface{}{
+		"bsonType":   "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		jsonSchema["required"] = required
+	}
+	return map[string]interface{}{"$jsonSchema": jsonSchema}
+}
+
+func (m *TypeMapper) override(table, column string) (string, bool) {
+	if t, ok := m.Overrides[table+"."+column]; ok && t != "" {
+		return t, true
+	}
+	if t, ok := m.Overrides[column]; ok && t != "" {
+		return t, true
+	}
+	return "", false
+}
+
+var numericLiteral = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+// portableDefault 返回可在目标方言使用的默认值表达式，第二个返回值表示是否保留
+func portableDefault(def string, t CanonicalType, to DataSourceType) (string, bool) {
+	upper := strings.ToUpper(strings.Trim(def, "()"))
+	switch {
+	case upper == "NULL":
+		return "", true
+	case strings.HasPrefix(upper, "CURRENT_TIMESTAMP"), upper == "NOW":
+		if to == DataSourceTypeMongoDB {
+			return "", false
+		}
+		return "CURRENT_TIMESTAMP", true
+	case numericLiteral.MatchString(def):
+		if to == DataSourceTypePostgreSQL && (t.Kind == KindBool || (t.Kind == KindTinyInt && t.Length == 1)) {
+			if def == "0" {
+				return "FALSE", true
+			}
+			return "TRUE", true
+		}
+		return def, true
+	case strings.HasPrefix(def, "'") && strings.HasSuffix(def, "'"):
+		return def, true
+	}
+	return "", false
+}
+
+var typeArgsRe = regexp.MustCompile(`\(([^)]*)\)`)
+
+// typeParts 拆分类型字符串：小写的基础类型名、括号内参数、是否 unsigned
+func typeParts(raw string) (string, []string, bool) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	var args []string
+	if match := typeArgsRe.FindStringSubmatch(s); match != nil {
+		for _, a := range splitTypeArgs(match[1]) {
+			args = append(args, strings.TrimSpace(a))
+		}
+		s = typeArgsRe.ReplaceAllString(s, "")
+	}
+	unsigned := strings.Contains(s, "unsigned")
+	s = strings.ReplaceAll(s, "unsigned", "")
+	s = strings.ReplaceAll(s, "zerofill", "")
+	return strings.Join(strings.Fields(s), " "), args, unsigned
+}
+
+// splitTypeArgs 按逗号拆分参数，忽略引号内的逗号（enum 取值中可能包含逗号）
+func splitTypeArgs(s string) []string {
+	var args []string
+	var cur strings.Builder
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\'':
+			inQuote = !inQuote
+			cur.WriteByte(c)
+		case c == ',' && !inQuote:
+			args = append(args, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	return append(args, cur.String())
+}
+
+func atoi(args []string, i int) int {
+	if i >= len(args) {
+		return 0
+	}
+	n, _ := strconv.Atoi(args[i])
+	return n
+}
+
+// enumValues 从原始类型字符串中提取 enum/set 取值，保留原始大小写
+func enumValues(raw string) []string {
+	match := typeArgsRe.FindStringSubmatch(raw)
+	if match == nil {
+		return nil
+	}
+	var values []string
+	for _, v := range splitTypeArgs(match[1]) {
+		v = strings.TrimSpace(v)
+		v = strings.TrimSuffix(strings.TrimPrefix(v, "'"), "'")
+		values = append(values, strings.ReplaceAll(v, "''", "'"))
+	}
+	return values
+}
+
+func maxValueLen(values []string) int {
+	n := 1
+	for _, v := range values {
+		if len(v) > n {
+			n = len(v)
+		}
+	}
+	return n
+}
+
+func quoteValues(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+	return strings.Join(quoted, ",")
+}
+
+// fracPrecision 返回时间类型的小数秒位数，超过 max 时截断并给出原因
+func fracPrecision(t CanonicalType, max int) (int, string) {
+	if !t.HasPrecision || t.Precision <= max {
+		return t.Precision, ""
+	}
+	return max, fmt.Sprintf("fractional seconds precision %d truncated to %d", t.Precision, max)
+}
+
+// mysqlTypes MySQL 类型映射
+type mysqlTypes struct{}
+
+func (mysqlTypes) Parse(raw string) CanonicalType {
+	base, args, unsigned := typeParts(raw)
+	t := CanonicalType{Raw: raw, Unsigned: unsigned}
+	if len(args) > 0 {
+		t.Precision, t.Scale, t.HasPrecision = atoi(args, 0), atoi(args, 1), true
+		t.Length = t.Precision
+	}
+	switch base {
+	case "bool", "boolean":
+		t.Kind = KindBool
+	case "tinyint":
+		t.Kind = KindTinyInt
+		if t.Length == 1 && !unsigned {
+			t.Kind = KindBool
+		}
+	case "smallint":
+		t.Kind = KindSmallInt
+	case "mediumint", "int", "integer":
+		t.Kind = KindInt
+	case "bigint":
+		t.Kind = KindBigInt
+	case "decimal", "numeric", "dec", "fixed":
+		t.Kind = KindDecimal
+	case "float":
+		t.Kind = KindFloat
+	case "double", "double precision", "real":
+		t.Kind = KindDouble
+	case "bit":
+		t.Kind = KindBit
+		if t.Length <= 1 {
+			t.Kind = KindBool
+		}
+	case "char":
+		t.Kind = KindChar
+	case "varchar":
+		t.Kind = KindVarchar
+	case "tinytext", "text", "mediumtext", "longtext":
+		t.Kind, t.Size = KindText, strings.TrimSuffix(base, "text")
+	case "binary":
+		t.Kind = KindBinary
+	case "varbinary":
+		t.Kind = KindVarBinary
+	case "tinyblob", "blob", "mediumblob", "longblob":
+		t.Kind, t.Size = KindBlob, strings.TrimSuffix(base, "blob")
+	case "date":
+		t.Kind = KindDate
+	case "time":
+		t.Kind = KindTime
+	case "datetime":
+		t.Kind = KindDateTime
+	case "timestamp":
+		// MySQL TIMESTAMP 按 UTC 存储，读写时按会话时区转换
+		t.Kind = KindTimestampTZ
+	case "year":
+		t.Kind = KindYear
+	case "json":
+		t.Kind = KindJSON
+	case "enum":
+		t.Kind, t.Values = KindEnum, enumValues(raw)
+	case "set":
+		t.Kind, t.Values = KindSet, enumValues(raw)
+	default:
+		t.Kind = KindUnknown
+	}
+	return t
+}
+
+func (mysqlTypes) Render(t CanonicalType) (string, []string) {
+	var reasons []string
+	unsigned := func(s string) string {
+		if t.Unsigned {
+			return s + " UNSIGNED"
+		}
+		return s
+	}
+	switch t.Kind {
+	case KindBool:
+		return "TINYINT(1)", nil
+	case KindTinyInt:
+		return unsigned("TINYINT"), nil
+	case KindSmallInt:
+		return unsigned("SMALLINT"), nil
+	case KindInt:
+		return unsigned("INT"), nil
+	case KindBigInt:
+		return unsigned("BIGINT"), nil
+	case KindDecimal:
+		if !t.HasPrecision {
+			return unsigned("DECIMAL(65,30)"), []string{"unbounded numeric mapped to DECIMAL(65,30)"}
+		}
+		p, s := t.Precision, t.Scale
+		if p > 65 {
+			reasons = append(reasons, fmt.Sprintf("numeric precision %d exceeds MySQL maximum 65", p))
+			p = 65
+		}
+		if s > 30 {
+			reasons = append(reasons, fmt.Sprintf("numeric scale %d exceeds MySQL maximum 30", s))
+			s = 30
+		}
+		return unsigned(fmt.Sprintf("DECIMAL(%d,%d)", p, s)), reasons
+	case KindFloat:
+		return "FLOAT", nil
+	case KindDouble:
+		return "DOUBLE", nil
+	case KindChar:
+		if t.Length > 255 {
+			return fmt.Sprintf("VARCHAR(%d)", t.Length), nil
+		}
+		if t.Length <= 0 {
+			return "CHAR(1)", nil
+		}
+		return fmt.Sprintf("CHAR(%d)", t.Length), nil
+	case KindVarchar:
+		if t.Length <= 0 {
+			return "LONGTEXT", nil
+		}
+		return fmt.Sprintf("VARCHAR(%d)", t.Length), nil
+	case KindText:
+		return strings.ToUpper(t.Size) + "TEXT", nil
+	case KindBinary:
+		return fmt.Sprintf("BINARY(%d)", max(t.Length, 1)), nil
+	case KindVarBinary:
+		if t.Length <= 0 {
+			return "LONGBLOB", nil
+		}
+		return fmt.Sprintf("VARBINARY(%d)", t.Length), nil
+	case KindBlob:
+		if t.Size == "" {
+			return "LONGBLOB", nil
+		}
+		return strings.ToUpper(t.Size) + "BLOB", nil
+	case KindDate:
+		return "DATE", nil
+	case KindTime, KindDateTime, KindTimestamp, KindTimestampTZ:
+		name := map[SQLKind]string{KindTime: "TIME", KindDateTime: "DATETIME", KindTimestamp: "DATETIME", KindTimestampTZ: "DATETIME"}[t.Kind]
+		if t.Kind == KindTimestampTZ {
+			reasons = append(reasons, "time zone offset is not stored, values are converted to the session time zone")
+		}
+		p, reason := fracPrecision(t, 6)
+		if reason != "" {
+			reasons = append(reasons, reason)
+		}
+		if p > 0 {
+			return fmt.Sprintf("%s(%d)", name, p), reasons
+		}
+		return name, reasons
+	case KindYear:
+		return "YEAR", nil
+	case KindJSON:
+		return "JSON", nil
+	case KindEnum:
+		return fmt.Sprintf("ENUM(%s)", quoteValues(t.Values)), nil
+	case KindSet:
+		return fmt.Sprintf("SET(%s)", quoteValues(t.Values)), nil
+	case KindUUID:
+		return "CHAR(36)", nil
+	case KindBit:
+		return fmt.Sprintf("BIT(%d)", min(max(t.Length, 1), 64)), nil
+	case KindInterval:
+		return "VARCHAR(64)", []string{"interval stored as text"}
+	case KindArray:
+		return "JSON", []string{"array stored as JSON"}
+	}
+	return t.Raw, []string{"unknown type copied verbatim"}
+}
+
+// postgresTypes PostgreSQL 类型映射
+type postgresTypes struct{}
+
+func (postgresTypes) Parse(raw string) CanonicalType {
+	t := CanonicalType{Raw: raw}
+	lower := strings.ToLower(strings.TrimSpace(raw))
+	if strings.HasSuffix(lower, "[]") || strings.HasPrefix(lower, "_") {
+		t.Kind = KindArray
+		t.Element = strings.TrimSuffix(strings.TrimPrefix(raw, "_"), "[]")
+		return t
+	}
+	base, args, _ := typeParts(raw)
+	if len(args) > 0 {
+		t.Precision, t.Scale, t.HasPrecision = atoi(args, 0), atoi(args, 1), true
+		t.Length = t.Precision
+	}
+	switch base {
+	case "boolean", "bool":
+		t.Kind = KindBool
+	case "smallint", "int2", "smallserial", "serial2":
+		t.Kind = KindSmallInt
+	case "integer", "int", "int4", "serial", "serial4":
+		t.Kind = KindInt
+	case "bigint", "int8", "bigserial", "serial8":
+		t.Kind = KindBigInt
+	case "numeric", "decimal":
+		t.Kind = KindDecimal
+	case "money":
+		t.Kind, t.Precision, t.Scale, t.HasPrecision = KindDecimal, 19, 2, true
+	case "real", "float4":
+		t.Kind = KindFloat
+	case "double precision", "float8", "float":
+		t.Kind = KindDouble
+	case "char", "character", "bpchar":
+		t.Kind = KindChar
+	case "varchar", "character varying":
+		t.Kind = KindVarchar
+	case "text", "citext", "name":
+		t.Kind, t.Size = KindText, "long"
+	case "bytea":
+		t.Kind, t.Size = KindBlob, "long"
+	case "date":
+		t.Kind = KindDate
+	case "time", "time without time zone", "timetz", "time with time zone":
+		t.Kind = KindTime
+	case "timestamp", "timestamp without time zone":
+		t.Kind = KindTimestamp
+	case "timestamptz", "timestamp with time zone":
+		t.Kind = KindTimestampTZ
+	case "json", "jsonb":
+		t.Kind = KindJSON
+	case "uuid":
+		t.Kind = KindUUID
+	case "bit", "bit varying", "varbit":
+		t.Kind = KindBit
+	case "interval":
+		t.Kind = KindInterval
+	default:
+		t.Kind = KindUnknown
+	}
+	return t
+}
+
+func (postgresTypes) Render(t CanonicalType) (string, []string) {
+	var reasons []string
+	switch t.Kind {
+	case KindBool:
+		return "BOOLEAN", nil
+	case KindTinyInt:
+		return "SMALLINT", nil
+	case KindSmallInt:
+		if t.Unsigned {
+			return "INTEGER", nil
+		}
+		return "SMALLINT", nil
+	case KindInt:
+		if t.Unsigned {
+			return "BIGINT", nil
+		}
+		return "INTEGER", nil
+	case KindBigInt:
+		if t.Unsigned {
+			return "NUMERIC(20,0)", []string{"unsigned bigint stored as NUMERIC(20,0) to hold values above 2^63-1"}
+		}
+		return "BIGINT", nil
+	case KindDecimal:
+		if t.Unsigned {
+			reasons = append(reasons, "unsigned constraint dropped")
+		}
+		if !t.HasPrecision {
+			return "NUMERIC", reasons
+		}
+		return fmt.Sprintf("NUMERIC(%d,%d)", t.Precision, t.Scale), reasons
+	case KindFloat:
+		return "REAL", nil
+	case KindDouble:
+		return "DOUBLE PRECISION", nil
+	case KindChar:
+		return fmt.Sprintf("CHAR(%d)", max(t.Length, 1)), nil
+	case KindVarchar:
+		if t.Length <= 0 {
+			return "VARCHAR", nil
+		}
+		return fmt.Sprintf("VARCHAR(%d)", t.Length), nil
+	case KindText:
+		return "TEXT", nil
+	case KindBinary, KindVarBinary, KindBlob:
+		return "BYTEA", nil
+	case KindDate:
+		return "DATE", nil
+	case KindTime, KindDateTime, KindTimestamp, KindTimestampTZ:
+		name := map[SQLKind]string{KindTime: "TIME", KindDateTime: "TIMESTAMP", KindTimestamp: "TIMESTAMP", KindTimestampTZ: "TIMESTAMPTZ"}[t.Kind]
+		p, reason := fracPrecision(t, 6)
+		if reason != "" {
+			reasons = append(reasons, reason)
+		}
+		if t.HasPrecision {
+			return fmt.Sprintf("%s(%d)", name, p), reasons
+		}
+		return name, reasons
+	case KindYear:
+		return "SMALLINT", []string{"year stored as SMALLINT"}
+	case KindJSON:
+		return "JSONB", nil
+	case KindEnum:
+		return fmt.Sprintf("VARCHAR(%d)", maxValueLen(t.Values)), []string{"enum values are not enforced, consider a CHECK constraint or CREATE TYPE"}
+	case KindSet:
+		return "TEXT", []string{"set stored as comma separated TEXT"}
+	case KindUUID:
+		return "UUID", nil
+	case KindBit:
+		return fmt.Sprintf("BIT(%d)", max(t.Length, 1)), nil
+	case KindInterval:
+		return "INTERVAL", nil
+	case KindArray:
+		return t.Element + "[]", nil
+	}
+	return t.Raw, []string{"unknown type copied verbatim"}
+}
+
+// sqliteTypes SQLite 类型映射，解析遵循 SQLite 的类型亲和规则
+type sqliteTypes struct{}
+
+func (sqliteTypes) Parse(raw string) CanonicalType {
+	base, args, unsigned := typeParts(raw)
+	t := CanonicalType{Raw: raw, Unsigned: unsigned}
+	if len(args) > 0 {
+		t.Precision, t.Scale, t.HasPrecision = atoi(args, 0), atoi(args, 1), true
+		t.Length = t.Precision
+	}
+	switch base {
+	case "boolean", "bool":
+		t.Kind = KindBool
+		return t
+	case "date":
+		t.Kind = KindDate
+		return t
+	case "datetime", "timestamp":
+		t.Kind = KindDateTime
+		return t
+	case "json":
+		t.Kind = KindJSON
+		return t
+	}
+	switch {
+	case strings.Contains(base, "int"):
+		t.Kind = KindBigInt
+	case strings.Contains(base, "char"), strings.Contains(base, "clob"), strings.Contains(base, "text"):
+		t.Kind, t.Size = KindText, "long"
+	case base == "", strings.Contains(base, "blob"):
+		t.Kind, t.Size = KindBlob, "long"
+	case strings.Contains(base, "real"), strings.Contains(base, "floa"), strings.Contains(base, "doub"):
+		t.Kind = KindDouble
+	default:
+		t.Kind = KindDecimal
+	}
+	return t
+}
+
+func (sqliteTypes) Render(t CanonicalType) (string, []string) {
+	switch t.Kind {
+	case KindBool, KindTinyInt, KindSmallInt, KindInt, KindYear:
+		return "INTEGER", nil
+	case KindBigInt:
+		if t.Unsigned {
+			return "INTEGER", []string{"unsigned bigint values above 2^63-1 overflow SQLite INTEGER"}
+		}
+		return "INTEGER", nil
+	case KindDecimal:
+		if t.HasPrecision && (t.Scale > 0 || t.Precision > 15) {
+			return "NUMERIC", []string{"decimal may be stored as REAL and lose precision"}
+		}
+		return "NUMERIC", nil
+	case KindFloat, KindDouble:
+		return "REAL", nil
+	case KindChar, KindVarchar, KindText, KindUUID, KindJSON, KindInterval:
+		return "TEXT", nil
+	case KindEnum:
+		return "TEXT", []string{"enum values are not enforced"}
+	case KindSet:
+		return "TEXT", []string{"set stored as comma separated TEXT"}
+	case KindBinary, KindVarBinary, KindBlob, KindBit:
+		return "BLOB", nil
+	case KindDate:
+		return "DATE", nil
+	case KindTime, KindDateTime, KindTimestamp, KindTimestampTZ:
+		reasons := []string{"SQLite has no native date/time type, values are stored as ISO8601 TEXT"}
+		if t.Kind == KindTimestampTZ {
+			reasons = append(reasons, "time zone offset is not preserved")
+		}
+		return "DATETIME", reasons
+	case KindArray:
+		return "TEXT", []string{"array stored as JSON TEXT"}
+	}
+	return "BLOB", []string{"unknown type stored with BLOB affinity"}
+}
+
+// mongoTypes MongoDB 校验规则中的 bsonType 映射
+type mongoTypes struct{}
+
+func (mongoTypes) Parse(raw string) CanonicalType {
+	t := CanonicalType{Raw: raw}
+	switch strings.TrimSpace(raw) {
+	case "bool":
+		t.Kind = KindBool
+	case "int":
+		t.Kind = KindInt
+	case "long":
+		t.Kind = KindBigInt
+	case "double":
+		t.Kind = KindDouble
+	case "decimal":
+		t.Kind, t.Precision, t.Scale, t.HasPrecision = KindDecimal, 34, 10, true
+	case "string":
+		t.Kind, t.Size = KindText, "long"
+	case "date":
+		t.Kind, t.Precision, t.HasPrecision = KindDateTime, 3, true
+	case "timestamp":
+		t.Kind = KindTimestampTZ
+	case "objectId":
+		t.Kind, t.Length = KindChar, 24
+	case "binData":
+		t.Kind, t.Size = KindBlob, "long"
+	case "object":
+		t.Kind = KindJSON
+	case "array":
+		t.Kind, t.Element = KindArray, "jsonb"
+	default:
+		t.Kind = KindUnknown
+	}
+	return t
+}
+
+func (mongoTypes) Render(t CanonicalType) (string, []string) {
+	switch t.Kind {
+	case KindBool:
+		return "bool", nil
+	case KindTinyInt, KindSmallInt, KindYear:
+		return "int", nil
+	case KindInt:
+		if t.Unsigned {
+			return "long", nil
+		}
+		return "int", nil
+	case KindBigInt:
+		if t.Unsigned {
+			return "decimal", []string{"unsigned bigint stored as Decimal128 to hold values above 2^63-1"}
+		}
+		return "long", nil
+	case KindDecimal:
+		if t.HasPrecision && t.Precision > 34 {
+			return "decimal", []string{fmt.Sprintf("numeric precision %d exceeds Decimal128 maximum 34", t.Precision)}
+		}
+		return "decimal", nil
+	case KindFloat, KindDouble:
+		return "double", nil
+	case KindChar, KindVarchar, KindText, KindEnum, KindUUID:
+		return "string", nil
+	case KindSet:
+		return "string", []string{"set stored as comma separated string"}
+	case KindBinary, KindVarBinary, KindBlob:
+		return "binData", nil
+	case KindBit:
+		return "long", nil
+	case KindDate, KindDateTime, KindTimestamp, KindTimestampTZ:
+		if t.HasPrecision && t.Precision > 3 {
+			return "date", []string{fmt.Sprintf("fractional seconds precision %d truncated to milliseconds", t.Precision)}
+		}
+		return "date", nil
+	case KindTime:
+		return "string", []string{"time of day stored as string"}
+	case KindJSON:
+		return "object", nil
+	case KindInterval:
+		return "string", []string{"interval stored as string"}
+	case KindArray:
+		return "array", nil
+	}
+	return "string", []string{"unknown type stored as string"}
+}
+
+// dialectOf 返回数据源实例对应的方言
+func dialectOf(ds DataSource) DataSourceType {
+	switch ds.(type) {
+	case *MySQLDataSource:
+		return DataSourceTypeMySQL
+	default:
+		return ""
+	}
+}
+
+// SchemaMapRequest 预览跨方言表结构转换的请求
+type SchemaMapRequest struct {
+	SourceConfig  DataSourceConfig  `json:"source_config"`
+	Database      string            `json:"database"`
+	Table         string            `json:"table"`
+	TargetType    DataSourceType    `json:"target_type"`
+	TypeOverrides map[string]string `json:"type_overrides"`
+}
+
+// SchemaMapResponse 表结构转换结果，目标为 MongoDB 时附带集合校验规则
+type SchemaMapResponse struct {
+	Source    *TableSchema           `json:"source"`
+	Target    *TableSchema           `json:"target"`
+	Warnings  []TypeMappingWarning   `json:"warnings"`
+	Validator map[string]interface{} `json:"validator,omitempty"`
+}
+
+// MapTableSchema 读取源表结构并转换为目标方言
+func (s *MigrationService) MapTableSchema(sourceDS DataSource, req *SchemaMapRequest) (*SchemaMapResponse, error) {
+	mapper, err := NewTypeMapper(dialectOf(sourceDS), req.TargetType, req.TypeOverrides)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := sourceDS.GetTableSchema(req.Database, req.Table)
+	if err != nil {
+		return nil, err
+	}
+	mapped, warnings := mapper.MapSchema(schema)
+	resp := &SchemaMapResponse{Source: schema, Target: mapped, Warnings: warnings}
+	if req.TargetType == DataSourceTypeMongoDB {
+		resp.Validator = mapper.MongoValidator(schema)
+	}
+	return resp, nil
+}
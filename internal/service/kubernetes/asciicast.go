@@ -0,0 +1,96 @@
+package kubernetes
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// asciicastHeader asciicast v2 文件头
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     uint16            `json:"width"`
+	Height    uint16            `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// AsciicastRecorder 将终端会话按 asciicast v2 格式写入文件，可用 asciinema play 回放。
+// 输出记录为 "o" 事件，输入记录为 "i" 事件，窗口大小变化记录为 "r" 事件
+type AsciicastRecorder struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	start  time.Time
+	closed bool
+}
+
+// NewAsciicastRecorder 创建录像文件并写入文件头
+func NewAsciicastRecorder(path string, width, height uint16, title, shell string) (*AsciicastRecorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording file: %w", err)
+	}
+
+	r := &AsciicastRecorder{file: file, writer: bufio.NewWriter(file), start: time.Now()}
+	header, _ := json.Marshal(asciicastHeader{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: r.start.Unix(),
+		Title:     title,
+		Env:       map[string]string{"SHELL": shell, "TERM": "xterm"},
+	})
+	r.writer.Write(header)
+	r.writer.WriteByte('\n')
+	return r, nil
+}
+
+// Output 记录终端输出，data 应以完整的 UTF-8 字符结尾，否则被截断的字符在 JSON 编码时会被替换为 U+FFFD
+func (r *AsciicastRecorder) Output(data []byte) {
+	r.event("o", string(data))
+}
+
+// Input 记录用户输入
+func (r *AsciicastRecorder) Input(data []byte) {
+	r.event("i", string(data))
+}
+
+// Resize 记录窗口大小变化
+func (r *AsciicastRecorder) Resize(width, height uint16) {
+	r.event("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+func (r *AsciicastRecorder) event(kind, data string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	line, _ := json.Marshal([]interface{}{time.Since(r.start).Seconds(), kind, data})
+	r.writer.Write(line)
+	r.writer.WriteByte('\n')
+}
+
+// Close 刷新缓冲并关闭文件
+func (r *AsciicastRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if err := r.writer.Flush(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
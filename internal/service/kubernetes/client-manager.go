@@ -0,0 +1,314 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"opscore/config"
+	"opscore/internal/log"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	defaultClientIdleTimeout           = 30 * time.Minute
+	defaultInformerResync              = 10 * time.Minute
+	defaultInformerSyncTimeout         = 15 * time.Second
+	clientJanitorInterval              = time.Minute
+	cachedClientQPS            float32 = 50
+	cachedClientBurst                  = 100
+)
+
+// ClusterClients 单个集群缓存的各类客户端，可在多个请求间并发复用
+type ClusterClients struct {
+	ClusterID  string
+	RESTConfig *rest.Config
+	Clientset  *kubernetes.Clientset
+	Dynamic    dynamic.Interface
+	Discovery  discovery.CachedDiscoveryInterface
+	Mapper     meta.ResettableRESTMapper
+
+	kubeconfigHash string
+	lastUsed       time.Time
+
+	informerMu     sync.Mutex
+	factory        informers.SharedInformerFactory
+	informerResync time.Duration
+	syncTimeout    time.Duration
+	stopCh         chan struct{}
+	closed         bool // 已从缓存移除，不再启动新的 informer
+}
+
+// ClientManager 按 ClusterID 缓存集群客户端，kubeconfig 变更或集群删除后失效
+type ClientManager struct {
+	mu      sync.Mutex
+	clients map[string]*ClusterClients
+	logger  *zap.Logger
+
+	idleTimeout      time.Duration
+	informersEnabled bool
+	informerResync   time.Duration
+	syncTimeout      time.Duration
+
+	janitorOnce sync.Once
+}
+
+var (
+	clientManager     *ClientManager
+	clientManagerOnce sync.Once
+)
+
+// GetClientManager 返回全局集群客户端管理器
+func GetClientManager() *ClientManager {
+	clientManagerOnce.Do(func() {
+		cfg := config.GetConfig().Kubernetes.ClientCache
+		m := &ClientManager{
+			clients:          make(map[string]*ClusterClients),
+			logger:           log.GetLogger(),
+			idleTimeout:      defaultClientIdleTimeout,
+			informersEnabled: cfg.Informers,
+			informerResync:   defaultInformerResync,
+			syncTimeout:      defaultInformerSyncTimeout,
+		}
+		if cfg.IdleTimeoutSec > 0 {
+			m.idleTimeout = time.Duration(cfg.IdleTimeoutSec) * time.Second
+		}
+		if cfg.InformerResyncSec > 0 {
+			m.informerResync = time.Duration(cfg.InformerResyncSec) * time.Second
+		}
+		if cfg.InformerSyncTimeoutSec > 0 {
+			m.syncTimeout = time.Duration(cfg.InformerSyncTimeoutSec) * time.Second
+		}
+		clientManager = m
+	})
+	return clientManager
+}
+
+// GetClusterClients 获取集群的缓存客户端
+func GetClusterClients(clusterID string) (*ClusterClients, error) {
+	return GetClientManager().Get(clusterID)
+}
+
+// Get 获取集群客户端，数据库中的 kubeconfig 与缓存时不一致时重建
+func (m *ClientManager) Get(clusterID string) (*ClusterClients, error) {
+	m.janitorOnce.Do(func() { go m.janitor() })
+
+	cluster, err := GetClusterByClusterID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	kubeconfig, err := ClusterKubeConfig(cluster)
+	if err != nil {
+		return nil, err
+	}
+	hash := kubeconfigHash(kubeconfig)
+
+	m.mu.Lock()
+	if cc, ok := m.clients[cluster.ClusterID]; ok {
+		if cc.kubeconfigHash == hash {
+			cc.lastUsed = time.Now()
+			m.mu.Unlock()
+			return cc, nil
+		}
+		m.logger.Info("集群kubeconfig已变更，重建客户端", zap.String("clusterID", cluster.ClusterID))
+		delete(m.clients, cluster.ClusterID)
+		cc.stopInformers()
+	}
+	m.mu.Unlock()
+
+	// 构建客户端放在锁外，避免阻塞其他集群的请求
+	cc, err := m.newClusterClients(cluster.ClusterID, kubeconfig, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.clients[cluster.ClusterID]; ok && existing.kubeconfigHash == hash {
+		// 并发请求已创建了相同的客户端
+		existing.lastUsed = time.Now()
+		return existing, nil
+	}
+	m.clients[cluster.ClusterID] = cc
+	return cc, nil
+}
+
+// Invalidate 使集群的缓存客户端失效并停止其 informer，clusterID 可以是数据库主键或 cluster_id
+func (m *ClientManager) Invalidate(clusterID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, cc := range m.clients {
+		if key == clusterID || cc.ClusterID == clusterID {
+			delete(m.clients, key)
+			cc.stopInformers()
+		}
+	}
+}
+
+// InvalidateCluster 使集群的缓存客户端失效
+func InvalidateCluster(clusterID string) {
+	GetClientManager().Invalidate(clusterID)
+}
+
+func (m *ClientManager) newClusterClients(clusterID, kubeconfig, hash string) (*ClusterClients, error) {
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig([]byte(kubeconfig))
+	if err != nil {
+		return nil, fmt.Errorf("无法从 kubeconfig 数据构建 REST 配置: %w", err)
+	}
+	restConfig.QPS = cachedClientQPS
+	restConfig.Burst = cachedClientBurst
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("无法创建 Kubernetes Clientset: %w", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("无法创建 Kubernetes 动态客户端: %w", err)
+	}
+	cachedDiscovery := memory.NewMemCacheClient(clientset.Discovery())
+
+	m.logger.Info("创建集群缓存客户端", zap.String("clusterID", clusterID))
+	cc := &ClusterClients{
+		ClusterID:      clusterID,
+		RESTConfig:     restConfig,
+		Clientset:      clientset,
+		Dynamic:        dynamicClient,
+		Discovery:      cachedDiscovery,
+		Mapper:         restmapper.NewDeferredDiscoveryRESTMapper(cachedDiscovery),
+		kubeconfigHash: hash,
+		lastUsed:       time.Now(),
+		syncTimeout:    m.syncTimeout,
+	}
+	if m.informersEnabled {
+		cc.informerResync = m.informerResync
+	}
+	return cc, nil
+}
+
+// janitor 定期回收长时间未使用的集群客户端
+func (m *ClientManager) janitor() {
+	ticker := time.NewTicker(clientJanitorInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		m.mu.Lock()
+		for key, cc := range m.clients {
+			if time.Since(cc.lastUsed) > m.idleTimeout {
+				delete(m.clients, key)
+				cc.stopInformers()
+				m.logger.Info("回收空闲的集群客户端", zap.String("clusterID", cc.ClusterID))
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+// InformersEnabled 是否为该集群启用了共享 informer
+func (c *ClusterClients) InformersEnabled() bool {
+	return c.informerResync > 0
+}
+
+// ResetDiscovery 清空发现缓存，集群新增 CRD 后调用
+func (c *ClusterClients) ResetDiscovery() {
+	c.Discovery.Invalidate()
+	c.Mapper.Reset()
+}
+
+// NamespaceLister 返回已同步的命名空间缓存，未启用 informer 或同步超时返回 false
+func (c *ClusterClients) NamespaceLister() (corev1listers.NamespaceLister, bool) {
+	factory, ok := c.informerFactory()
+	if !ok {
+		return nil, false
+	}
+	informer := factory.Core().V1().Namespaces()
+	if !c.waitForSync(factory, informer.Informer()) {
+		return nil, false
+	}
+	return informer.Lister(), true
+}
+
+// PodLister 返回已同步的 Pod 缓存，未启用 informer 或同步超时返回 false
+func (c *ClusterClients) PodLister() (corev1listers.PodLister, bool) {
+	factory, ok := c.informerFactory()
+	if !ok {
+		return nil, false
+	}
+	informer := factory.Core().V1().Pods()
+	if !c.waitForSync(factory, informer.Informer()) {
+		return nil, false
+	}
+	return informer.Lister(), true
+}
+
+// informerFactory 懒加载共享 informer 工厂
+func (c *ClusterClients) informerFactory() (informers.SharedInformerFactory, bool) {
+	if !c.InformersEnabled() {
+		return nil, false
+	}
+	c.informerMu.Lock()
+	defer c.informerMu.Unlock()
+	if c.closed {
+		return nil, false
+	}
+	if c.factory == nil {
+		c.factory = informers.NewSharedInformerFactory(c.Clientset, c.informerResync)
+		c.stopCh = make(chan struct{})
+	}
+	return c.factory, true
+}
+
+// waitForSync 启动新注册的 informer 并等待首次同步完成
+func (c *ClusterClients) waitForSync(factory informers.SharedInformerFactory, informer cache.SharedIndexInformer) bool {
+	c.informerMu.Lock()
+	stopCh := c.stopCh
+	c.informerMu.Unlock()
+	if stopCh == nil {
+		return false
+	}
+	factory.Start(stopCh)
+	if informer.HasSynced() {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.syncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		log.GetLogger().Warn("informer 同步超时，回退到直接请求 API Server", zap.String("clusterID", c.ClusterID))
+		return false
+	}
+	return true
+}
+
+func (c *ClusterClients) stopInformers() {
+	c.informerMu.Lock()
+	defer c.informerMu.Unlock()
+	c.closed = true
+	if c.stopCh != nil {
+		close(c.stopCh)
+		c.stopCh = nil
+	}
+	if c.factory != nil {
+		c.factory.Shutdown()
+		c.factory = nil
+	}
+}
+
+func kubeconfigHash(kubeconfig string) string {
+	sum := sha256.Sum256([]byte(kubeconfig))
+	return hex.EncodeToString(sum[:])
+}
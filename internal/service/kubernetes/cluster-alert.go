@@ -0,0 +1,97 @@
+package kubernetes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"opscore/internal/log"
+
+	"go.uber.org/zap"
+)
+
+// 集群告警类型
+const (
+	AlertClusterUnreachable = "cluster_unreachable"
+	AlertClusterRecovered   = "cluster_recovered"
+	AlertCredentialExpiring = "credential_expiring"
+)
+
+// ClusterAlert 集群告警内容
+type ClusterAlert struct {
+	Type           string     `json:"type"`
+	ClusterID      string     `json:"cluster_id"`
+	ClusterName    string     `json:"cluster_name"`
+	Status         string     `json:"status"`
+	PreviousStatus string     `json:"previous_status,omitempty"`
+	Message        string     `json:"message,omitempty"`
+	CertExpiresAt  *time.Time `json:"cert_expires_at,omitempty"`
+	Time           time.Time  `json:"time"`
+}
+
+// AlertNotifier 告警发送接口
+type AlertNotifier interface {
+	Notify(alert ClusterAlert) error
+}
+
+// NewAlertNotifier 创建告警发送器：始终写日志，配置了 webhook 时同时推送
+func NewAlertNotifier(webhookURL string) AlertNotifier {
+	notifiers := multiNotifier{logNotifier{}}
+	if webhookURL != "" {
+		notifiers = append(notifiers, &webhookNotifier{
+			url:    webhookURL,
+			client: &http.Client{Timeout: 10 * time.Second},
+		})
+	}
+	return notifiers
+}
+
+// logNotifier 将告警写入日志
+type logNotifier struct{}
+
+func (logNotifier) Notify(alert ClusterAlert) error {
+	log.GetLogger().Warn("集群告警",
+		zap.String("type", alert.Type),
+		zap.String("clusterID", alert.ClusterID),
+		zap.String("clusterName", alert.ClusterName),
+		zap.String("status", alert.Status),
+		zap.String("message", alert.Message))
+	return nil
+}
+
+// webhookNotifier 以 JSON 形式 POST 告警
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (w *webhookNotifier) Notify(alert ClusterAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post alert: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// multiNotifier 依次调用多个发送器，返回第一个错误
+type multiNotifier []AlertNotifier
+
+func (m multiNotifier) Notify(alert ClusterAlert) error {
+	var firstErr error
+	for _, n := range m {
+		if err := n.Notify(alert); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,340 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	coreError "opscore/error"
+	"opscore/internal/crypto"
+	"opscore/internal/db"
+	"opscore/internal/log"
+	"opscore/internal/model"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// 集群认证方式
+const (
+	AuthTypeKubeConfig     = "kubeconfig"     // 直接提供 kubeconfig
+	AuthTypeToken          = "token"          // Bearer Token + CA + API Server 地址
+	AuthTypeServiceAccount = "serviceaccount" // 通过引导 kubeconfig 为 opscore 创建专用 ServiceAccount
+	AuthTypeInCluster      = "incluster"      // opscore 运行在集群内时使用 Pod 的 ServiceAccount
+)
+
+const (
+	defaultServiceAccountNamespace = "opscore-system"
+	defaultServiceAccountName      = "opscore"
+	// defaultServiceAccountRole 未指定 ClusterRole 时创建并绑定的最小权限角色，
+	// 跨集群迁移的目标集群需要写入任意资源，应显式指定权限更大的角色
+	defaultServiceAccountRole  = "opscore:operator"
+	serviceAccountTokenTimeout = 30 * time.Second
+)
+
+// operatorRoleRules opscore 默认角色的权限：只读所有资源，外加终端、日志、驱逐、
+// 节点封锁以及工作负载扩缩容、重启、回滚和更新镜像所需的写权限
+var operatorRoleRules = []rbacv1.PolicyRule{
+	{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"get", "list", "watch"}},
+	{APIGroups: []string{""}, Resources: []string{"pods/exec"}, Verbs: []string{"create", "get"}},
+	{APIGroups: []string{""}, Resources: []string{"pods/eviction"}, Verbs: []string{"create"}},
+	{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"patch", "update"}},
+	{
+		APIGroups: []string{"apps"},
+		Resources: []string{"deployments", "statefulsets", "daemonsets", "deployments/scale", "statefulsets/scale"},
+		Verbs:     []string{"patch", "update"},
+	},
+}
+
+// ClusterCredentials 注册或更新集群时提交的凭证，不同认证方式使用不同字段
+type ClusterCredentials struct {
+	AuthType string
+
+	// kubeconfig 方式
+	KubeConfig string
+
+	// token 方式
+	Server   string
+	Token    string
+	CAData   string // PEM 格式的 CA 证书
+	Insecure bool   // 未提供 CA 时是否跳过证书校验
+
+	// serviceaccount 方式：引导 kubeconfig 只用于创建 ServiceAccount，不会入库
+	BootstrapKubeConfig     string
+	ServiceAccountNamespace string
+	ServiceAccountName      string
+	ClusterRole             string
+}
+
+// BuildKubeConfig 将各种认证方式统一转换为 kubeconfig，后续连接、健康检查和客户端缓存都只依赖 kubeconfig
+func BuildKubeConfig(creds ClusterCredentials) (string, error) {
+	switch creds.AuthType {
+	case "", AuthTypeKubeConfig:
+		if creds.KubeConfig == "" {
+			return "", fmt.Errorf("%w: kube_config is required", coreError.ErrInvalidKubeConfig)
+		}
+		return creds.KubeConfig, nil
+	case AuthTypeToken:
+		if creds.Server == "" || creds.Token == "" {
+			return "", fmt.Errorf("%w: server and token are required", coreError.ErrInvalidKubeConfig)
+		}
+		if creds.CAData == "" && !creds.Insecure {
+			return "", fmt.Errorf("%w: ca_data is required unless insecure is set", coreError.ErrInvalidKubeConfig)
+		}
+		return writeKubeConfig(&clientcmdapi.Cluster{
+			Server:                   creds.Server,
+			CertificateAuthorityData: []byte(creds.CAData),
+			InsecureSkipTLSVerify:    creds.CAData == "" && creds.Insecure,
+		}, &clientcmdapi.AuthInfo{Token: creds.Token})
+	case AuthTypeServiceAccount:
+		return bootstrapServiceAccount(creds)
+	case AuthTypeInCluster:
+		return inClusterKubeConfig()
+	default:
+		return "", fmt.Errorf("%w: unsupported auth type %q", coreError.ErrInvalidKubeConfig, creds.AuthType)
+	}
+}
+
+// writeKubeConfig 生成只包含单个上下文的 kubeconfig
+func writeKubeConfig(cluster *clientcmdapi.Cluster, authInfo *clientcmdapi.AuthInfo) (string, error) {
+	cfg := clientcmdapi.NewConfig()
+	cfg.Clusters["cluster"] = cluster
+	cfg.AuthInfos["opscore"] = authInfo
+	cfg.Contexts["opscore"] = &clientcmdapi.Context{Cluster: "cluster", AuthInfo: "opscore"}
+	cfg.CurrentContext = "opscore"
+	data, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	return string(data), nil
+}
+
+// inClusterKubeConfig 使用 Pod 挂载的 ServiceAccount 生成 kubeconfig。
+// kubeconfig 中引用令牌文件路径而不是令牌内容，令牌自动轮换后无需更新
+func inClusterKubeConfig() (string, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", coreError.ErrInvalidKubeConfig, err)
+	}
+	return writeKubeConfig(&clientcmdapi.Cluster{
+		Server:               restConfig.Host,
+		CertificateAuthority: restConfig.TLSClientConfig.CAFile,
+	}, &clientcmdapi.AuthInfo{TokenFile: restConfig.BearerTokenFile})
+}
+
+// bootstrapServiceAccount 使用引导 kubeconfig 在目标集群中创建 ServiceAccount、
+// ClusterRoleBinding 和长期令牌 Secret，返回基于该令牌的 kubeconfig。资源已存在时复用
+func bootstrapServiceAccount(creds ClusterCredentials) (string, error) {
+	logger := log.GetLogger()
+	if creds.BootstrapKubeConfig == "" {
+		return "", fmt.Errorf("%w: bootstrap_kube_config is required", coreError.ErrInvalidKubeConfig)
+	}
+	namespace := creds.ServiceAccountNamespace
+	if namespace == "" {
+		namespace = defaultServiceAccountNamespace
+	}
+	name := creds.ServiceAccountName
+	if name == "" {
+		name = defaultServiceAccountName
+	}
+	role := creds.ClusterRole
+	if role == "" {
+		role = defaultServiceAccountRole
+	}
+
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig([]byte(creds.BootstrapKubeConfig))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", coreError.ErrInvalidKubeConfig, err)
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", coreError.ErrInvalidKubeConfig, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), serviceAccountTokenTimeout)
+	defer cancel()
+
+	labels := map[string]string{"app.kubernetes.io/managed-by": "opscore"}
+	if err := ignoreAlreadyExists(client.CoreV1().Namespaces().Create(ctx, &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: labels},
+	}, metav1.CreateOptions{})); err != nil {
+		return "", fmt.Errorf("failed to create namespace %s: %w", namespace, err)
+	}
+	if err := ignoreAlreadyExists(client.CoreV1().ServiceAccounts(namespace).Create(ctx, &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+	}, metav1.CreateOptions{})); err != nil {
+		return "", fmt.Errorf("failed to create service account %s/%s: %w", namespace, name, err)
+	}
+	if role == defaultServiceAccountRole {
+		if err := ensureOperatorRole(ctx, client, labels); err != nil {
+			return "", fmt.Errorf("failed to create cluster role %s: %w", role, err)
+		}
+	}
+	if err := ensureClusterRoleBinding(ctx, client, &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("opscore:%s:%s", namespace, name), Labels: labels},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: role},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: namespace}},
+	}); err != nil {
+		return "", fmt.Errorf("failed to bind cluster role %s: %w", role, err)
+	}
+
+	// 1.24 之后不再自动生成令牌 Secret，需要手动创建并等待控制器填充
+	secretName := name + "-token"
+	if err := ignoreAlreadyExists(client.CoreV1().Secrets(namespace).Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        secretName,
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: map[string]string{corev1.ServiceAccountNameKey: name},
+		},
+		Type: corev1.SecretTypeServiceAccountToken,
+	}, metav1.CreateOptions{})); err != nil {
+		return "", fmt.Errorf("failed to create token secret: %w", err)
+	}
+
+	var secret *corev1.Secret
+	err = wait.PollUntilContextCancel(ctx, time.Second, true, func(ctx context.Context) (bool, error) {
+		s, err := client.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if len(s.Data[corev1.ServiceAccountTokenKey]) == 0 {
+			return false, nil
+		}
+		secret = s
+		return true, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to wait for service account token: %w", err)
+	}
+
+	logger.Info("已为 opscore 创建 ServiceAccount",
+		zap.String("namespace", namespace), zap.String("name", name), zap.String("clusterRole", role))
+
+	caData := secret.Data[corev1.ServiceAccountRootCAKey]
+	if len(caData) == 0 {
+		caData = restConfig.CAData
+	}
+	return writeKubeConfig(&clientcmdapi.Cluster{
+		Server:                   restConfig.Host,
+		CertificateAuthorityData: caData,
+		InsecureSkipTLSVerify:    len(caData) == 0 && restConfig.Insecure,
+	}, &clientcmdapi.AuthInfo{Token: string(secret.Data[corev1.ServiceAccountTokenKey])})
+}
+
+// ensureOperatorRole 创建 opscore 默认角色，已存在时更新为当前版本的权限
+func ensureOperatorRole(ctx context.Context, client kubernetes.Interface, labels map[string]string) error {
+	role := &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: defaultServiceAccountRole, Labels: labels},
+		Rules:      operatorRoleRules,
+	}
+	_, err := client.RbacV1().ClusterRoles().Create(ctx, role, metav1.CreateOptions{})
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	existing, err := client.RbacV1().ClusterRoles().Get(ctx, defaultServiceAccountRole, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	existing.Rules = operatorRoleRules
+	_, err = client.RbacV1().ClusterRoles().Update(ctx, existing, metav1.UpdateOptions{})
+	return err
+}
+
+// ensureClusterRoleBinding 创建 ClusterRoleBinding。已存在但绑定的角色或主体与期望不同时删除后重建，
+// RoleRef 不可修改，否则之前绑定的角色（例如早期默认的 cluster-admin）会一直保留
+func ensureClusterRoleBinding(ctx context.Context, client kubernetes.Interface, binding *rbacv1.ClusterRoleBinding) error {
+	bindings := client.RbacV1().ClusterRoleBindings()
+	_, err := bindings.Create(ctx, binding, metav1.CreateOptions{})
+	if !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	existing, err := bindings.Get(ctx, binding.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if existing.RoleRef == binding.RoleRef && reflect.DeepEqual(existing.Subjects, binding.Subjects) {
+		return nil
+	}
+
+	log.GetLogger().Info("ClusterRoleBinding 与期望不一致，重建绑定",
+		zap.String("name", binding.Name),
+		zap.String("fromRole", existing.RoleRef.Name),
+		zap.String("toRole", binding.RoleRef.Name))
+	if err := bindings.Delete(ctx, binding.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	_, err = bindings.Create(ctx, binding, metav1.CreateOptions{})
+	return err
+}
+
+func ignoreAlreadyExists[T any](_ T, err error) error {
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// encryptKubeConfig 加密 kubeconfig 后入库
+func encryptKubeConfig(kubeconfig string) (string, error) {
+	encrypted, err := crypto.Encrypt(kubeconfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt kubeconfig: %w", err)
+	}
+	return encrypted, nil
+}
+
+// ClusterKubeConfig 返回解密后的集群 kubeconfig，兼容历史明文数据
+func ClusterKubeConfig(cluster *model.K8sClusterMetaData) (string, error) {
+	kubeconfig, err := crypto.Decrypt(cluster.KubeConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt kubeconfig of cluster %s: %w", cluster.ClusterID, err)
+	}
+	return kubeconfig, nil
+}
+
+// ReencryptClusterCredentials 使用当前主密钥重新加密所有集群的 kubeconfig，
+// 包括历史明文和旧密钥加密的数据，返回处理的集群数量。密钥轮换后调用，完成后即可移除旧密钥
+func ReencryptClusterCredentials() (int, error) {
+	logger := log.GetLogger()
+
+	var clusters []model.K8sClusterMetaData
+	// 包含软删除的集群，否则移除旧密钥后无法再恢复这些集群
+	if err := db.DBInstance.DB.Unscoped().Find(&clusters).Error; err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := range clusters {
+		cluster := &clusters[i]
+		needed, err := crypto.NeedsReencrypt(cluster.KubeConfig)
+		if err != nil {
+			return count, err
+		}
+		if !needed {
+			continue
+		}
+		encrypted, err := crypto.Reencrypt(cluster.KubeConfig)
+		if err != nil {
+			return count, fmt.Errorf("failed to re-encrypt kubeconfig of cluster %s: %w", cluster.ClusterID, err)
+		}
+		if err := db.DBInstance.DB.Unscoped().Model(cluster).UpdateColumn("kube_config", encrypted).Error; err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if count > 0 {
+		logger.Info("已重新加密集群 kubeconfig", zap.Int("count", count))
+	}
+	return count, nil
+}
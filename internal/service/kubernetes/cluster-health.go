@@ -0,0 +1,399 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"opscore/config"
+	"opscore/internal/db"
+	"opscore/internal/log"
+	"opscore/internal/model"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// 健康检查产生的集群状态，手动测试使用同一组状态，避免两条路径交替写入时误报状态变化
+const (
+	ClusterStatusDegraded    = "degraded"    // API Server 可达但存在未就绪节点
+	ClusterStatusUnreachable = "unreachable" // API Server 不可达
+	// clusterStatusLegacyError 早期手动测试失败时写入的状态，等同于不可达
+	clusterStatusLegacyError = "error"
+)
+
+const (
+	defaultHealthCheckInterval  = time.Minute
+	defaultHealthCheckTimeout   = 10 * time.Second
+	defaultCertExpiryWarnDays   = 30
+	defaultHistoryRetentionDays = 30
+	healthCheckConcurrency      = 5
+	// certAlertInterval 同一集群凭证即将过期的告警最短间隔
+	certAlertInterval = 24 * time.Hour
+)
+
+// ClusterProbeResult 单次健康检查结果
+type ClusterProbeResult struct {
+	Status        string
+	Version       string
+	NodesTotal    int
+	NodesReady    int
+	Latency       time.Duration
+	CertExpiresAt *time.Time
+	Message       string
+}
+
+// ProbeCluster 检查 API Server 可达性、版本、节点就绪数量以及 kubeconfig 凭证过期时间
+func ProbeCluster(kubeconfig string, timeout time.Duration) ClusterProbeResult {
+	result := ClusterProbeResult{Status: ClusterStatusUnreachable}
+
+	// 凭证过期时间只依赖 kubeconfig 本身，即使集群不可达也能给出
+	if expiresAt, err := CredentialExpiry(kubeconfig); err == nil {
+		result.CertExpiresAt = expiresAt
+	}
+
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig([]byte(kubeconfig))
+	if err != nil {
+		result.Message = fmt.Sprintf("invalid kubeconfig: %v", err)
+		return result
+	}
+	restConfig.Timeout = timeout
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		result.Message = fmt.Sprintf("failed to create client: %v", err)
+		return result
+	}
+
+	start := time.Now()
+	serverVersion, err := client.Discovery().ServerVersion()
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Message = fmt.Sprintf("api server unreachable: %v", err)
+		return result
+	}
+	result.Version = serverVersion.GitVersion
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		// 凭证可能没有列出节点的权限，API Server 可达即视为连接正常
+		result.Status = ClusterStatusConnected
+		result.Message = fmt.Sprintf("failed to list nodes: %v", err)
+		return result
+	}
+	result.NodesTotal = len(nodes.Items)
+	for _, node := range nodes.Items {
+		if isNodeReady(&node) {
+			result.NodesReady++
+		}
+	}
+
+	result.Status = ClusterStatusConnected
+	if result.NodesReady < result.NodesTotal {
+		result.Status = ClusterStatusDegraded
+		result.Message = fmt.Sprintf("%d/%d nodes ready", result.NodesReady, result.NodesTotal)
+	}
+	return result
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// CredentialExpiry 返回 kubeconfig 当前上下文凭证的过期时间：
+// 客户端证书取证书的 NotAfter，JWT 令牌取 exp 声明，无法判断时返回 nil
+func CredentialExpiry(kubeconfig string) (*time.Time, error) {
+	cfg, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return nil, err
+	}
+	kubeContext, ok := cfg.Contexts[cfg.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("current context %q not found", cfg.CurrentContext)
+	}
+	authInfo, ok := cfg.AuthInfos[kubeContext.AuthInfo]
+	if !ok {
+		return nil, fmt.Errorf("user %q not found", kubeContext.AuthInfo)
+	}
+
+	certData := authInfo.ClientCertificateData
+	if len(certData) == 0 && authInfo.ClientCertificate != "" {
+		if certData, err = os.ReadFile(authInfo.ClientCertificate); err != nil {
+			return nil, err
+		}
+	}
+	if len(certData) > 0 {
+		block, _ := pem.Decode(certData)
+		if block == nil {
+			return nil, fmt.Errorf("failed to decode client certificate")
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		notAfter := cert.NotAfter
+		return &notAfter, nil
+	}
+
+	if authInfo.Token != "" {
+		return tokenExpiry(authInfo.Token), nil
+	}
+	return nil, nil
+}
+
+// tokenExpiry 解析 JWT 令牌的 exp 声明，非 JWT 或没有 exp 时返回 nil
+func tokenExpiry(token string) *time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return nil
+	}
+	exp := time.Unix(claims.Exp, 0)
+	return &exp
+}
+
+// HealthMonitor 周期性检查所有已注册集群，更新状态并记录历史
+type HealthMonitor struct {
+	interval         time.Duration
+	timeout          time.Duration
+	certWarnDuration time.Duration
+	retention        time.Duration
+	notifier         AlertNotifier
+	logger           *zap.Logger
+
+	mu            sync.Mutex
+	lastCertAlert map[string]time.Time
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// StartHealthMonitor 按配置启动集群健康检查，未启用时返回 nil
+func StartHealthMonitor() *HealthMonitor {
+	cfg := config.GetConfig().Kubernetes.HealthCheck
+	logger := log.GetLogger()
+	if !cfg.Enabled {
+		logger.Info("集群健康检查未启用")
+		return nil
+	}
+
+	m := &HealthMonitor{
+		interval:         defaultHealthCheckInterval,
+		timeout:          defaultHealthCheckTimeout,
+		certWarnDuration: defaultCertExpiryWarnDays * 24 * time.Hour,
+		retention:        defaultHistoryRetentionDays * 24 * time.Hour,
+		notifier:         NewAlertNotifier(cfg.AlertWebhook),
+		logger:           logger,
+		lastCertAlert:    make(map[string]time.Time),
+		stopCh:           make(chan struct{}),
+	}
+	if cfg.IntervalSec > 0 {
+		m.interval = time.Duration(cfg.IntervalSec) * time.Second
+	}
+	if cfg.TimeoutSec > 0 {
+		m.timeout = time.Duration(cfg.TimeoutSec) * time.Second
+	}
+	if cfg.CertExpiryWarnDays > 0 {
+		m.certWarnDuration = time.Duration(cfg.CertExpiryWarnDays) * 24 * time.Hour
+	}
+	if cfg.HistoryRetentionDays > 0 {
+		m.retention = time.Duration(cfg.HistoryRetentionDays) * 24 * time.Hour
+	}
+
+	go m.run()
+	logger.Info("集群健康检查已启动", zap.Duration("interval", m.interval))
+	return m
+}
+
+// Stop 停止健康检查，可重复调用，nil 接收者安全
+func (m *HealthMonitor) Stop() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() { close(m.stopCh) })
+}
+
+func (m *HealthMonitor) run() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	m.checkAll()
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.checkAll()
+		}
+	}
+}
+
+// checkAll 并发检查所有集群，并清理过期的历史记录
+func (m *HealthMonitor) checkAll() {
+	clusters, err := GetAllClusters()
+	if err != nil {
+		m.logger.Error("健康检查获取集群列表失败", zap.Error(err))
+		return
+	}
+
+	sem := make(chan struct{}, healthCheckConcurrency)
+	var wg sync.WaitGroup
+	for i := range clusters {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(cluster *model.K8sClusterMetaData) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			m.checkCluster(cluster)
+		}(&clusters[i])
+	}
+	wg.Wait()
+
+	cutoff := time.Now().Add(-m.retention)
+	if err := db.DBInstance.DB.Unscoped().Where("checked_at < ?", cutoff).Delete(&model.K8sClusterStatusHistory{}).Error; err != nil {
+		m.logger.Warn("清理集群状态历史失败", zap.Error(err))
+	}
+}
+
+// checkCluster 检查单个集群，更新状态、写入历史并在需要时发送告警
+func (m *HealthMonitor) checkCluster(cluster *model.K8sClusterMetaData) {
+	previous := cluster.Status
+	if previous == clusterStatusLegacyError {
+		previous = ClusterStatusUnreachable
+	}
+	var result ClusterProbeResult
+	if kubeconfig, err := ClusterKubeConfig(cluster); err != nil {
+		result = ClusterProbeResult{Status: ClusterStatusUnreachable, Message: err.Error()}
+	} else {
+		result = ProbeCluster(kubeconfig, m.timeout)
+	}
+	now := time.Now()
+
+	updates := map[string]interface{}{
+		"status":          result.Status,
+		"last_checked_at": now,
+		"cert_expires_at": result.CertExpiresAt,
+	}
+	if result.Version != "" {
+		updates["version"] = result.Version
+	}
+	if err := db.DBInstance.DB.Model(cluster).Updates(updates).Error; err != nil {
+		m.logger.Error("更新集群健康状态失败", zap.String("clusterID", cluster.ClusterID), zap.Error(err))
+	}
+
+	history := model.K8sClusterStatusHistory{
+		ClusterID:     cluster.ClusterID,
+		Status:        result.Status,
+		Version:       result.Version,
+		NodesTotal:    result.NodesTotal,
+		NodesReady:    result.NodesReady,
+		LatencyMs:     result.Latency.Milliseconds(),
+		CertExpiresAt: result.CertExpiresAt,
+		Message:       result.Message,
+		CheckedAt:     now,
+	}
+	if err := db.DBInstance.DB.Create(&history).Error; err != nil {
+		m.logger.Error("写入集群状态历史失败", zap.String("clusterID", cluster.ClusterID), zap.Error(err))
+	}
+
+	if previous != result.Status {
+		m.logger.Info("集群状态变化",
+			zap.String("clusterID", cluster.ClusterID),
+			zap.String("from", previous),
+			zap.String("to", result.Status))
+	}
+
+	switch {
+	case result.Status == ClusterStatusUnreachable && previous != ClusterStatusUnreachable:
+		m.notify(ClusterAlert{
+			Type:           AlertClusterUnreachable,
+			ClusterID:      cluster.ClusterID,
+			ClusterName:    cluster.ClusterName,
+			Status:         result.Status,
+			PreviousStatus: previous,
+			Message:        result.Message,
+			Time:           now,
+		})
+	case previous == ClusterStatusUnreachable && result.Status != ClusterStatusUnreachable:
+		m.notify(ClusterAlert{
+			Type:           AlertClusterRecovered,
+			ClusterID:      cluster.ClusterID,
+			ClusterName:    cluster.ClusterName,
+			Status:         result.Status,
+			PreviousStatus: previous,
+			Time:           now,
+		})
+	}
+
+	if result.CertExpiresAt != nil && result.CertExpiresAt.Sub(now) < m.certWarnDuration && m.shouldAlertCert(cluster.ClusterID, now) {
+		m.notify(ClusterAlert{
+			Type:          AlertCredentialExpiring,
+			ClusterID:     cluster.ClusterID,
+			ClusterName:   cluster.ClusterName,
+			Status:        result.Status,
+			Message:       fmt.Sprintf("kubeconfig credentials expire at %s", result.CertExpiresAt.Format(time.RFC3339)),
+			CertExpiresAt: result.CertExpiresAt,
+			Time:          now,
+		})
+	}
+}
+
+// shouldAlertCert 同一集群的凭证过期告警在 certAlertInterval 内只发送一次
+func (m *HealthMonitor) shouldAlertCert(clusterID string, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if last, ok := m.lastCertAlert[clusterID]; ok && now.Sub(last) < certAlertInterval {
+		return false
+	}
+	m.lastCertAlert[clusterID] = now
+	return true
+}
+
+func (m *HealthMonitor) notify(alert ClusterAlert) {
+	if err := m.notifier.Notify(alert); err != nil {
+		m.logger.Error("发送集群告警失败", zap.String("clusterID", alert.ClusterID), zap.String("type", alert.Type), zap.Error(err))
+	}
+}
+
+// GetClusterStatusHistory 按检查时间倒序返回集群的状态历史
+func GetClusterStatusHistory(clusterID string, limit int) ([]model.K8sClusterStatusHistory, error) {
+	cluster, err := GetClusterByClusterID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	var history []model.K8sClusterStatusHistory
+	err = db.DBInstance.DB.Where("cluster_id = ?", cluster.ClusterID).
+		Order("checked_at DESC").Limit(limit).Find(&history).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cluster status history: %w", err)
+	}
+	return history, nil
+}
@@ -0,0 +1,194 @@
+package kubernetes
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	coreError "opscore/error"
+	"opscore/internal/log"
+	"opscore/internal/model"
+
+	"go.uber.org/zap"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// clusterRegistrationConcurrency 批量注册时同时校验的集群数量
+const clusterRegistrationConcurrency = 5
+
+// KubeConfigContext kubeconfig 中的一个上下文
+type KubeConfigContext struct {
+	Name      string `json:"name"`
+	Cluster   string `json:"cluster"`
+	User      string `json:"user"`
+	Namespace string `json:"namespace,omitempty"`
+	Server    string `json:"server,omitempty"`
+	Current   bool   `json:"current"`
+}
+
+// ClusterRegistration 批量注册中的单个集群
+type ClusterRegistration struct {
+	ClusterName string
+	Comment     string
+	Credentials ClusterCredentials
+}
+
+// ClusterRegistrationResult 单个集群的注册结果，失败时 Cluster 为 nil
+type ClusterRegistrationResult struct {
+	ClusterName string
+	Context     string
+	Cluster     *model.K8sClusterMetaData
+	Err         error
+}
+
+// ImportContext 从 kubeconfig 中选择导入的上下文，ClusterName 为空时使用上下文名称
+type ImportContext struct {
+	Context     string
+	ClusterName string
+	Comment     string
+}
+
+// ListKubeConfigContexts 解析 kubeconfig 并列出其中的全部上下文
+func ListKubeConfigContexts(kubeconfig string) ([]KubeConfigContext, error) {
+	cfg, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", coreError.ErrInvalidKubeConfig, err)
+	}
+
+	contexts := make([]KubeConfigContext, 0, len(cfg.Contexts))
+	for name, ctx := range cfg.Contexts {
+		item := KubeConfigContext{
+			Name:      name,
+			Cluster:   ctx.Cluster,
+			User:      ctx.AuthInfo,
+			Namespace: ctx.Namespace,
+			Current:   name == cfg.CurrentContext,
+		}
+		if cluster, ok := cfg.Clusters[ctx.Cluster]; ok {
+			item.Server = cluster.Server
+		}
+		contexts = append(contexts, item)
+	}
+	sort.Slice(contexts, func(i, j int) bool { return contexts[i].Name < contexts[j].Name })
+	return contexts, nil
+}
+
+// MinimalKubeConfig 生成只包含指定上下文及其集群、用户的 kubeconfig，
+// 避免每个集群都保存整份包含其他集群凭证的 kubeconfig。
+// 上传的 kubeconfig 必须内嵌证书和令牌，以文件路径引用的上下文会被拒绝，否则调用方可以借此读取 opscore 主机上的文件
+func MinimalKubeConfig(kubeconfig, contextName string) (string, error) {
+	cfg, err := clientcmd.Load([]byte(kubeconfig))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", coreError.ErrInvalidKubeConfig, err)
+	}
+	if _, ok := cfg.Contexts[contextName]; !ok {
+		return "", fmt.Errorf("%w: context %q not found", coreError.ErrInvalidKubeConfig, contextName)
+	}
+	cfg.CurrentContext = contextName
+	if err := clientcmdapi.MinifyConfig(cfg); err != nil {
+		return "", fmt.Errorf("%w: %v", coreError.ErrInvalidKubeConfig, err)
+	}
+	if err := rejectFileReferences(cfg); err != nil {
+		return "", err
+	}
+	data, err := clientcmd.Write(*cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to write kubeconfig: %w", err)
+	}
+	return string(data), nil
+}
+
+// rejectFileReferences 检查集群和用户是否以文件路径引用证书、私钥或令牌，
+// 上传的 kubeconfig 应使用 certificate-authority-data、client-certificate-data、client-key-data 和 token 字段
+func rejectFileReferences(cfg *clientcmdapi.Config) error {
+	for name, cluster := range cfg.Clusters {
+		if cluster.CertificateAuthority != "" {
+			return fmt.Errorf("%w: cluster %q references certificate-authority by file path, use certificate-authority-data instead",
+				coreError.ErrInvalidKubeConfig, name)
+		}
+	}
+	for name, authInfo := range cfg.AuthInfos {
+		var field string
+		switch {
+		case authInfo.ClientCertificate != "":
+			field = "client-certificate"
+		case authInfo.ClientKey != "":
+			field = "client-key"
+		case authInfo.TokenFile != "":
+			field = "tokenFile"
+		default:
+			continue
+		}
+		return fmt.Errorf("%w: user %q references %s by file path, embed the content with the *-data or token fields instead",
+			coreError.ErrInvalidKubeConfig, name, field)
+	}
+	return nil
+}
+
+// ImportClusters 将 kubeconfig 中选中的上下文分别注册为独立的集群，各上下文并发校验。
+// kubeconfig 无法解析时返回错误，单个上下文失败记录在对应的结果中
+func ImportClusters(kubeconfig string, selected []ImportContext) ([]ClusterRegistrationResult, error) {
+	if _, err := clientcmd.Load([]byte(kubeconfig)); err != nil {
+		return nil, fmt.Errorf("%w: %v", coreError.ErrInvalidKubeConfig, err)
+	}
+
+	results := make([]ClusterRegistrationResult, len(selected))
+	registrations := make([]ClusterRegistration, 0, len(selected))
+	indexes := make([]int, 0, len(selected))
+	for i, item := range selected {
+		name := item.ClusterName
+		if name == "" {
+			name = item.Context
+		}
+		results[i] = ClusterRegistrationResult{ClusterName: name, Context: item.Context}
+
+		minimal, err := MinimalKubeConfig(kubeconfig, item.Context)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		registrations = append(registrations, ClusterRegistration{
+			ClusterName: name,
+			Comment:     item.Comment,
+			Credentials: ClusterCredentials{AuthType: AuthTypeKubeConfig, KubeConfig: minimal},
+		})
+		indexes = append(indexes, i)
+	}
+
+	for i, result := range AddClusters(registrations) {
+		results[indexes[i]].Cluster = result.Cluster
+		results[indexes[i]].Err = result.Err
+	}
+	return results, nil
+}
+
+// AddClusters 并发注册多个集群，结果顺序与输入一致
+func AddClusters(registrations []ClusterRegistration) []ClusterRegistrationResult {
+	logger := log.GetLogger()
+	results := make([]ClusterRegistrationResult, len(registrations))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, clusterRegistrationConcurrency)
+	for i, reg := range registrations {
+		wg.Add(1)
+		go func(i int, reg ClusterRegistration) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			cluster, err := AddCluster(reg.ClusterName, reg.Comment, reg.Credentials)
+			results[i] = ClusterRegistrationResult{ClusterName: reg.ClusterName, Cluster: cluster, Err: err}
+		}(i, reg)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	logger.Info("批量注册集群完成", zap.Int("total", len(results)), zap.Int("failed", failed))
+	return results
+}
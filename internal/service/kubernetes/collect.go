@@ -0,0 +1,233 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"opscore/internal/log"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+)
+
+// AllResourceTypes 作为资源类型时表示命名空间内所有可列出且可创建的资源类型
+const AllResourceTypes = "*"
+
+// defaultResourceTypes 未指定资源类型时导出和迁移的资源
+var defaultResourceTypes = []string{
+	"deployments", "statefulsets", "services", "configmaps",
+	"secrets", "persistentvolumeclaims", "persistentvolumes", "cronjobs", "jobs",
+}
+
+// resourceTypeAliases 兼容旧接口中使用的资源类型写法
+var resourceTypeAliases = map[string]string{
+	"pvcs": "persistentvolumeclaims",
+	"pvs":  "persistentvolumes",
+}
+
+// excludedWildcardResources 展开 * 时跳过的资源：由控制器生成、运行时数据或无法跨集群复用的对象
+var excludedWildcardResources = sets.New(
+	"events", "events.events.k8s.io", "pods", "replicasets.apps", "controllerrevisions.apps",
+	"endpoints", "endpointslices.discovery.k8s.io", "leases.coordination.k8s.io",
+	"localsubjectaccessreviews.authorization.k8s.io", "bindings", "podmetrics.metrics.k8s.io",
+)
+
+// CollectedResources 同一资源类型下收集到的对象
+type CollectedResources struct {
+	ResourceType string
+	Mapping      *meta.RESTMapping
+	Objects      []*unstructured.Unstructured
+}
+
+// collectResources 通过发现接口和动态客户端收集资源，支持内置类型和 CRD。
+// 命名空间级资源从 namespace 中列出，集群级资源列出全部。已清理状态和服务端填充字段，
+// 由控制器管理的对象（例如 Deployment 创建的 ReplicaSet）和系统自动创建的对象会被跳过
+func collectResources(clients *ClusterClients, namespace string, resourceTypes []string) ([]CollectedResources, error) {
+	logger := log.GetLogger()
+	resourceTypes, err := expandResourceTypes(clients, namespace, resourceTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []CollectedResources
+	seen := sets.New[schema.GroupVersionResource]()
+	for _, resourceType := range resourceTypes {
+		mapping, err := clients.ResolveResource(resourceType)
+		if err != nil {
+			return nil, err
+		}
+		if seen.Has(mapping.Resource) {
+			continue
+		}
+		seen.Insert(mapping.Resource)
+
+		client := clients.Dynamic.Resource(mapping.Resource)
+		var list *unstructured.UnstructuredList
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			list, err = client.Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+		} else {
+			list, err = client.List(context.TODO(), metav1.ListOptions{})
+		}
+		if err != nil {
+			logger.Error("collectResources: failed to list resources", zap.Error(err), zap.String("resource", mapping.Resource.String()))
+			return nil, fmt.Errorf("获取%s失败: %w", mapping.Resource.Resource, err)
+		}
+
+		collected := CollectedResources{ResourceType: resourceType, Mapping: mapping}
+		gk := mapping.GroupVersionKind.GroupKind()
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if !shouldCollect(gk, obj) {
+				continue
+			}
+			obj.SetAPIVersion(mapping.GroupVersionKind.GroupVersion().String())
+			obj.SetKind(mapping.GroupVersionKind.Kind)
+			cleanUnstructured(gk, obj)
+			collected.Objects = append(collected.Objects, obj)
+		}
+		sort.Slice(collected.Objects, func(i, j int) bool { return collected.Objects[i].GetName() < collected.Objects[j].GetName() })
+		result = append(result, collected)
+	}
+	return result, nil
+}
+
+// expandResourceTypes 处理默认值、别名以及 *
+func expandResourceTypes(clients *ClusterClients, namespace string, resourceTypes []string) ([]string, error) {
+	if len(resourceTypes) == 0 {
+		return defaultResourceTypes, nil
+	}
+	var expanded []string
+	for _, resourceType := range resourceTypes {
+		resourceType = strings.ToLower(strings.TrimSpace(resourceType))
+		if alias, ok := resourceTypeAliases[resourceType]; ok {
+			resourceType = alias
+		}
+		if resourceType != AllResourceTypes {
+			expanded = append(expanded, resourceType)
+			continue
+		}
+		all, err := exportableResourceTypes(clients, namespace != "")
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, all...)
+	}
+	return expanded, nil
+}
+
+// exportableResourceTypes 通过发现接口列出支持 list 和 create 的资源类型，返回 resource.group 形式
+func exportableResourceTypes(clients *ClusterClients, namespaced bool) ([]string, error) {
+	lists, err := discovery.ServerPreferredResources(clients.Discovery)
+	if err != nil && len(lists) == 0 {
+		return nil, err
+	}
+	if err != nil {
+		log.GetLogger().Warn("部分 API 组发现失败", zap.String("clusterID", clients.ClusterID), zap.Error(err))
+	}
+	var types []string
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || r.Namespaced != namespaced {
+				continue
+			}
+			verbs := sets.New(r.Verbs...)
+			if !verbs.Has("list") || !verbs.Has("create") {
+				continue
+			}
+			name := r.Name
+			if gv.Group != "" {
+				name += "." + gv.Group
+			}
+			if !excludedWildcardResources.Has(name) {
+				types = append(types, name)
+			}
+		}
+	}
+	sort.Strings(types)
+	return types, nil
+}
+
+// shouldCollect 跳过由控制器管理的对象以及集群自动创建的对象
+func shouldCollect(gk schema.GroupKind, obj *unstructured.Unstructured) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Controller != nil && *ref.Controller {
+			return false
+		}
+	}
+	switch {
+	case gk.Group == "" && gk.Kind == "ConfigMap" && obj.GetName() == "kube-root-ca.crt":
+		return false
+	case gk.Group == "" && gk.Kind == "ServiceAccount" && obj.GetName() == "default":
+		return false
+	}
+	return true
+}
+
+// serverPopulatedFields 由 API Server 或控制器填充、不能在其他集群复用的字段
+var serverPopulatedFields = map[schema.GroupKind][][]string{
+	{Kind: "Service"}: {
+		{"spec", "clusterIP"},
+		{"spec", "clusterIPs"},
+		{"spec", "healthCheckNodePort"},
+	},
+	{Kind: "PersistentVolume"}: {
+		{"spec", "claimRef", "uid"},
+		{"spec", "claimRef", "resourceVersion"},
+	},
+	{Group: "batch", Kind: "Job"}: {
+		{"spec", "selector"},
+		{"spec", "template", "metadata", "labels", "controller-uid"},
+		{"spec", "template", "metadata", "labels", "batch.kubernetes.io/controller-uid"},
+		{"spec", "template", "metadata", "labels", "job-name"},
+		{"spec", "template", "metadata", "labels", "batch.kubernetes.io/job-name"},
+	},
+}
+
+// serverPopulatedAnnotations 由客户端工具或控制器写入的注解
+var serverPopulatedAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+	deploymentRevisionKey,
+	"pv.kubernetes.io/bind-completed",
+	"pv.kubernetes.io/bound-by-controller",
+	"volume.kubernetes.io/selected-node",
+}
+
+// cleanUnstructured 通用地清理状态、元数据中的服务端字段以及特定类型的服务端填充字段
+func cleanUnstructured(gk schema.GroupKind, obj *unstructured.Unstructured) {
+	unstructured.RemoveNestedField(obj.Object, "status")
+	for _, field := range []string{
+		"managedFields", "selfLink", "uid", "resourceVersion", "generation",
+		"creationTimestamp", "deletionTimestamp", "deletionGracePeriodSeconds", "ownerReferences",
+	} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		for _, key := range serverPopulatedAnnotations {
+			delete(annotations, key)
+		}
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		obj.SetAnnotations(annotations)
+	}
+
+	// 手动指定了 selector 的 Job 保留原样
+	if manual, _, _ := unstructured.NestedBool(obj.Object, "spec", "manualSelector"); manual && gk.Group == "batch" && gk.Kind == "Job" {
+		return
+	}
+	for _, path := range serverPopulatedFields[gk] {
+		unstructured.RemoveNestedField(obj.Object, path...)
+	}
+}
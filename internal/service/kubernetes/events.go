@@ -0,0 +1,417 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	coreError "opscore/error"
+	"opscore/internal/log"
+
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// EventObject 事件关联的对象
+type EventObject struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// EventInfo 事件展示信息，Warning 类型的事件 Warning 为 true 便于前端高亮
+type EventInfo struct {
+	Type           string      `json:"type"`
+	Warning        bool        `json:"warning"`
+	Reason         string      `json:"reason"`
+	Message        string      `json:"message"`
+	Count          int32       `json:"count"`
+	Source         string      `json:"source"`
+	InvolvedObject EventObject `json:"involvedObject"`
+	FirstTimestamp time.Time   `json:"firstTimestamp"`
+	LastTimestamp  time.Time   `json:"lastTimestamp"`
+}
+
+// EventQuery 列出事件时的过滤和分页参数
+type EventQuery struct {
+	Kind     string // 关联对象的 Kind
+	Name     string // 关联对象的名称
+	Type     string // Normal 或 Warning
+	Limit    int64
+	Continue string
+}
+
+// EventList 事件列表，按最近发生时间倒序
+type EventList struct {
+	Items         []EventInfo `json:"items"`
+	WarningCount  int         `json:"warningCount"`
+	ContinueToken string      `json:"continueToken"`
+}
+
+// ListEvents 列出命名空间内的事件，命名空间为空时列出所有命名空间，可按关联对象和类型过滤
+func ListEvents(clusterID, namespace string, query EventQuery) (*EventList, error) {
+	logger := log.GetLogger()
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	selector := fields.Set{}
+	if query.Kind != "" {
+		selector["involvedObject.kind"] = query.Kind
+	}
+	if query.Name != "" {
+		selector["involvedObject.name"] = query.Name
+	}
+	if query.Type != "" {
+		selector["type"] = query.Type
+	}
+	listOptions := metav1.ListOptions{
+		FieldSelector: selector.AsSelector().String(),
+		Limit:         query.Limit,
+		Continue:      query.Continue,
+	}
+
+	events, err := clients.Clientset.CoreV1().Events(namespace).List(context.TODO(), listOptions)
+	if err != nil {
+		logger.Error("ListEvents: failed to list events", zap.Error(err), zap.String("clusterID", clusterID), zap.String("namespace", namespace))
+		return nil, err
+	}
+
+	result := &EventList{Items: make([]EventInfo, 0, len(events.Items)), ContinueToken: events.Continue}
+	for i := range events.Items {
+		info := toEventInfo(&events.Items[i])
+		if info.Warning {
+			result.WarningCount++
+		}
+		result.Items = append(result.Items, info)
+	}
+	sort.SliceStable(result.Items, func(i, j int) bool {
+		return result.Items[i].LastTimestamp.After(result.Items[j].LastTimestamp)
+	})
+	return result, nil
+}
+
+// ListObjectEvents 列出某个资源对象的事件，resourceType 与通用资源接口一致
+func ListObjectEvents(clusterID, resourceType, namespace, name string) (*EventList, error) {
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := clients.ResolveResource(resourceType)
+	if err != nil {
+		return nil, err
+	}
+	return ListEvents(clusterID, namespace, EventQuery{Kind: mapping.GroupVersionKind.Kind, Name: name})
+}
+
+func toEventInfo(e *corev1.Event) EventInfo {
+	source := e.Source.Component
+	if source == "" {
+		source = e.ReportingController
+	}
+	if e.Source.Host != "" {
+		source += ", " + e.Source.Host
+	}
+	count := e.Count
+	if count == 0 && e.Series != nil {
+		count = e.Series.Count
+	}
+	if count == 0 {
+		count = 1
+	}
+	return EventInfo{
+		Type:    e.Type,
+		Warning: e.Type == corev1.EventTypeWarning,
+		Reason:  e.Reason,
+		Message: e.Message,
+		Count:   count,
+		Source:  source,
+		InvolvedObject: EventObject{
+			Kind:      e.InvolvedObject.Kind,
+			Name:      e.InvolvedObject.Name,
+			Namespace: e.InvolvedObject.Namespace,
+		},
+		FirstTimestamp: firstNonZeroTime(e.FirstTimestamp.Time, e.EventTime.Time, e.CreationTimestamp.Time),
+		LastTimestamp:  eventTime(e),
+	}
+}
+
+// eventTime 事件最近一次发生的时间，兼容 events.k8s.io 只填写 eventTime 的情况
+func eventTime(e *corev1.Event) time.Time {
+	if e.Series != nil && !e.Series.LastObservedTime.IsZero() {
+		return e.Series.LastObservedTime.Time
+	}
+	return firstNonZeroTime(e.LastTimestamp.Time, e.EventTime.Time, e.FirstTimestamp.Time, e.CreationTimestamp.Time)
+}
+
+func firstNonZeroTime(times ...time.Time) time.Time {
+	for _, t := range times {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// 时间线条目类别
+const (
+	TimelineEvent   = "event"   // Kubernetes 事件
+	TimelineRollout = "rollout" // ReplicaSet 或 ControllerRevision 的新版本
+	TimelineRestart = "restart" // 容器重启
+	TimelinePod     = "pod"     // Pod 创建
+)
+
+// TimelineEntry 工作负载时间线中的一条记录
+type TimelineEntry struct {
+	Time     time.Time   `json:"time"`
+	Category string      `json:"category"`
+	Warning  bool        `json:"warning"`
+	Object   EventObject `json:"object"`
+	Reason   string      `json:"reason"`
+	Message  string      `json:"message"`
+}
+
+// WorkloadTimeline 工作负载时间线，按时间正序
+type WorkloadTimeline struct {
+	Kind    string          `json:"kind"`
+	Name    string          `json:"name"`
+	Entries []TimelineEntry `json:"entries"`
+}
+
+// timelineScope 时间线涉及的对象：工作负载本身、版本记录和 Pod
+type timelineScope struct {
+	uids     map[types.UID]bool
+	selector *metav1.LabelSelector
+	entries  []TimelineEntry
+}
+
+// GetWorkloadTimeline 将工作负载及其 ReplicaSet、Pod 的事件、版本发布和容器重启合并为一条时间线。
+// 支持 Deployment、StatefulSet、DaemonSet、ReplicaSet、Job 和 Pod，since 为零时不限制起始时间
+func GetWorkloadTimeline(clusterID, resourceType, namespace, name string, since time.Time) (*WorkloadTimeline, error) {
+	logger := log.GetLogger()
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := clients.ResolveResource(resourceType)
+	if err != nil {
+		return nil, err
+	}
+	kind := mapping.GroupVersionKind.Kind
+	ctx := context.TODO()
+	cs := clients.Clientset
+
+	scope := &timelineScope{uids: map[types.UID]bool{}}
+	switch kind {
+	case "Deployment":
+		deploy, err := cs.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		scope.uids[deploy.UID] = true
+		scope.selector = deploy.Spec.Selector
+		rsList, err := cs.AppsV1().ReplicaSets(namespace).List(ctx, metav1.ListOptions{LabelSelector: metav1.FormatLabelSelector(deploy.Spec.Selector)})
+		if err != nil {
+			return nil, err
+		}
+		for i := range rsList.Items {
+			rs := &rsList.Items[i]
+			if !isOwnedBy(rs.OwnerReferences, deploy.UID) {
+				continue
+			}
+			scope.uids[rs.UID] = true
+			scope.entries = append(scope.entries, replicaSetRollout(rs))
+		}
+	case "StatefulSet":
+		sts, err := cs.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		scope.uids[sts.UID] = true
+		scope.selector = sts.Spec.Selector
+		if err := scope.addControllerRevisions(clients, namespace, sts.UID, sts.Spec.Selector); err != nil {
+			return nil, err
+		}
+	case "DaemonSet":
+		ds, err := cs.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		scope.uids[ds.UID] = true
+		scope.selector = ds.Spec.Selector
+		if err := scope.addControllerRevisions(clients, namespace, ds.UID, ds.Spec.Selector); err != nil {
+			return nil, err
+		}
+	case "ReplicaSet":
+		rs, err := cs.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		scope.uids[rs.UID] = true
+		scope.selector = rs.Spec.Selector
+		scope.entries = append(scope.entries, replicaSetRollout(rs))
+	case "Job":
+		job, err := cs.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		scope.uids[job.UID] = true
+		scope.selector = job.Spec.Selector
+	case "Pod":
+		pod, err := cs.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		scope.addPod(pod)
+	default:
+		return nil, fmt.Errorf("%w: timeline is not supported for %s", coreError.ErrInvalidResource, kind)
+	}
+
+	if scope.selector != nil {
+		pods, err := cs.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: metav1.FormatLabelSelector(scope.selector)})
+		if err != nil {
+			return nil, err
+		}
+		for i := range pods.Items {
+			scope.addPod(&pods.Items[i])
+		}
+	}
+
+	// 命名空间内的事件一次取回后按 UID 过滤，避免逐个对象查询
+	events, err := cs.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		logger.Error("GetWorkloadTimeline: failed to list events", zap.Error(err), zap.String("clusterID", clusterID), zap.String("namespace", namespace))
+		return nil, err
+	}
+	for i := range events.Items {
+		e := &events.Items[i]
+		if !scope.uids[e.InvolvedObject.UID] {
+			continue
+		}
+		info := toEventInfo(e)
+		scope.entries = append(scope.entries, TimelineEntry{
+			Time:     info.LastTimestamp,
+			Category: TimelineEvent,
+			Warning:  info.Warning,
+			Object:   info.InvolvedObject,
+			Reason:   info.Reason,
+			Message:  eventMessage(info),
+		})
+	}
+
+	timeline := &WorkloadTimeline{Kind: kind, Name: name, Entries: make([]TimelineEntry, 0, len(scope.entries))}
+	for _, entry := range scope.entries {
+		if since.IsZero() || !entry.Time.Before(since) {
+			timeline.Entries = append(timeline.Entries, entry)
+		}
+	}
+	sort.SliceStable(timeline.Entries, func(i, j int) bool { return timeline.Entries[i].Time.Before(timeline.Entries[j].Time) })
+	return timeline, nil
+}
+
+// addControllerRevisions 将 StatefulSet、DaemonSet 的 ControllerRevision 作为版本发布记录
+func (s *timelineScope) addControllerRevisions(clients *ClusterClients, namespace string, owner types.UID, selector *metav1.LabelSelector) error {
+	revisions, err := clients.Clientset.AppsV1().ControllerRevisions(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: metav1.FormatLabelSelector(selector)})
+	if err != nil {
+		return err
+	}
+	for i := range revisions.Items {
+		rev := &revisions.Items[i]
+		if !isOwnedBy(rev.OwnerReferences, owner) {
+			continue
+		}
+		s.entries = append(s.entries, TimelineEntry{
+			Time:     rev.CreationTimestamp.Time,
+			Category: TimelineRollout,
+			Object:   EventObject{Kind: "ControllerRevision", Name: rev.Name, Namespace: rev.Namespace},
+			Reason:   "RevisionCreated",
+			Message:  fmt.Sprintf("revision %d created", rev.Revision),
+		})
+	}
+	return nil
+}
+
+// addPod 记录 Pod 创建以及各容器最近一次重启
+func (s *timelineScope) addPod(pod *corev1.Pod) {
+	s.uids[pod.UID] = true
+	object := EventObject{Kind: "Pod", Name: pod.Name, Namespace: pod.Namespace}
+	s.entries = append(s.entries, TimelineEntry{
+		Time:     pod.CreationTimestamp.Time,
+		Category: TimelinePod,
+		Object:   object,
+		Reason:   "PodCreated",
+		Message:  fmt.Sprintf("pod created on node %s", pod.Spec.NodeName),
+	})
+
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		terminated := cs.LastTerminationState.Terminated
+		if cs.RestartCount == 0 || terminated == nil {
+			continue
+		}
+		s.entries = append(s.entries, TimelineEntry{
+			Time:     firstNonZeroTime(terminated.FinishedAt.Time, terminated.StartedAt.Time),
+			Category: TimelineRestart,
+			Warning:  terminated.ExitCode != 0,
+			Object:   object,
+			Reason:   terminated.Reason,
+			Message: fmt.Sprintf("container %s restarted (restart count %d, exit code %d)%s",
+				cs.Name, cs.RestartCount, terminated.ExitCode, prefixIfNotEmpty(": ", terminated.Message)),
+		})
+	}
+}
+
+// replicaSetRollout 将 ReplicaSet 转换为版本发布记录
+func replicaSetRollout(rs *appsv1.ReplicaSet) TimelineEntry {
+	revision := rs.Annotations[deploymentRevisionKey]
+	images := containerImages(rs.Spec.Template.Spec.Containers)
+	replicas := int32(0)
+	if rs.Spec.Replicas != nil {
+		replicas = *rs.Spec.Replicas
+	}
+	message := fmt.Sprintf("replicaset created with images %s, desired replicas %d", strings.Join(images, ", "), replicas)
+	if revision != "" {
+		if n, err := strconv.Atoi(revision); err == nil {
+			message = fmt.Sprintf("revision %d: %s", n, message)
+		}
+	}
+	return TimelineEntry{
+		Time:     rs.CreationTimestamp.Time,
+		Category: TimelineRollout,
+		Object:   EventObject{Kind: "ReplicaSet", Name: rs.Name, Namespace: rs.Namespace},
+		Reason:   "ReplicaSetCreated",
+		Message:  message,
+	}
+}
+
+func isOwnedBy(refs []metav1.OwnerReference, uid types.UID) bool {
+	for _, ref := range refs {
+		if ref.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// eventMessage 合并重复事件的次数
+func eventMessage(info EventInfo) string {
+	if info.Count > 1 {
+		return fmt.Sprintf("%s (x%d)", info.Message, info.Count)
+	}
+	return info.Message
+}
+
+func prefixIfNotEmpty(prefix, s string) string {
+	if s == "" {
+		return ""
+	}
+	return prefix + s
+}
@@ -0,0 +1,473 @@
+package kubernetes
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	coreError "opscore/error"
+	"opscore/internal/log"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// 导出格式
+const (
+	ExportFormatJSON      = "json"      // YAML 字符串数组，兼容旧接口
+	ExportFormatYAML      = "yaml"      // 单个多文档 YAML
+	ExportFormatTarGz     = "tar.gz"    // 每个资源一个文件，按资源类型分目录
+	ExportFormatZip       = "zip"       // 同 tar.gz，使用 zip 打包
+	ExportFormatHelm      = "helm"      // Helm chart 骨架，镜像和副本数提取到 values.yaml
+	ExportFormatKustomize = "kustomize" // Kustomize base 以及 overlays
+)
+
+const defaultChartVersion = "0.1.0"
+
+// archiveNamePattern chart 和 overlay 名称的格式，避免生成的归档路径越出根目录
+var archiveNamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([-_.a-zA-Z0-9]*[a-zA-Z0-9])?$`)
+
+// defaultOverlays 未指定时生成的 Kustomize overlays
+var defaultOverlays = []string{"staging", "production"}
+
+// podSpecPaths 各工作负载类型中 Pod spec 的位置
+var podSpecPaths = map[schema.GroupKind][]string{
+	{Kind: "Pod"}:                        {"spec"},
+	{Group: "apps", Kind: "Deployment"}:  {"spec", "template", "spec"},
+	{Group: "apps", Kind: "StatefulSet"}: {"spec", "template", "spec"},
+	{Group: "apps", Kind: "DaemonSet"}:   {"spec", "template", "spec"},
+	{Group: "apps", Kind: "ReplicaSet"}:  {"spec", "template", "spec"},
+	{Group: "batch", Kind: "Job"}:        {"spec", "template", "spec"},
+	{Group: "batch", Kind: "CronJob"}:    {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// ExportOptions 导出格式参数
+type ExportOptions struct {
+	Format       string
+	ChartName    string   // Helm chart 名称，默认为命名空间名
+	ChartVersion string   // Helm chart 版本，默认 0.1.0
+	Overlays     []string // Kustomize overlay 名称，默认 staging、production
+	SecretPolicy *SecretPolicy
+	Filter       *ResourceFilter
+}
+
+// ExportArchive 导出结果文件
+type ExportArchive struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// exportFile 归档中的一个文件
+type exportFile struct {
+	Path string
+	Data []byte
+}
+
+// ExportResourceArchive 按指定格式导出命名空间中的资源，返回可直接下载的文件
+func ExportResourceArchive(id, namespace string, resourceTypes []string, opts ExportOptions) (*ExportArchive, error) {
+	logger := log.GetLogger()
+	for _, name := range append([]string{opts.ChartName}, opts.Overlays...) {
+		if name != "" && !archiveNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("%w: invalid chart or overlay name %q", coreError.ErrInvalidResource, name)
+		}
+	}
+	clients, err := GetClusterClients(id)
+	if err != nil {
+		return nil, err
+	}
+	collected, err := collectExportResources(clients, namespace, resourceTypes, opts.SecretPolicy, opts.Filter)
+	if err != nil {
+		logger.Error("ExportResourceArchive: failed to collect resources", zap.Error(err), zap.String("cluster_id", id))
+		return nil, err
+	}
+
+	baseName := namespace
+	if baseName == "" {
+		baseName = "cluster"
+	}
+	var archive *ExportArchive
+	switch opts.Format {
+	case ExportFormatYAML:
+		data, err := multiDocumentYAML(collected)
+		if err != nil {
+			return nil, err
+		}
+		archive = &ExportArchive{FileName: baseName + ".yaml", ContentType: "application/yaml", Data: data}
+	case ExportFormatTarGz, ExportFormatZip:
+		files, err := resourceFiles(collected, baseName)
+		if err != nil {
+			return nil, err
+		}
+		archive, err = packFiles(files, baseName, opts.Format)
+		if err != nil {
+			return nil, err
+		}
+	case ExportFormatHelm:
+		chartName := opts.ChartName
+		if chartName == "" {
+			chartName = baseName
+		}
+		files, err := helmChartFiles(collected, chartName, opts.ChartVersion)
+		if err != nil {
+			return nil, err
+		}
+		archive, err = packFiles(files, chartName, ExportFormatTarGz)
+		if err != nil {
+			return nil, err
+		}
+	case ExportFormatKustomize:
+		files, err := kustomizeFiles(collected, baseName, namespace, opts.Overlays)
+		if err != nil {
+			return nil, err
+		}
+		archive, err = packFiles(files, baseName+"-kustomize", ExportFormatTarGz)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: unsupported export format %q", coreError.ErrInvalidResource, opts.Format)
+	}
+
+	logger.Info("ExportResourceArchive", zap.String("cluster_id", id), zap.String("namespace", namespace),
+		zap.String("format", opts.Format), zap.String("file", archive.FileName), zap.Int("size", len(archive.Data)))
+	return archive, nil
+}
+
+// collectExportResources 收集要导出的资源，按筛选条件过滤后对 Secret 应用策略
+func collectExportResources(clients *ClusterClients, namespace string, resourceTypes []string, secretPolicy *SecretPolicy, filter *ResourceFilter) ([]CollectedResources, error) {
+	policy, err := secretPolicy.compile(false)
+	if err != nil {
+		return nil, err
+	}
+	collected, err := collectResources(clients, namespace, resourceTypes)
+	if err != nil {
+		return nil, err
+	}
+	if collected, err = filterResources(clients, namespace, collected, filter); err != nil {
+		return nil, err
+	}
+	return applySecretPolicyToCollected(collected, policy)
+}
+
+// multiDocumentYAML 将所有资源写成以 --- 分隔的多文档 YAML
+func multiDocumentYAML(collected []CollectedResources) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, group := range collected {
+		for _, obj := range group.Objects {
+			data, err := yaml.Marshal(obj.Object)
+			if err != nil {
+				return nil, fmt.Errorf("转换资源为YAML失败: %w", err)
+			}
+			buffer.WriteString("---\n")
+			buffer.Write(data)
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// resourceFilePath 资源在归档中的相对路径：命名空间级资源为 <namespace>/<resource[.group]>/<name>.yaml，
+// 集群级资源为 <resource[.group]>/<name>.yaml。未指定命名空间导出时不同命名空间中的同名资源不会互相覆盖
+func resourceFilePath(mapping *meta.RESTMapping, obj *unstructured.Unstructured) string {
+	dir := mapping.Resource.Resource
+	if mapping.Resource.Group != "" {
+		dir += "." + mapping.Resource.Group
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace && obj.GetNamespace() != "" {
+		dir = path.Join(obj.GetNamespace(), dir)
+	}
+	return path.Join(dir, obj.GetName()+".yaml")
+}
+
+func resourceFiles(collected []CollectedResources, root string) ([]exportFile, error) {
+	var files []exportFile
+	for _, group := range collected {
+		for _, obj := range group.Objects {
+			data, err := yaml.Marshal(obj.Object)
+			if err != nil {
+				return nil, fmt.Errorf("转换资源为YAML失败: %w", err)
+			}
+			files = append(files, exportFile{Path: path.Join(root, resourceFilePath(group.Mapping, obj)), Data: data})
+		}
+	}
+	return files, nil
+}
+
+// packFiles 将文件打包为 tar.gz 或 zip
+func packFiles(files []exportFile, baseName, format string) (*ExportArchive, error) {
+	var buffer bytes.Buffer
+	now := time.Now()
+	if format == ExportFormatZip {
+		zw := zip.NewWriter(&buffer)
+		for _, f := range files {
+			w, err := zw.CreateHeader(&zip.FileHeader{Name: f.Path, Method: zip.Deflate, Modified: now})
+			if err != nil {
+				return nil, err
+			}
+			if _, err := w.Write(f.Data); err != nil {
+				return nil, err
+			}
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return &ExportArchive{FileName: baseName + ".zip", ContentType: "application/zip", Data: buffer.Bytes()}, nil
+	}
+
+	gw := gzip.NewWriter(&buffer)
+	tw := tar.NewWriter(gw)
+	for _, f := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: f.Path, Mode: 0o644, Size: int64(len(f.Data)), ModTime: now, Typeflag: tar.TypeReg}); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(f.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+	return &ExportArchive{FileName: baseName + ".tar.gz", ContentType: "application/gzip", Data: buffer.Bytes()}, nil
+}
+
+// imageRef 拆分后的镜像地址
+type imageRef struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+}
+
+// parseImage 拆分镜像的仓库和标签，带 digest 的镜像整体作为仓库
+func parseImage(image string) imageRef {
+	if strings.Contains(image, "@") {
+		return imageRef{Repository: image}
+	}
+	slash := strings.LastIndex(image, "/")
+	if colon := strings.LastIndex(image, ":"); colon > slash {
+		return imageRef{Repository: image[:colon], Tag: image[colon+1:]}
+	}
+	return imageRef{Repository: image}
+}
+
+// workloadContainers 返回工作负载中的普通容器和 init 容器，以及它们在对象中的字段路径
+func workloadContainers(gk schema.GroupKind, obj *unstructured.Unstructured) (map[string][]interface{}, []string) {
+	specPath, ok := podSpecPaths[gk]
+	if !ok {
+		return nil, nil
+	}
+	result := map[string][]interface{}{}
+	for _, field := range []string{"containers", "initContainers"} {
+		if containers, found, _ := unstructured.NestedSlice(obj.Object, append(append([]string{}, specPath...), field)...); found {
+			result[field] = containers
+		}
+	}
+	return result, specPath
+}
+
+// helmValueRef 生成访问 values 中嵌套键的模板表达式，使用 index 以支持包含 - 的键
+func helmValueRef(keys ...string) string {
+	quoted := make([]string, len(keys))
+	for i, k := range keys {
+		quoted[i] = fmt.Sprintf("%q", k)
+	}
+	return "index .Values " + strings.Join(quoted, " ")
+}
+
+// helmChartFiles 生成 Helm chart 骨架：工作负载的副本数和容器镜像提取到 values.yaml，
+// 命名空间使用 .Release.Namespace，资源中原有的 {{ }} 会被转义
+func helmChartFiles(collected []CollectedResources, chartName, chartVersion string) ([]exportFile, error) {
+	if chartVersion == "" {
+		chartVersion = defaultChartVersion
+	}
+	values := map[string]interface{}{}
+	escape := strings.NewReplacer("{{", `{{ "{{" }}`, "}}", `{{ "}}" }}`)
+	var files []exportFile
+
+	for _, group := range collected {
+		gk := group.Mapping.GroupVersionKind.GroupKind()
+		resourceKey := group.Mapping.Resource.Resource
+		for _, original := range group.Objects {
+			obj := original.DeepCopy()
+			placeholders := map[string]string{}
+			placeholder := func(expr string) string {
+				token := fmt.Sprintf("__OPSCORE_HELM_VALUE_%d__", len(placeholders))
+				placeholders[token] = expr
+				return token
+			}
+			objValues := map[string]interface{}{}
+
+			if replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); found {
+				objValues["replicas"] = replicas
+				_ = unstructured.SetNestedField(obj.Object, placeholder("{{ "+helmValueRef(resourceKey, obj.GetName(), "replicas")+" }}"), "spec", "replicas")
+			}
+			containersByField, specPath := workloadContainers(gk, obj)
+			images := map[string]interface{}{}
+			for field, containers := range containersByField {
+				for i, c := range containers {
+					container, ok := c.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					name, _ := container["name"].(string)
+					image, _ := container["image"].(string)
+					if name == "" || image == "" {
+						continue
+					}
+					ref := parseImage(image)
+					images[name] = map[string]interface{}{"repository": ref.Repository, "tag": ref.Tag}
+					repo := helmValueRef(resourceKey, obj.GetName(), "images", name, "repository")
+					tag := helmValueRef(resourceKey, obj.GetName(), "images", name, "tag")
+					container["image"] = placeholder(fmt.Sprintf(`"{{ %s }}{{ with %s }}:{{ . }}{{ end }}"`, repo, tag))
+					containers[i] = container
+				}
+				_ = unstructured.SetNestedSlice(obj.Object, containers, append(append([]string{}, specPath...), field)...)
+			}
+			if len(images) > 0 {
+				objValues["images"] = images
+			}
+			if len(objValues) > 0 {
+				byName, _ := values[resourceKey].(map[string]interface{})
+				if byName == nil {
+					byName = map[string]interface{}{}
+					values[resourceKey] = byName
+				}
+				byName[obj.GetName()] = objValues
+			}
+			if group.Mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+				obj.SetNamespace(placeholder("{{ .Release.Namespace }}"))
+			}
+
+			data, err := yaml.Marshal(obj.Object)
+			if err != nil {
+				return nil, fmt.Errorf("转换资源为YAML失败: %w", err)
+			}
+			rendered := escape.Replace(string(data))
+			for token, expr := range placeholders {
+				rendered = strings.ReplaceAll(rendered, token, expr)
+			}
+			files = append(files, exportFile{Path: path.Join(chartName, "templates", resourceFilePath(group.Mapping, obj)), Data: []byte(rendered)})
+		}
+	}
+
+	chart, err := yaml.Marshal(map[string]interface{}{
+		"apiVersion":  "v2",
+		"name":        chartName,
+		"description": "Exported by opscore",
+		"type":        "application",
+		"version":     chartVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+	valuesData, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	files = append([]exportFile{
+		{Path: path.Join(chartName, "Chart.yaml"), Data: chart},
+		{Path: path.Join(chartName, "values.yaml"), Data: valuesData},
+	}, files...)
+	return files, nil
+}
+
+// kustomizeFiles 生成 Kustomize base 和 overlays。base 包含导出的资源，
+// 每个 overlay 引用 base，并列出当前的镜像标签和副本数便于按环境修改
+func kustomizeFiles(collected []CollectedResources, root, namespace string, overlays []string) ([]exportFile, error) {
+	if len(overlays) == 0 {
+		overlays = defaultOverlays
+	}
+	var (
+		files     []exportFile
+		resources []string
+		replicas  []map[string]interface{}
+	)
+	images := map[string]string{}
+	for _, group := range collected {
+		gk := group.Mapping.GroupVersionKind.GroupKind()
+		for _, obj := range group.Objects {
+			data, err := yaml.Marshal(obj.Object)
+			if err != nil {
+				return nil, fmt.Errorf("转换资源为YAML失败: %w", err)
+			}
+			rel := resourceFilePath(group.Mapping, obj)
+			resources = append(resources, rel)
+			files = append(files, exportFile{Path: path.Join(root, "base", rel), Data: data})
+
+			// kustomize 的 replicas 字段只支持 Deployment、StatefulSet 等带 spec.replicas 的类型
+			if count, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas"); found && podSpecPaths[gk] != nil {
+				replicas = append(replicas, map[string]interface{}{"name": obj.GetName(), "count": count})
+			}
+			containersByField, _ := workloadContainers(gk, obj)
+			for _, containers := range containersByField {
+				for _, c := range containers {
+					if container, ok := c.(map[string]interface{}); ok {
+						if image, _ := container["image"].(string); image != "" {
+							ref := parseImage(image)
+							images[ref.Repository] = ref.Tag
+						}
+					}
+				}
+			}
+		}
+	}
+
+	base := map[string]interface{}{
+		"apiVersion": "kustomize.config.k8s.io/v1beta1",
+		"kind":       "Kustomization",
+		"resources":  resources,
+	}
+	if namespace != "" {
+		base["namespace"] = namespace
+	}
+	baseData, err := yaml.Marshal(base)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, exportFile{Path: path.Join(root, "base", "kustomization.yaml"), Data: baseData})
+
+	repositories := make([]string, 0, len(images))
+	for repo := range images {
+		repositories = append(repositories, repo)
+	}
+	sort.Strings(repositories)
+	imageEntries := make([]map[string]interface{}, 0, len(repositories))
+	for _, repo := range repositories {
+		entry := map[string]interface{}{"name": repo}
+		if tag := images[repo]; tag != "" {
+			entry["newTag"] = tag
+		}
+		imageEntries = append(imageEntries, entry)
+	}
+
+	for _, overlay := range overlays {
+		overlayData := map[string]interface{}{
+			"apiVersion": "kustomize.config.k8s.io/v1beta1",
+			"kind":       "Kustomization",
+			"resources":  []string{"../../base"},
+		}
+		if len(imageEntries) > 0 {
+			overlayData["images"] = imageEntries
+		}
+		if len(replicas) > 0 {
+			overlayData["replicas"] = replicas
+		}
+		data, err := yaml.Marshal(overlayData)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, exportFile{Path: path.Join(root, "overlays", overlay, "kustomization.yaml"), Data: data})
+	}
+	return files, nil
+}
@@ -0,0 +1,321 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	coreError "opscore/error"
+	"opscore/internal/log"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ObjectRef 按 Kind 和名称指定的对象，Kind 不区分大小写
+type ObjectRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// ResourceFilter 导出和迁移时的对象筛选条件。
+// 包含条件：同时满足 labelSelector 和 includeNames（设置了才检查），或者在 includeObjects 中；
+// 三者都未设置时包含所有对象。排除条件任意一项匹配即排除，优先于包含条件和引用跟踪
+type ResourceFilter struct {
+	LabelSelector        string      `json:"labelSelector"`
+	IncludeNames         []string    `json:"includeNames"` // 名称通配符，支持 * 和 ?
+	IncludeObjects       []ObjectRef `json:"includeObjects"`
+	ExcludeLabelSelector string      `json:"excludeLabelSelector"`
+	ExcludeNames         []string    `json:"excludeNames"`
+	ExcludeObjects       []ObjectRef `json:"excludeObjects"`
+	// FollowReferences 同时包含选中的工作负载引用的 ConfigMap、Secret、PVC、ServiceAccount，
+	// 以及选择这些工作负载的 Service，即使它们的类型不在请求的资源类型中
+	FollowReferences bool `json:"followReferences"`
+}
+
+// compiledResourceFilter 已解析选择器的筛选条件
+type compiledResourceFilter struct {
+	ResourceFilter
+	selector        labels.Selector
+	excludeSelector labels.Selector
+}
+
+// compile 校验筛选条件，nil 表示不筛选
+func (f *ResourceFilter) compile() (*compiledResourceFilter, error) {
+	if f == nil {
+		return nil, nil
+	}
+	compiled := &compiledResourceFilter{ResourceFilter: *f}
+	var err error
+	if f.LabelSelector != "" {
+		if compiled.selector, err = labels.Parse(f.LabelSelector); err != nil {
+			return nil, fmt.Errorf("%w: invalid labelSelector: %v", coreError.ErrInvalidResource, err)
+		}
+	}
+	if f.ExcludeLabelSelector != "" {
+		if compiled.excludeSelector, err = labels.Parse(f.ExcludeLabelSelector); err != nil {
+			return nil, fmt.Errorf("%w: invalid excludeLabelSelector: %v", coreError.ErrInvalidResource, err)
+		}
+	}
+	for _, pattern := range append(append([]string{}, f.IncludeNames...), f.ExcludeNames...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%w: invalid name pattern %q", coreError.ErrInvalidResource, pattern)
+		}
+	}
+	return compiled, nil
+}
+
+// included 判断对象是否满足包含条件
+func (f *compiledResourceFilter) included(gk schema.GroupKind, obj *unstructured.Unstructured) bool {
+	if matchObjectRefs(f.IncludeObjects, gk, obj) {
+		return true
+	}
+	if f.selector == nil && len(f.IncludeNames) == 0 {
+		return len(f.IncludeObjects) == 0
+	}
+	if f.selector != nil && !f.selector.Matches(labels.Set(obj.GetLabels())) {
+		return false
+	}
+	return len(f.IncludeNames) == 0 || matchNamePatterns(f.IncludeNames, obj.GetName())
+}
+
+// excluded 判断对象是否满足任意排除条件
+func (f *compiledResourceFilter) excluded(gk schema.GroupKind, obj *unstructured.Unstructured) bool {
+	if f.excludeSelector != nil && f.excludeSelector.Matches(labels.Set(obj.GetLabels())) {
+		return true
+	}
+	return matchNamePatterns(f.ExcludeNames, obj.GetName()) || matchObjectRefs(f.ExcludeObjects, gk, obj)
+}
+
+// matchNamePatterns 名称是否匹配任一通配符
+func matchNamePatterns(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchObjectRefs 对象是否在列表中
+func matchObjectRefs(refs []ObjectRef, gk schema.GroupKind, obj *unstructured.Unstructured) bool {
+	for _, ref := range refs {
+		if strings.EqualFold(ref.Kind, gk.Kind) && ref.Name == obj.GetName() {
+			return true
+		}
+	}
+	return false
+}
+
+// filterResources 按筛选条件过滤已收集的资源，开启引用跟踪时从源集群补充被引用的对象。
+// 补充的对象加入对应类型的分组，该类型未被收集时新建分组
+func filterResources(clients *ClusterClients, namespace string, collected []CollectedResources, filter *ResourceFilter) ([]CollectedResources, error) {
+	f, err := filter.compile()
+	if err != nil || f == nil {
+		return collected, err
+	}
+
+	for i := range collected {
+		gk := collected[i].Mapping.GroupVersionKind.GroupKind()
+		var kept []*unstructured.Unstructured
+		for _, obj := range collected[i].Objects {
+			if f.included(gk, obj) && !f.excluded(gk, obj) {
+				kept = append(kept, obj)
+			}
+		}
+		collected[i].Objects = kept
+	}
+	if !f.FollowReferences || namespace == "" {
+		return collected, nil
+	}
+	return followReferences(clients, namespace, collected, f)
+}
+
+// referencedTypes 引用跟踪涉及的类型
+var referencedTypes = map[string]string{
+	"ConfigMap":             "configmaps",
+	"Secret":                "secrets",
+	"PersistentVolumeClaim": "persistentvolumeclaims",
+	"ServiceAccount":        "serviceaccounts",
+	"Service":               "services",
+}
+
+// followReferences 补充选中工作负载引用的对象以及选择它们的 Service
+func followReferences(clients *ClusterClients, namespace string, collected []CollectedResources, f *compiledResourceFilter) ([]CollectedResources, error) {
+	logger := log.GetLogger()
+	present := map[ObjectRef]bool{}
+	refs := map[ObjectRef]bool{}
+	var podLabels []labels.Set
+	for _, group := range collected {
+		gk := group.Mapping.GroupVersionKind.GroupKind()
+		for _, obj := range group.Objects {
+			present[ObjectRef{Kind: gk.Kind, Name: obj.GetName()}] = true
+			podSpec, templateLabels, ok := podTemplate(gk, obj)
+			if !ok {
+				continue
+			}
+			for _, ref := range podSpecReferences(podSpec) {
+				refs[ref] = true
+			}
+			if len(templateLabels) > 0 {
+				podLabels = append(podLabels, templateLabels)
+			}
+		}
+	}
+
+	added := map[string][]*unstructured.Unstructured{}
+	for ref := range refs {
+		if present[ref] {
+			continue
+		}
+		mapping, err := clients.ResolveResource(referencedTypes[ref.Kind])
+		if err != nil {
+			return nil, err
+		}
+		obj, err := clients.Dynamic.Resource(mapping.Resource).Namespace(namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			logger.Warn("被引用的对象不存在", zap.String("kind", ref.Kind), zap.String("name", ref.Name))
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("获取%s %s失败: %w", ref.Kind, ref.Name, err)
+		}
+		added[ref.Kind] = append(added[ref.Kind], obj)
+	}
+
+	// 选择了任一选中工作负载的 Service
+	if len(podLabels) > 0 {
+		mapping, err := clients.ResolveResource(referencedTypes["Service"])
+		if err != nil {
+			return nil, err
+		}
+		services, err := clients.Dynamic.Resource(mapping.Resource).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("获取services失败: %w", err)
+		}
+		for i := range services.Items {
+			service := &services.Items[i]
+			if present[ObjectRef{Kind: "Service", Name: service.GetName()}] {
+				continue
+			}
+			selector, _, _ := unstructured.NestedStringMap(service.Object, "spec", "selector")
+			if len(selector) == 0 {
+				continue
+			}
+			for _, set := range podLabels {
+				if labels.SelectorFromSet(selector).Matches(set) {
+					added["Service"] = append(added["Service"], service)
+					break
+				}
+			}
+		}
+	}
+
+	kinds := make([]string, 0, len(added))
+	for kind := range added {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		mapping, err := clients.ResolveResource(referencedTypes[kind])
+		if err != nil {
+			return nil, err
+		}
+		gk := mapping.GroupVersionKind.GroupKind()
+		index := -1
+		for i := range collected {
+			if collected[i].Mapping.Resource == mapping.Resource {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			collected = append(collected, CollectedResources{ResourceType: mapping.Resource.Resource, Mapping: mapping})
+			index = len(collected) - 1
+		}
+		for _, obj := range added[kind] {
+			if !shouldCollect(gk, obj) || f.excluded(gk, obj) {
+				continue
+			}
+			obj.SetAPIVersion(mapping.GroupVersionKind.GroupVersion().String())
+			obj.SetKind(mapping.GroupVersionKind.Kind)
+			cleanUnstructured(gk, obj)
+			collected[index].Objects = append(collected[index].Objects, obj)
+		}
+		objects := collected[index].Objects
+		sort.Slice(objects, func(i, j int) bool { return objects[i].GetName() < objects[j].GetName() })
+	}
+	return collected, nil
+}
+
+// podTemplate 返回工作负载的 Pod spec 和 Pod 标签
+func podTemplate(gk schema.GroupKind, obj *unstructured.Unstructured) (map[string]interface{}, labels.Set, bool) {
+	specPath, ok := podSpecPaths[gk]
+	if !ok {
+		return nil, nil, false
+	}
+	podSpec, found, _ := unstructured.NestedMap(obj.Object, specPath...)
+	if !found {
+		return nil, nil, false
+	}
+	labelsPath := append(append([]string{}, specPath[:len(specPath)-1]...), "metadata", "labels")
+	templateLabels, _, _ := unstructured.NestedStringMap(obj.Object, labelsPath...)
+	return podSpec, templateLabels, true
+}
+
+// podSpecReferences 提取 Pod spec 中引用的 ConfigMap、Secret、PVC 和 ServiceAccount
+func podSpecReferences(podSpec map[string]interface{}) []ObjectRef {
+	var refs []ObjectRef
+	add := func(kind string, obj map[string]interface{}, fields ...string) {
+		if name, _, _ := unstructured.NestedString(obj, fields...); name != "" {
+			refs = append(refs, ObjectRef{Kind: kind, Name: name})
+		}
+	}
+
+	if name, _, _ := unstructured.NestedString(podSpec, "serviceAccountName"); name != "" {
+		refs = append(refs, ObjectRef{Kind: "ServiceAccount", Name: name})
+	}
+	for _, item := range nestedMaps(podSpec, "imagePullSecrets") {
+		add("Secret", item, "name")
+	}
+	for _, volume := range nestedMaps(podSpec, "volumes") {
+		add("ConfigMap", volume, "configMap", "name")
+		add("Secret", volume, "secret", "secretName")
+		add("PersistentVolumeClaim", volume, "persistentVolumeClaim", "claimName")
+		for _, source := range nestedMaps(volume, "projected", "sources") {
+			add("ConfigMap", source, "configMap", "name")
+			add("Secret", source, "secret", "name")
+		}
+	}
+	for _, field := range []string{"containers", "initContainers"} {
+		for _, container := range nestedMaps(podSpec, field) {
+			for _, envFrom := range nestedMaps(container, "envFrom") {
+				add("ConfigMap", envFrom, "configMapRef", "name")
+				add("Secret", envFrom, "secretRef", "name")
+			}
+			for _, env := range nestedMaps(container, "env") {
+				add("ConfigMap", env, "valueFrom", "configMapKeyRef", "name")
+				add("Secret", env, "valueFrom", "secretKeyRef", "name")
+			}
+		}
+	}
+	return refs
+}
+
+// nestedMaps 返回对象数组字段中的所有对象
+func nestedMaps(obj map[string]interface{}, fields ...string) []map[string]interface{} {
+	items, _, _ := unstructured.NestedSlice(obj, fields...)
+	result := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
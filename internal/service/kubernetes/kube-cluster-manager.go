@@ -2,6 +2,8 @@ package kubernetes
 
 import (
 	"context"
+	"fmt"
+	coreError "opscore/error"
 	"opscore/internal/db"
 	"opscore/internal/log"
 	// "time" // gorm.Model 会自动处理 CreatedAt
@@ -43,16 +45,22 @@ func NewK8sClient(kubeconfigData string) (*kubernetes.Clientset, error) {
 }
 
 // AddCluster 添加集群，测试连接，获取版本，并保存元数据。
-// 参数更新为 clusterName, comment, kubeconfig。
+// 各种认证方式的凭证统一转换为 kubeconfig，加密后入库。
 // 返回创建的 K8sClusterMetaData 对象指针和错误。
-func AddCluster(clusterName, comment, kubeconfig string) (*model.K8sClusterMetaData, error) {
+func AddCluster(clusterName, comment string, creds ClusterCredentials) (*model.K8sClusterMetaData, error) {
 	logger := log.GetLogger()
 
+	kubeconfig, err := BuildKubeConfig(creds)
+	if err != nil {
+		logger.Error("构建集群kubeconfig失败", zap.Error(err), zap.String("clusterName", clusterName), zap.String("authType", creds.AuthType))
+		return nil, err
+	}
+
 	// 1. 创建 Kubernetes 客户端
 	client, err := NewK8sClient(kubeconfig) // 假设 NewK8sClient 函数已存在
 	if err != nil {
 		logger.Error("创建Kubernetes客户端失败", zap.Error(err), zap.String("clusterName", clusterName))
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", coreError.ErrInvalidKubeConfig, err)
 	}
 	logger.Info("Kubernetes客户端创建成功", zap.String("clusterName", clusterName))
 
@@ -61,20 +69,27 @@ func AddCluster(clusterName, comment, kubeconfig string) (*model.K8sClusterMetaD
 	if err != nil {
 		// TestAndGetClusterVersion 内部会记录错误
 		logger.Error("集群连接测试或获取版本失败", zap.Error(err), zap.String("clusterName", clusterName))
-		return nil, err
+		return nil, fmt.Errorf("%w: %v", coreError.ErrInvalidKubeConfig, err)
 	}
 	logger.Info("集群连接测试成功", zap.String("clusterName", clusterName), zap.String("version", clusterVersion))
 
+	encrypted, err := encryptKubeConfig(kubeconfig)
+	if err != nil {
+		logger.Error("加密kubeconfig失败", zap.Error(err), zap.String("clusterName", clusterName))
+		return nil, err
+	}
+
 	// 3. 准备要存入数据库的集群元数据
 	// K8sClusterMetaData 结构体需要确保已更新以包含这些字段
 	clusterData := model.K8sClusterMetaData{
 		// gorm.Model 会自动填充 ID, CreatedAt, UpdatedAt, DeletedAt
 		ClusterName: clusterName,
 		Comment:     comment,
-		KubeConfig:  kubeconfig,
+		KubeConfig:  encrypted,
+		AuthType:    authTypeOrDefault(creds.AuthType),
 		ClusterID:   uuid.New().String(), // 生成一个唯一的字符串ID
 		Version:     clusterVersion,
-		Status:      "connected",         // 如果测试通过，状态为 "connected"
+		Status:      ClusterStatusConnected, // 如果测试通过，状态为 "connected"
 	}
 
 	dbInstance := db.DBInstance
@@ -119,3 +134,152 @@ func TestAndGetClusterVersion(client *kubernetes.Clientset) (string, error) {
 
 
 
+// ClusterStatusConnected 集群连接正常，不可达等其他状态见 cluster-health.go
+const ClusterStatusConnected = "connected"
+
+// UpdateClusterInput 更新集群的参数，字段为 nil 表示保持不变
+type UpdateClusterInput struct {
+	ClusterName *string
+	Comment     *string
+	Credentials *ClusterCredentials
+}
+
+// authTypeOrDefault 未指定认证方式时视为 kubeconfig
+func authTypeOrDefault(authType string) string {
+	if authType == "" {
+		return AuthTypeKubeConfig
+	}
+	return authType
+}
+
+// UpdateCluster 更新集群信息。更换凭证时先通过 TestAndGetClusterVersion 校验，
+// 校验失败不会保存，成功后加密入库并刷新 Version 和 Status。
+func UpdateCluster(clusterID string, input UpdateClusterInput) (*model.K8sClusterMetaData, error) {
+	logger := log.GetLogger()
+
+	cluster, err := GetClusterByClusterID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.ClusterName != nil && *input.ClusterName != "" {
+		cluster.ClusterName = *input.ClusterName
+	}
+	if input.Comment != nil {
+		cluster.Comment = *input.Comment
+	}
+	if input.Credentials != nil {
+		if err := updateClusterCredentials(cluster, *input.Credentials); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := db.DBInstance.DB.Save(cluster).Error; err != nil {
+		logger.Error("更新集群元数据失败", zap.Error(err), zap.String("clusterID", clusterID))
+		return nil, err
+	}
+
+	InvalidateCluster(cluster.ClusterID)
+
+	logger.Info("成功更新集群", zap.String("clusterName", cluster.ClusterName), zap.String("clusterID", cluster.ClusterID))
+	return cluster, nil
+}
+
+// updateClusterCredentials 校验新凭证并替换集群的 kubeconfig，凭证未变化时不做处理
+func updateClusterCredentials(cluster *model.K8sClusterMetaData, creds ClusterCredentials) error {
+	logger := log.GetLogger()
+	clusterID := cluster.ClusterID
+
+	kubeconfig, err := BuildKubeConfig(creds)
+	if err != nil {
+		logger.Error("构建集群kubeconfig失败", zap.Error(err), zap.String("clusterID", clusterID))
+		return err
+	}
+	if current, err := ClusterKubeConfig(cluster); err == nil && current == kubeconfig {
+		return nil
+	}
+
+	client, err := NewK8sClient(kubeconfig)
+	if err != nil {
+		logger.Error("新的kubeconfig无效", zap.Error(err), zap.String("clusterID", clusterID))
+		return fmt.Errorf("%w: %v", coreError.ErrInvalidKubeConfig, err)
+	}
+	version, err := TestAndGetClusterVersion(client)
+	if err != nil {
+		logger.Error("新的kubeconfig连接测试失败", zap.Error(err), zap.String("clusterID", clusterID))
+		return fmt.Errorf("%w: %v", coreError.ErrInvalidKubeConfig, err)
+	}
+
+	encrypted, err := encryptKubeConfig(kubeconfig)
+	if err != nil {
+		return err
+	}
+	cluster.KubeConfig = encrypted
+	cluster.Version = version
+	cluster.AuthType = authTypeOrDefault(creds.AuthType)
+	cluster.Status = ClusterStatusConnected
+	return nil
+}
+
+// DeleteCluster 删除集群。默认软删除，purge 为 true 时从数据库中彻底删除。
+func DeleteCluster(clusterID string, purge bool) error {
+	logger := log.GetLogger()
+
+	cluster, err := GetClusterByClusterID(clusterID)
+	if err != nil {
+		return err
+	}
+
+	tx := db.DBInstance.DB
+	if purge {
+		tx = tx.Unscoped()
+	}
+	if err := tx.Delete(cluster).Error; err != nil {
+		logger.Error("删除集群失败", zap.Error(err), zap.String("clusterID", clusterID))
+		return err
+	}
+
+	InvalidateCluster(cluster.ClusterID)
+
+	logger.Info("成功删除集群", zap.String("clusterName", cluster.ClusterName), zap.String("clusterID", cluster.ClusterID), zap.Bool("purge", purge))
+	return nil
+}
+
+// TestCluster 重新测试集群连通性，并将最新的 Version 和 Status 写回数据库。
+// 连接失败时返回更新为 error 状态后的集群信息以及连接错误。
+func TestCluster(clusterID string) (*model.K8sClusterMetaData, error) {
+	logger := log.GetLogger()
+
+	cluster, err := GetClusterByClusterID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var testErr error
+	kubeconfig, err := ClusterKubeConfig(cluster)
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewK8sClient(kubeconfig)
+	if err != nil {
+		testErr = err
+	} else if version, err := TestAndGetClusterVersion(client); err != nil {
+		testErr = err
+	} else {
+		cluster.Version = version
+	}
+
+	cluster.Status = ClusterStatusConnected
+	if testErr != nil {
+		cluster.Status = ClusterStatusUnreachable
+	}
+	if err := db.DBInstance.DB.Model(cluster).Updates(map[string]interface{}{
+		"version": cluster.Version,
+		"status":  cluster.Status,
+	}).Error; err != nil {
+		logger.Error("更新集群状态失败", zap.Error(err), zap.String("clusterID", clusterID))
+		return nil, err
+	}
+
+	return cluster, testErr
+}
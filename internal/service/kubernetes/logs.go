@@ -0,0 +1,166 @@
+package kubernetes
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"opscore/internal/log"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// maxAggregatedStreams 聚合日志时最多同时读取的容器数
+	maxAggregatedStreams = 50
+	// maxLogLineSize 单行日志的最大长度
+	maxLogLineSize = 1024 * 1024
+)
+
+// LogOptions 读取容器日志的参数
+type LogOptions struct {
+	Container    string     // 容器名，为空时单 Pod 日志使用默认容器，聚合日志读取所有容器
+	TailLines    *int64     // 只返回最后 N 行
+	SinceTime    *time.Time // 只返回该时间之后的日志
+	SinceSeconds *int64     // 只返回最近 N 秒的日志
+	Previous     bool       // 读取上一次终止的容器的日志
+	Follow       bool       // 持续跟踪新日志
+	Timestamps   bool       // 每行前加上 RFC3339 时间戳
+}
+
+// LogLine 带来源信息的一行日志
+type LogLine struct {
+	Pod       string    `json:"pod"`
+	Container string    `json:"container"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+}
+
+func (o LogOptions) podLogOptions(container string) *corev1.PodLogOptions {
+	opts := &corev1.PodLogOptions{
+		Container:    container,
+		TailLines:    o.TailLines,
+		SinceSeconds: o.SinceSeconds,
+		Previous:     o.Previous,
+		Follow:       o.Follow,
+		Timestamps:   o.Timestamps,
+	}
+	if o.SinceTime != nil {
+		since := metav1.NewTime(*o.SinceTime)
+		opts.SinceTime = &since
+	}
+	return opts
+}
+
+// StreamPodLogs 打开单个 Pod 容器的日志流，调用方负责关闭。ctx 取消时流随之结束
+func StreamPodLogs(ctx context.Context, clusterID, namespace, pod string, opts LogOptions) (io.ReadCloser, error) {
+	logger := log.GetLogger()
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("StreamPodLogs", zap.String("clusterID", clusterID), zap.String("namespace", namespace), zap.String("pod", pod),
+		zap.String("container", opts.Container), zap.Bool("follow", opts.Follow), zap.Bool("previous", opts.Previous))
+	stream, err := clients.Clientset.CoreV1().Pods(namespace).GetLogs(pod, opts.podLogOptions(opts.Container)).Stream(ctx)
+	if err != nil {
+		logger.Error("StreamPodLogs: failed to open log stream", zap.Error(err), zap.String("pod", pod))
+		return nil, err
+	}
+	return stream, nil
+}
+
+// AggregateLogs 读取命名空间内匹配标签选择器的所有 Pod 的日志，按时间戳交错输出，类似 stern。
+// 非 follow 模式下收集全部日志后按时间排序；follow 模式下按到达顺序实时输出，直到 ctx 取消。
+// 返回的通道在所有日志流结束后关闭
+func AggregateLogs(ctx context.Context, clusterID, namespace, labelSelector string, opts LogOptions) (<-chan LogLine, error) {
+	logger := log.GetLogger()
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := clients.Clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		logger.Error("AggregateLogs: failed to list pods", zap.Error(err), zap.String("labelSelector", labelSelector))
+		return nil, err
+	}
+
+	type target struct{ pod, container string }
+	var targets []target
+	for _, pod := range pods.Items {
+		for _, c := range pod.Spec.Containers {
+			if opts.Container == "" || opts.Container == c.Name {
+				targets = append(targets, target{pod: pod.Name, container: c.Name})
+			}
+		}
+	}
+	if len(targets) > maxAggregatedStreams {
+		return nil, fmt.Errorf("label selector %q matches %d containers, at most %d are allowed", labelSelector, len(targets), maxAggregatedStreams)
+	}
+
+	// 聚合时总是请求时间戳，用于排序和展示
+	opts.Timestamps = true
+	lines := make(chan LogLine, 256)
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(pod, container string) {
+			defer wg.Done()
+			stream, err := clients.Clientset.CoreV1().Pods(namespace).GetLogs(pod, opts.podLogOptions(container)).Stream(ctx)
+			if err != nil {
+				logger.Warn("AggregateLogs: failed to open log stream", zap.Error(err), zap.String("pod", pod), zap.String("container", container))
+				return
+			}
+			defer stream.Close()
+			scanLogLines(ctx, stream, pod, container, lines)
+		}(t.pod, t.container)
+	}
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+
+	if opts.Follow {
+		return lines, nil
+	}
+
+	// 非 follow 模式：收集后按时间排序再输出
+	var collected []LogLine
+	for line := range lines {
+		collected = append(collected, line)
+	}
+	sort.SliceStable(collected, func(i, j int) bool { return collected[i].Timestamp.Before(collected[j].Timestamp) })
+	sorted := make(chan LogLine, len(collected))
+	for _, line := range collected {
+		sorted <- line
+	}
+	close(sorted)
+	return sorted, nil
+}
+
+// scanLogLines 按行读取带时间戳的日志流并发送到通道
+func scanLogLines(ctx context.Context, stream io.Reader, pod, container string, out chan<- LogLine) {
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		line := LogLine{Pod: pod, Container: container, Message: scanner.Text()}
+		if ts, msg, ok := strings.Cut(line.Message, " "); ok {
+			if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+				line.Timestamp = t
+				line.Message = msg
+			}
+		}
+		select {
+		case out <- line:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"opscore/internal/log"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// metrics-server 提供的资源使用量 API
+var (
+	metricsGroupVersion = schema.GroupVersion{Group: "metrics.k8s.io", Version: "v1beta1"}
+	podMetricsResource  = metricsGroupVersion.WithResource("pods")
+	nodeMetricsResource = metricsGroupVersion.WithResource("nodes")
+)
+
+// ResourceUsage CPU（毫核）和内存（字节）使用量
+type ResourceUsage struct {
+	CPUMilli    int64
+	MemoryBytes int64
+}
+
+// podUsage 单个 Pod 的使用量，按容器汇总
+type podUsage struct {
+	Total      ResourceUsage
+	Containers map[string]ResourceUsage
+}
+
+// NodeUsage 节点资源使用情况，MetricsAvailable 为 false 时使用量字段为空
+type NodeUsage struct {
+	Name              string  `json:"name"`
+	Ready             bool    `json:"ready"`
+	Cpu               string  `json:"cpu"`
+	Memory            string  `json:"memory"`
+	CpuAllocatable    string  `json:"cpuAllocatable"`
+	MemoryAllocatable string  `json:"memoryAllocatable"`
+	CpuPercent        float64 `json:"cpuPercent"`
+	MemoryPercent     float64 `json:"memoryPercent"`
+	CpuMillicores     int64   `json:"cpuMillicores"`
+	MemoryBytes       int64   `json:"memoryBytes"`
+}
+
+// NodeUsageList 节点使用情况列表
+type NodeUsageList struct {
+	MetricsAvailable bool        `json:"metricsAvailable"`
+	Nodes            []NodeUsage `json:"nodes"`
+}
+
+// TopPodList 按使用量排序的 Pod 列表
+type TopPodList struct {
+	MetricsAvailable bool      `json:"metricsAvailable"`
+	SortBy           string    `json:"sortBy"`
+	Pods             []PodInfo `json:"pods"`
+}
+
+// 排序依据
+const (
+	SortByCPU    = "cpu"
+	SortByMemory = "memory"
+)
+
+// listPodMetrics 获取命名空间内的 Pod 使用量，键为 namespace/name。
+// 集群未安装 metrics-server 或请求失败时返回 false，调用方按无指标处理
+func listPodMetrics(clients *ClusterClients, namespace string) (map[string]podUsage, bool) {
+	list, err := clients.Dynamic.Resource(podMetricsResource).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		log.GetLogger().Debug("获取 Pod 指标失败，可能未安装 metrics-server", zap.String("clusterID", clients.ClusterID), zap.Error(err))
+		return nil, false
+	}
+
+	usages := make(map[string]podUsage, len(list.Items))
+	for _, item := range list.Items {
+		containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+		usage := podUsage{Containers: make(map[string]ResourceUsage, len(containers))}
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(container, "name")
+			u := parseUsage(container)
+			usage.Containers[name] = u
+			usage.Total.CPUMilli += u.CPUMilli
+			usage.Total.MemoryBytes += u.MemoryBytes
+		}
+		usages[item.GetNamespace()+"/"+item.GetName()] = usage
+	}
+	return usages, true
+}
+
+// listNodeMetrics 获取节点使用量，键为节点名
+func listNodeMetrics(clients *ClusterClients) (map[string]ResourceUsage, bool) {
+	list, err := clients.Dynamic.Resource(nodeMetricsResource).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		log.GetLogger().Debug("获取节点指标失败，可能未安装 metrics-server", zap.String("clusterID", clients.ClusterID), zap.Error(err))
+		return nil, false
+	}
+	usages := make(map[string]ResourceUsage, len(list.Items))
+	for _, item := range list.Items {
+		usages[item.GetName()] = parseUsage(item.Object)
+	}
+	return usages, true
+}
+
+// parseUsage 解析指标对象中的 usage 字段
+func parseUsage(obj map[string]interface{}) ResourceUsage {
+	var u ResourceUsage
+	if cpu, found, _ := unstructured.NestedString(obj, "usage", "cpu"); found {
+		if q, err := resource.ParseQuantity(cpu); err == nil {
+			u.CPUMilli = q.MilliValue()
+		}
+	}
+	if memory, found, _ := unstructured.NestedString(obj, "usage", "memory"); found {
+		if q, err := resource.ParseQuantity(memory); err == nil {
+			u.MemoryBytes = q.Value()
+		}
+	}
+	return u
+}
+
+// applyPodMetrics 将使用量填充到 Pod 信息中
+func applyPodMetrics(infos []PodInfo, usages map[string]podUsage) {
+	for i := range infos {
+		usage, ok := usages[infos[i].Namespace+"/"+infos[i].Name]
+		if !ok {
+			continue
+		}
+		infos[i].Cpu = formatCPU(usage.Total.CPUMilli)
+		infos[i].Memory = formatMemory(usage.Total.MemoryBytes)
+		infos[i].CpuMillicores = usage.Total.CPUMilli
+		infos[i].MemoryBytes = usage.Total.MemoryBytes
+		for j := range infos[i].Containers {
+			if cu, ok := usage.Containers[infos[i].Containers[j].Name]; ok {
+				infos[i].Containers[j].Cpu = formatCPU(cu.CPUMilli)
+				infos[i].Containers[j].Memory = formatMemory(cu.MemoryBytes)
+			}
+		}
+	}
+}
+
+// ListNodeUsage 列出节点的资源使用量及可分配量，未安装 metrics-server 时只返回可分配量
+func ListNodeUsage(clusterID string) (*NodeUsageList, error) {
+	logger := log.GetLogger()
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := clients.Clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Error("ListNodeUsage: failed to list nodes", zap.Error(err), zap.String("clusterID", clusterID))
+		return nil, err
+	}
+	usages, available := listNodeMetrics(clients)
+
+	result := &NodeUsageList{MetricsAvailable: available, Nodes: make([]NodeUsage, 0, len(nodes.Items))}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		cpuAlloc := node.Status.Allocatable.Cpu()
+		memAlloc := node.Status.Allocatable.Memory()
+		item := NodeUsage{
+			Name:              node.Name,
+			Ready:             isNodeReady(node),
+			CpuAllocatable:    cpuAlloc.String(),
+			MemoryAllocatable: memAlloc.String(),
+		}
+		if usage, ok := usages[node.Name]; ok {
+			item.Cpu = formatCPU(usage.CPUMilli)
+			item.Memory = formatMemory(usage.MemoryBytes)
+			item.CpuMillicores = usage.CPUMilli
+			item.MemoryBytes = usage.MemoryBytes
+			item.CpuPercent = percent(usage.CPUMilli, cpuAlloc.MilliValue())
+			item.MemoryPercent = percent(usage.MemoryBytes, memAlloc.Value())
+		}
+		result.Nodes = append(result.Nodes, item)
+	}
+	sort.Slice(result.Nodes, func(i, j int) bool { return result.Nodes[i].Name < result.Nodes[j].Name })
+	return result, nil
+}
+
+// TopPods 返回命名空间内使用量最高的 N 个 Pod，命名空间为空时统计所有命名空间。
+// 未安装 metrics-server 时返回空列表并将 MetricsAvailable 置为 false
+func TopPods(clusterID, namespace, sortBy string, n int) (*TopPodList, error) {
+	logger := log.GetLogger()
+	if sortBy == "" {
+		sortBy = SortByCPU
+	}
+	if sortBy != SortByCPU && sortBy != SortByMemory {
+		return nil, fmt.Errorf("invalid sortBy %q, expected %s or %s", sortBy, SortByCPU, SortByMemory)
+	}
+
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	result := &TopPodList{SortBy: sortBy, Pods: []PodInfo{}}
+	usages, available := listPodMetrics(clients, namespace)
+	if !available {
+		return result, nil
+	}
+	result.MetricsAvailable = true
+
+	pods, err := clients.Clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Error("TopPods: failed to list pods", zap.Error(err), zap.String("clusterID", clusterID), zap.String("namespace", namespace))
+		return nil, err
+	}
+	infos := make([]PodInfo, 0, len(pods.Items))
+	for i := range pods.Items {
+		if _, ok := usages[pods.Items[i].Namespace+"/"+pods.Items[i].Name]; ok {
+			infos = append(infos, toPodInfo(&pods.Items[i]))
+		}
+	}
+	applyPodMetrics(infos, usages)
+
+	sort.Slice(infos, func(i, j int) bool {
+		if sortBy == SortByMemory {
+			return infos[i].MemoryBytes > infos[j].MemoryBytes
+		}
+		return infos[i].CpuMillicores > infos[j].CpuMillicores
+	})
+	if n > 0 && len(infos) > n {
+		infos = infos[:n]
+	}
+	result.Pods = infos
+	return result, nil
+}
+
+// sumContainerResources 汇总容器的 requests 和 limits
+func sumContainerResources(containers []corev1.Container) (requests, limits corev1.ResourceList) {
+	requests, limits = corev1.ResourceList{}, corev1.ResourceList{}
+	for _, c := range containers {
+		addResourceList(requests, c.Resources.Requests)
+		addResourceList(limits, c.Resources.Limits)
+	}
+	return requests, limits
+}
+
+func addResourceList(total, add corev1.ResourceList) {
+	for name, q := range add {
+		if existing, ok := total[name]; ok {
+			existing.Add(q)
+			total[name] = existing
+		} else {
+			total[name] = q.DeepCopy()
+		}
+	}
+}
+
+// quantityString 返回资源量的字符串形式，未设置时为空
+func quantityString(list corev1.ResourceList, name corev1.ResourceName) string {
+	if q, ok := list[name]; ok {
+		return q.String()
+	}
+	return ""
+}
+
+func formatCPU(milli int64) string {
+	return fmt.Sprintf("%dm", milli)
+}
+
+func formatMemory(bytes int64) string {
+	return fmt.Sprintf("%dMi", bytes/(1024*1024))
+}
+
+func percent(used, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(used*10000/total) / 100
+}
@@ -0,0 +1,83 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+// FieldManager 服务端应用时使用的字段管理者名称
+const FieldManager = "opscore"
+
+// 不可变字段有变化时的处理方式
+const (
+	ImmutableStrategyFail     = "fail"     // 报错，保留现有对象
+	ImmutableStrategySkip     = "skip"     // 视为成功，保留现有对象
+	ImmutableStrategyRecreate = "recreate" // 删除现有对象后重新创建
+)
+
+var immutableStrategies = sets.New(ImmutableStrategyFail, ImmutableStrategySkip, ImmutableStrategyRecreate)
+
+// defaultImmutableStrategies 未指定时的处理方式。PVC、PV 重建会丢失数据，Job 重建会重新执行，默认都保留现有对象
+var defaultImmutableStrategies = map[string]string{
+	"PersistentVolumeClaim": ImmutableStrategySkip,
+	"PersistentVolume":      ImmutableStrategySkip,
+	"Job":                   ImmutableStrategySkip,
+}
+
+// recreateTimeout 重建时等待旧对象删除的最长时间
+const recreateTimeout = 2 * time.Minute
+
+// isImmutableFieldError 判断应用失败是否因为修改了不可变字段
+func isImmutableFieldError(err error) bool {
+	return errors.IsInvalid(err) && strings.Contains(err.Error(), "immutable")
+}
+
+// handleImmutableChange 按策略处理不可变字段的变化
+func handleImmutableChange(resource dynamic.ResourceInterface, gk schema.GroupKind, live, obj *unstructured.Unstructured, opts MigrateOptions, applyErr error) MigrateResult {
+	strategy := opts.ImmutableStrategies[gk.Kind]
+	if strategy == "" {
+		strategy = defaultImmutableStrategies[gk.Kind]
+	}
+	switch strategy {
+	case ImmutableStrategySkip:
+		return MigrateResult{Success: true, Message: "不可变字段有变化，按策略保留目标集群现有对象", Action: MigrateActionSkip}
+	case ImmutableStrategyRecreate:
+	default:
+		return MigrateResult{Success: false, Message: fmt.Sprintf("不可变字段有变化，可将 %s 的处理方式设为 recreate: %v", gk.Kind, applyErr)}
+	}
+
+	cleanUnstructured(gk, live)
+	diff := objectDiff(gk.Kind, live, obj)
+	if opts.DryRun {
+		return MigrateResult{Success: true, Message: "将删除并重新创建", Action: MigrateActionRecreate, Diff: diff}
+	}
+
+	propagation := metav1.DeletePropagationBackground
+	if err := resource.Delete(context.TODO(), obj.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !errors.IsNotFound(err) {
+		return MigrateResult{Success: false, Message: fmt.Sprintf("删除现有对象失败: %v", err)}
+	}
+	err := wait.PollUntilContextTimeout(context.TODO(), time.Second, recreateTimeout, true, func(ctx context.Context) (bool, error) {
+		_, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		return MigrateResult{Success: false, Message: fmt.Sprintf("等待现有对象删除失败: %v", err)}
+	}
+	if _, err := resource.Apply(context.TODO(), obj.GetName(), obj, metav1.ApplyOptions{FieldManager: FieldManager, Force: true}); err != nil {
+		return MigrateResult{Success: false, Message: fmt.Sprintf("重新创建失败: %v", err)}
+	}
+	return MigrateResult{Success: true, Message: "已删除并重新创建", Action: MigrateActionRecreate, Diff: diff}
+}
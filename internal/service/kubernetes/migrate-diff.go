@@ -0,0 +1,90 @@
+package kubernetes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// 迁移时对象在目标集群上的变更类型
+const (
+	MigrateActionCreate    = "create"
+	MigrateActionUpdate    = "update"
+	MigrateActionUnchanged = "unchanged"
+	MigrateActionRecreate  = "recreate"
+	MigrateActionSkip      = "skip"
+)
+
+// dryRunNamespace 检查目标命名空间，不存在时在结果中记录将要创建
+func dryRunNamespace(client *kubernetes.Clientset, namespace string, results map[string]map[string]MigrateResult) (bool, error) {
+	_, err := client.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+	if err == nil {
+		return true, nil
+	}
+	if !errors.IsNotFound(err) {
+		return false, fmt.Errorf("检查命名空间是否存在时出错: %v", err)
+	}
+	results["namespaces"] = map[string]MigrateResult{
+		namespace: {Success: true, Message: "命名空间将被创建", Action: MigrateActionCreate},
+	}
+	return false, nil
+}
+
+// objectDiff 生成两个对象 YAML 的统一格式差异，before 为空表示新建。Secret 的值以摘要代替
+func objectDiff(kind string, before, after *unstructured.Unstructured) string {
+	var a, b []byte
+	if before != nil {
+		a, _ = yaml.Marshal(maskSecretData(kind, before).Object)
+	}
+	if after != nil {
+		b, _ = yaml.Marshal(maskSecretData(kind, after).Object)
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: "live",
+		ToFile:   "migrated",
+		Context:  3,
+	})
+	return diff
+}
+
+// maskSecretData 将 Secret 的 data 替换为内容摘要，既能看出是否变化又不在差异中暴露明文
+func maskSecretData(kind string, obj *unstructured.Unstructured) *unstructured.Unstructured {
+	if kind != "Secret" {
+		return obj
+	}
+	masked := obj.DeepCopy()
+	data, _, _ := unstructured.NestedStringMap(masked.Object, "data")
+	for key, value := range data {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			decoded = []byte(value)
+		}
+		data[key] = secretDigest(decoded)
+	}
+	if len(data) > 0 {
+		_ = unstructured.SetNestedStringMap(masked.Object, data, "data")
+	}
+	if stringData, _, _ := unstructured.NestedStringMap(masked.Object, "stringData"); len(stringData) > 0 {
+		for key, value := range stringData {
+			stringData[key] = secretDigest([]byte(value))
+		}
+		_ = unstructured.SetNestedStringMap(masked.Object, stringData, "stringData")
+	}
+	return masked
+}
+
+// secretDigest 返回值的 SHA-256 前缀
+func secretDigest(value []byte) string {
+	sum := sha256.Sum256(value)
+	return fmt.Sprintf("<sha256:%x>", sum[:6])
+}
@@ -0,0 +1,268 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+// 迁移阶段，按依赖关系依次应用：被引用的对象先于引用它的对象
+const (
+	migrationTierNamespace = iota
+	migrationTierCRD
+	migrationTierRBAC
+	migrationTierConfig
+	migrationTierStorage
+	migrationTierService
+	migrationTierWorkload
+	migrationTierCustom // 其他类型，包括 CRD 定义的自定义资源
+	migrationTierIngress
+)
+
+// migrationTiers 已知类型所属的阶段，未列出的类型归入 migrationTierCustom
+var migrationTiers = map[schema.GroupKind]int{
+	{Kind: "Namespace"}: migrationTierNamespace,
+
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}: migrationTierCRD,
+
+	{Kind: "ServiceAccount"}:                                         migrationTierRBAC,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:        migrationTierRBAC,
+	{Group: "rbac.authorization.k8s.io", Kind: "Role"}:               migrationTierRBAC,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}: migrationTierRBAC,
+	{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}:        migrationTierRBAC,
+
+	{Kind: "ResourceQuota"}:                      migrationTierConfig,
+	{Kind: "LimitRange"}:                         migrationTierConfig,
+	{Kind: "ConfigMap"}:                          migrationTierConfig,
+	{Kind: "Secret"}:                             migrationTierConfig,
+	{Group: "bitnami.com", Kind: "SealedSecret"}: migrationTierConfig,
+
+	{Group: "storage.k8s.io", Kind: "StorageClass"}: migrationTierStorage,
+	{Kind: "PersistentVolume"}:                      migrationTierStorage,
+	{Kind: "PersistentVolumeClaim"}:                 migrationTierStorage,
+
+	{Kind: "Service"}: migrationTierService,
+
+	{Group: "apps", Kind: "Deployment"}:                     migrationTierWorkload,
+	{Group: "apps", Kind: "StatefulSet"}:                    migrationTierWorkload,
+	{Group: "apps", Kind: "DaemonSet"}:                      migrationTierWorkload,
+	{Group: "apps", Kind: "ReplicaSet"}:                     migrationTierWorkload,
+	{Kind: "ReplicationController"}:                         migrationTierWorkload,
+	{Kind: "Pod"}:                                           migrationTierWorkload,
+	{Group: "batch", Kind: "Job"}:                           migrationTierWorkload,
+	{Group: "batch", Kind: "CronJob"}:                       migrationTierWorkload,
+	{Group: "autoscaling", Kind: "HorizontalPodAutoscaler"}: migrationTierWorkload,
+	{Group: "policy", Kind: "PodDisruptionBudget"}:          migrationTierWorkload,
+
+	{Group: "networking.k8s.io", Kind: "IngressClass"}: migrationTierIngress,
+	{Group: "networking.k8s.io", Kind: "Ingress"}:      migrationTierIngress,
+}
+
+// sortByMigrationTier 按依赖阶段对收集到的资源稳定排序
+func sortByMigrationTier(collected []CollectedResources) {
+	sort.SliceStable(collected, func(i, j int) bool {
+		return migrationTier(collected[i].Mapping.GroupVersionKind.GroupKind()) < migrationTier(collected[j].Mapping.GroupVersionKind.GroupKind())
+	})
+}
+
+// migrationTier 返回类型所属的迁移阶段
+func migrationTier(gk schema.GroupKind) int {
+	if tier, ok := migrationTiers[gk]; ok {
+		return tier
+	}
+	return migrationTierCustom
+}
+
+// defaultReadyTimeout 等待单个工作负载就绪的默认超时时间
+const defaultReadyTimeout = 5 * time.Minute
+
+// ReadinessResult 工作负载在目标集群上的就绪状态
+type ReadinessResult struct {
+	Ready   bool   `json:"ready"`
+	Message string `json:"message"`
+	Elapsed string `json:"elapsed"`
+}
+
+// readinessCheck 根据对象状态判断是否就绪，返回错误表示已确定失败、无需继续等待
+type readinessCheck func(obj *unstructured.Unstructured) (bool, string, error)
+
+// readinessChecks 支持等待就绪的类型
+var readinessChecks = map[schema.GroupKind]readinessCheck{
+	{Group: "apps", Kind: "Deployment"}:  deploymentReady,
+	{Group: "apps", Kind: "StatefulSet"}: statefulSetReady,
+	{Group: "apps", Kind: "DaemonSet"}:   daemonSetReady,
+	{Group: "apps", Kind: "ReplicaSet"}:  replicaSetReady,
+	{Group: "batch", Kind: "Job"}:        jobReady,
+	{Kind: "Pod"}:                        podReady,
+}
+
+// readinessTarget 等待就绪的对象及其结果所在位置
+type readinessTarget struct {
+	results map[string]MigrateResult
+	name    string
+	gvk     schema.GroupVersionKind
+}
+
+// newReadinessTarget 对支持就绪检查的类型返回等待目标
+func newReadinessTarget(results map[string]MigrateResult, obj *unstructured.Unstructured) (readinessTarget, bool) {
+	gvk := obj.GroupVersionKind()
+	if _, ok := readinessChecks[gvk.GroupKind()]; !ok {
+		return readinessTarget{}, false
+	}
+	return readinessTarget{results: results, name: obj.GetName(), gvk: gvk}, true
+}
+
+// waitForReadiness 并发等待同一阶段的工作负载就绪，每个对象单独计算超时，结果写回对应的迁移结果
+func waitForReadiness(clients *ClusterClients, namespace string, targets []readinessTarget, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultReadyTimeout
+	}
+	readiness := make([]ReadinessResult, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target readinessTarget) {
+			defer wg.Done()
+			readiness[i] = waitForObjectReady(clients, namespace, target, timeout)
+		}(i, target)
+	}
+	wg.Wait()
+
+	for i, target := range targets {
+		result := target.results[target.name]
+		result.Readiness = &readiness[i]
+		if !readiness[i].Ready {
+			result.Success = false
+		}
+		target.results[target.name] = result
+	}
+}
+
+// waitForObjectReady 轮询对象状态直到就绪、确定失败或超时
+func waitForObjectReady(clients *ClusterClients, namespace string, target readinessTarget, timeout time.Duration) ReadinessResult {
+	start := time.Now()
+	mapping, err := clients.Mapper.RESTMapping(target.gvk.GroupKind(), target.gvk.Version)
+	if err != nil {
+		return ReadinessResult{Message: err.Error()}
+	}
+	check := readinessChecks[target.gvk.GroupKind()]
+	client := clients.Dynamic.Resource(mapping.Resource)
+	var resource dynamic.ResourceInterface = client
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		resource = client.Namespace(namespace)
+	}
+
+	var message string
+	err = wait.PollUntilContextTimeout(context.TODO(), 2*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		obj, err := resource.Get(ctx, target.name, metav1.GetOptions{})
+		if err != nil {
+			message = err.Error()
+			return false, nil
+		}
+		var ready bool
+		ready, message, err = check(obj)
+		return ready, err
+	})
+	elapsed := time.Since(start).Round(time.Second).String()
+	switch {
+	case err == nil:
+		return ReadinessResult{Ready: true, Message: "已就绪", Elapsed: elapsed}
+	case wait.Interrupted(err):
+		return ReadinessResult{Message: fmt.Sprintf("等待就绪超时: %s", message), Elapsed: elapsed}
+	default:
+		return ReadinessResult{Message: err.Error(), Elapsed: elapsed}
+	}
+}
+
+// observedLatest 控制器是否已处理最新的 spec
+func observedLatest(obj *unstructured.Unstructured) bool {
+	observed, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	return observed >= obj.GetGeneration()
+}
+
+// desiredReplicas 返回 spec.replicas，未设置时为 1
+func desiredReplicas(obj *unstructured.Unstructured) int64 {
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		return 1
+	}
+	return replicas
+}
+
+// statusInt 返回 status 下的整数字段
+func statusInt(obj *unstructured.Unstructured, field string) int64 {
+	value, _, _ := unstructured.NestedInt64(obj.Object, "status", field)
+	return value
+}
+
+// statusCondition 返回指定类型的 condition 的 status、reason 和 message
+func statusCondition(obj *unstructured.Unstructured, conditionType string) (string, string, string) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok || condition["type"] != conditionType {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		reason, _ := condition["reason"].(string)
+		message, _ := condition["message"].(string)
+		return status, reason, message
+	}
+	return "", "", ""
+}
+
+func deploymentReady(obj *unstructured.Unstructured) (bool, string, error) {
+	if _, reason, message := statusCondition(obj, "Progressing"); reason == "ProgressDeadlineExceeded" {
+		return false, message, fmt.Errorf("发布超时: %s", message)
+	}
+	replicas := desiredReplicas(obj)
+	updated, available := statusInt(obj, "updatedReplicas"), statusInt(obj, "availableReplicas")
+	message := fmt.Sprintf("%d/%d 可用，%d 已更新", available, replicas, updated)
+	return observedLatest(obj) && updated >= replicas && available >= replicas, message, nil
+}
+
+func statefulSetReady(obj *unstructured.Unstructured) (bool, string, error) {
+	replicas := desiredReplicas(obj)
+	ready, updated := statusInt(obj, "readyReplicas"), statusInt(obj, "updatedReplicas")
+	message := fmt.Sprintf("%d/%d 就绪，%d 已更新", ready, replicas, updated)
+	return observedLatest(obj) && ready >= replicas && updated >= replicas, message, nil
+}
+
+func daemonSetReady(obj *unstructured.Unstructured) (bool, string, error) {
+	desired := statusInt(obj, "desiredNumberScheduled")
+	ready, updated := statusInt(obj, "numberReady"), statusInt(obj, "updatedNumberScheduled")
+	message := fmt.Sprintf("%d/%d 就绪，%d 已更新", ready, desired, updated)
+	return observedLatest(obj) && ready >= desired && updated >= desired, message, nil
+}
+
+func replicaSetReady(obj *unstructured.Unstructured) (bool, string, error) {
+	replicas := desiredReplicas(obj)
+	ready := statusInt(obj, "readyReplicas")
+	return observedLatest(obj) && ready >= replicas, fmt.Sprintf("%d/%d 就绪", ready, replicas), nil
+}
+
+func jobReady(obj *unstructured.Unstructured) (bool, string, error) {
+	if status, _, message := statusCondition(obj, "Failed"); status == "True" {
+		return false, message, fmt.Errorf("Job 执行失败: %s", message)
+	}
+	status, _, _ := statusCondition(obj, "Complete")
+	return status == "True", fmt.Sprintf("%d 个 Pod 已成功", statusInt(obj, "succeeded")), nil
+}
+
+func podReady(obj *unstructured.Unstructured) (bool, string, error) {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	if phase == "Failed" {
+		return false, phase, fmt.Errorf("Pod 运行失败")
+	}
+	status, _, _ := statusCondition(obj, "Ready")
+	return status == "True" || phase == "Succeeded", phase, nil
+}
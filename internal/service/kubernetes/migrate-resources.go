@@ -3,281 +3,242 @@ package kubernetes
 import (
 	"context"
 	"fmt"
-	"strings"
+	"time"
 
+	coreError "opscore/error"
 	"opscore/internal/log"
 
 	"go.uber.org/zap"
-	appsv1 "k8s.io/api/apps/v1"
-	batchv1 "k8s.io/api/batch/v1"
-	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/kubernetes/scheme"
-	"sigs.k8s.io/yaml"
 )
 
 // MigrateResult 定义单个资源迁移的结果
 type MigrateResult struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
+	// Action 变更类型：create、update、unchanged、recreate、skip；Diff 为与目标集群现有对象的 YAML 差异
+	Action string `json:"action,omitempty"`
+	Diff   string `json:"diff,omitempty"`
+	// Transforms 对该对象产生作用的转换规则
+	Transforms []string `json:"transforms,omitempty"`
+	// Readiness 开启等待就绪时工作负载在目标集群上的就绪状态
+	Readiness *ReadinessResult `json:"readiness,omitempty"`
 }
 
-// MigrateResources 将资源从源集群迁移到目标集群
-func MigrateResources(sourceClusterID, destClusterID, sourceNamespace, destNamespace string, resourceTypes []string) (map[string]map[string]MigrateResult, error) {
+// MigrateOptions 资源迁移的可选项
+type MigrateOptions struct {
+	// Filter 按标签、名称和对象列表筛选，可选跟踪工作负载引用的对象
+	Filter *ResourceFilter
+	// SecretPolicy Secret 处理策略，为空时原样迁移但跳过 ServiceAccount 令牌、Helm release 等系统类型
+	SecretPolicy *SecretPolicy
+	// DryRun 为 true 时只在目标集群做服务端预演，不产生任何变更
+	DryRun bool
+	// ForceConflicts 与其他字段管理者冲突时强制接管字段
+	ForceConflicts bool
+	// ImmutableStrategies 按 Kind 指定不可变字段有变化时的处理方式：fail、skip、recreate，
+	// 未指定时 PVC、PV、Job 保留现有对象，其余类型报错
+	ImmutableStrategies map[string]string
+	// TransformRules 应用前按顺序对对象执行的转换规则，预演时同样生效
+	TransformRules []TransformRule
+	// WaitForReady 每个阶段应用完成后等待其中的工作负载就绪再进入下一阶段，预演时忽略
+	WaitForReady bool
+	// ReadyTimeout 等待单个工作负载就绪的超时时间，默认 5 分钟
+	ReadyTimeout time.Duration
+}
+
+// MigrateResources 将资源从源集群迁移到目标集群。资源通过发现接口和动态客户端收集，
+// 支持任意内置类型和 CRD，按命名空间、CRD、RBAC、配置、存储、Service、工作负载、Ingress 的依赖顺序
+// 以服务端应用（server-side apply）的方式写入目标集群
+func MigrateResources(sourceClusterID, destClusterID, sourceNamespace, destNamespace string, resourceTypes []string, opts MigrateOptions) (map[string]map[string]MigrateResult, error) {
 	logger := log.GetLogger()
 	logger.Info("开始资源迁移",
 		zap.String("sourceClusterID", sourceClusterID),
 		zap.String("destClusterID", destClusterID),
 		zap.String("sourceNamespace", sourceNamespace),
 		zap.String("destNamespace", destNamespace),
-		zap.Strings("resourceTypes", resourceTypes))
+		zap.Strings("resourceTypes", resourceTypes),
+		zap.Bool("dryRun", opts.DryRun))
 
-	// 获取源集群和目标集群的信息
-	sourceCluster, err := GetClusterByClusterID(sourceClusterID)
+	policy, err := opts.SecretPolicy.compile(true)
 	if err != nil {
-		logger.Error("获取源集群信息失败", zap.Error(err))
-		return nil, fmt.Errorf("获取源集群信息失败: %v", err)
+		return nil, err
 	}
-
-	destCluster, err := GetClusterByClusterID(destClusterID)
+	rules, err := compileTransformRules(opts.TransformRules)
 	if err != nil {
-		logger.Error("获取目标集群信息失败", zap.Error(err))
-		return nil, fmt.Errorf("获取目标集群信息失败: %v", err)
+		return nil, err
+	}
+	if _, err := opts.Filter.compile(); err != nil {
+		return nil, err
+	}
+	for kind, strategy := range opts.ImmutableStrategies {
+		if !immutableStrategies.Has(strategy) {
+			return nil, fmt.Errorf("%w: unsupported immutable strategy %q for %s", coreError.ErrInvalidResource, strategy, kind)
+		}
 	}
 
-	// 创建源集群和目标集群的客户端
-	sourceClient, err := NewK8sClient(sourceCluster.KubeConfig)
+	// 获取源集群和目标集群的缓存客户端
+	sourceClients, err := GetClusterClients(sourceClusterID)
 	if err != nil {
 		logger.Error("创建源集群客户端失败", zap.Error(err))
 		return nil, fmt.Errorf("创建源集群客户端失败: %v", err)
 	}
 
-	destClient, err := NewK8sClient(destCluster.KubeConfig)
+	destClients, err := GetClusterClients(destClusterID)
 	if err != nil {
 		logger.Error("创建目标集群客户端失败", zap.Error(err))
 		return nil, fmt.Errorf("创建目标集群客户端失败: %v", err)
 	}
 
-	// 如果未指定资源类型，则使用默认资源类型
-	if len(resourceTypes) == 0 {
-		resourceTypes = []string{
-			"deployments", "statefulsets", "services", "configmaps",
-			"secrets", "pvcs", "pvs", "cronjobs", "jobs",
-		}
+	// 处理默认资源类型、别名以及 *
+	resourceTypes, err = expandResourceTypes(sourceClients, sourceNamespace, resourceTypes)
+	if err != nil {
+		logger.Error("解析资源类型失败", zap.Error(err))
+		return nil, err
 	}
 
 	// 存储迁移结果
 	results := make(map[string]map[string]MigrateResult)
 
-	// 确保目标命名空间存在
-	if err := ensureNamespaceExists(destClient, destNamespace); err != nil {
+	// 确保目标命名空间存在，预演时只检查
+	namespaceExists := true
+	if opts.DryRun {
+		namespaceExists, err = dryRunNamespace(destClients.Clientset, destNamespace, results)
+		if err != nil {
+			logger.Error("检查目标命名空间失败", zap.Error(err))
+			return nil, err
+		}
+	} else if err := ensureNamespaceExists(destClients.Clientset, destNamespace); err != nil {
 		logger.Error("确保目标命名空间存在失败", zap.Error(err))
 		return nil, fmt.Errorf("确保目标命名空间存在失败: %v", err)
 	}
 
-	// 遍历资源类型并迁移
+	// 逐个类型收集资源，单个类型获取失败不影响其他类型
+	var collected []CollectedResources
 	for _, resourceType := range resourceTypes {
-		resourceResults := make(map[string]MigrateResult)
-		results[resourceType] = resourceResults
-
-		var resources []runtime.Object
-		var resourceNames []string
-
-		switch strings.ToLower(resourceType) {
-		case "deployments":
-			deployments, err := sourceClient.AppsV1().Deployments(sourceNamespace).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				logger.Error("获取Deployments失败", zap.Error(err))
-				resourceResults["_error"] = MigrateResult{Success: false, Message: fmt.Sprintf("获取Deployments失败: %v", err)}
-				continue
-			}
-			for i := range deployments.Items {
-				item := &deployments.Items[i]
-				item.APIVersion = "apps/v1"
-				item.Kind = "Deployment"
-				resources = append(resources, item)
-				resourceNames = append(resourceNames, item.Name)
-			}
+		groups, err := collectResources(sourceClients, sourceNamespace, []string{resourceType})
+		if err != nil {
+			logger.Error("获取资源失败", zap.String("resourceType", resourceType), zap.Error(err))
+			results[resourceType] = map[string]MigrateResult{"_error": {Success: false, Message: err.Error()}}
+			continue
+		}
+		collected = append(collected, groups...)
+	}
 
-		case "statefulsets":
-			statefulsets, err := sourceClient.AppsV1().StatefulSets(sourceNamespace).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				logger.Error("获取StatefulSets失败", zap.Error(err))
-				resourceResults["_error"] = MigrateResult{Success: false, Message: fmt.Sprintf("获取StatefulSets失败: %v", err)}
-				continue
-			}
-			for i := range statefulsets.Items {
-				item := &statefulsets.Items[i]
-				item.APIVersion = "apps/v1"
-				item.Kind = "StatefulSet"
-				resources = append(resources, item)
-				resourceNames = append(resourceNames, item.Name)
-			}
+	// 按筛选条件过滤，并补充被引用的对象
+	collected, err = filterResources(sourceClients, sourceNamespace, collected, opts.Filter)
+	if err != nil {
+		logger.Error("筛选资源失败", zap.Error(err))
+		return nil, err
+	}
 
-		case "services":
-			serviceList, err := sourceClient.CoreV1().Services(sourceNamespace).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				logger.Error("获取Services失败", zap.Error(err))
-				resourceResults["_error"] = MigrateResult{Success: false, Message: fmt.Sprintf("获取Services失败: %v", err)}
-				continue
-			}
-			for i := range serviceList.Items {
-				item := &serviceList.Items[i]
-				item.APIVersion = "v1"
-				item.Kind = "Service"
-				item.ManagedFields = nil
-				item.Spec.ClusterIP = ""
-				item.Spec.ClusterIPs = nil
-				item.Spec.ExternalIPs = nil
-				item.Spec.ExternalName = ""
-				item.Spec.ExternalTrafficPolicy = ""
-				item.Spec.HealthCheckNodePort = 0
-				item.Spec.LoadBalancerIP = ""
-				item.Spec.LoadBalancerSourceRanges = nil
-				resources = append(resources, item)
-				resourceNames = append(resourceNames, item.Name)
-			}
+	// 按依赖阶段迁移，进入下一阶段前等待上一阶段的工作负载就绪
+	sortByMigrationTier(collected)
+	var pending []readinessTarget
+	currentTier := -1
+	for _, group := range collected {
+		resourceType := group.ResourceType
+		gk := group.Mapping.GroupVersionKind.GroupKind()
+		if tier := migrationTier(gk); tier != currentTier {
+			if len(pending) > 0 {
+				waitForReadiness(destClients, destNamespace, pending, opts.ReadyTimeout)
+				pending = nil
+			}
+			currentTier = tier
+		}
 
-		case "configmaps":
-			configMapList, err := sourceClient.CoreV1().ConfigMaps(sourceNamespace).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				logger.Error("获取ConfigMaps失败", zap.Error(err))
-				resourceResults["_error"] = MigrateResult{Success: false, Message: fmt.Sprintf("获取ConfigMaps失败: %v", err)}
-				continue
-			}
-			for i := range configMapList.Items {
-				item := &configMapList.Items[i]
-				item.APIVersion = "v1"
-				item.Kind = "ConfigMap"
-				resources = append(resources, item)
-				resourceNames = append(resourceNames, item.Name)
-			}
-		case "secrets":
-			secretList, err := sourceClient.CoreV1().Secrets(sourceNamespace).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				logger.Error("获取Secrets失败", zap.Error(err))
-				resourceResults["_error"] = MigrateResult{Success: false, Message: fmt.Sprintf("获取Secrets失败: %v", err)}
-				continue
-			}
-			for i := range secretList.Items {
-				item := &secretList.Items[i]
-				item.APIVersion = "v1"
-				item.Kind = "Secret"
-				resources = append(resources, item)
-				resourceNames = append(resourceNames, item.Name)
-			}
+		resourceResults := make(map[string]MigrateResult)
+		results[resourceType] = resourceResults
 
-		case "pvcs", "persistentvolumeclaims":
-			pvcList, err := sourceClient.CoreV1().PersistentVolumeClaims(sourceNamespace).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				logger.Error("获取PersistentVolumeClaims失败", zap.Error(err))
-				resourceResults["_error"] = MigrateResult{Success: false, Message: fmt.Sprintf("获取PersistentVolumeClaims失败: %v", err)}
-				continue
-			}
-			for i := range pvcList.Items {
-				item := &pvcList.Items[i]
-				item.APIVersion = "v1"
-				item.Kind = "PersistentVolumeClaim"
-				resources = append(resources, item)
-				resourceNames = append(resourceNames, item.Name)
+		for _, obj := range group.Objects {
+			resourceName := obj.GetName()
+			for _, path := range migrationStrippedFields[gk] {
+				unstructured.RemoveNestedField(obj.Object, path...)
 			}
 
-		case "pvs", "persistentvolumes":
-			// PV是集群级资源，不属于特定命名空间
-			pvList, err := sourceClient.CoreV1().PersistentVolumes().List(context.TODO(), metav1.ListOptions{})
+			// 执行转换规则
+			transforms, err := applyTransformRules(rules, gk, obj)
 			if err != nil {
-				logger.Error("获取PersistentVolumes失败", zap.Error(err))
-				resourceResults["_error"] = MigrateResult{Success: false, Message: fmt.Sprintf("获取PersistentVolumes失败: %v", err)}
+				logger.Error("执行转换规则失败", zap.String("resourceType", resourceType), zap.String("resourceName", resourceName), zap.Error(err))
+				resourceResults[resourceName] = MigrateResult{Success: false, Message: err.Error(), Transforms: transforms}
 				continue
 			}
-			for i := range pvList.Items {
-				item := &pvList.Items[i]
-				item.APIVersion = "v1"
-				item.Kind = "PersistentVolume"
-				resources = append(resources, item)
-				resourceNames = append(resourceNames, item.Name)
-			}
 
-		case "cronjobs":
-			cronjobList, err := sourceClient.BatchV1().CronJobs(sourceNamespace).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				// 尝试BatchV1beta1 API (针对较旧的Kubernetes版本)
-				cronjobBetaList, betaErr := sourceClient.BatchV1beta1().CronJobs(sourceNamespace).List(context.TODO(), metav1.ListOptions{})
-				if betaErr != nil {
-					logger.Error("获取CronJobs失败", zap.Error(err), zap.Error(betaErr))
-					resourceResults["_error"] = MigrateResult{Success: false, Message: fmt.Sprintf("获取CronJobs失败: %v, %v", err, betaErr)}
+			// Secret 按策略处理，被跳过时直接记录结果
+			if gk.Group == "" && gk.Kind == "Secret" {
+				prepared, result := prepareSecretForMigration(obj, destNamespace, policy)
+				if result != nil {
+					resourceResults[resourceName] = *result
 					continue
 				}
-				for i := range cronjobBetaList.Items {
-					item := &cronjobBetaList.Items[i]
-					item.APIVersion = "batch/v1beta1"
-					item.Kind = "CronJob"
-					resources = append(resources, item)
-					resourceNames = append(resourceNames, item.Name)
-				}
-			} else {
-				for i := range cronjobList.Items {
-					item := &cronjobList.Items[i]
-					item.APIVersion = "batch/v1"
-					item.Kind = "CronJob"
-					resources = append(resources, item)
-					resourceNames = append(resourceNames, item.Name)
-				}
-			}
-
-		case "jobs":
-			jobList, err := sourceClient.BatchV1().Jobs(sourceNamespace).List(context.TODO(), metav1.ListOptions{})
-			if err != nil {
-				logger.Error("获取Jobs失败", zap.Error(err))
-				resourceResults["_error"] = MigrateResult{Success: false, Message: fmt.Sprintf("获取Jobs失败: %v", err)}
-				continue
+				obj = prepared
 			}
-			for i := range jobList.Items {
-				item := &jobList.Items[i]
-				item.APIVersion = "batch/v1"
-				item.Kind = "Job"
-				resources = append(resources, item)
-				resourceNames = append(resourceNames, item.Name)
-			}
-		}
-
-		// 清理资源并应用到目标集群
-		for i, obj := range resources {
-			resourceName := resourceNames[i]
-
-			// 清理不必要的字段
-			cleanObject(obj)
 
 			// 将资源应用到目标集群
-			err := applyResourceToCluster(destClient, obj, destNamespace)
-			if err != nil {
+			result := applyResourceToCluster(destClients, obj, destNamespace, namespaceExists, opts)
+			result.Transforms = transforms
+			if !result.Success {
 				logger.Error("应用资源到目标集群失败",
 					zap.String("resourceType", resourceType),
 					zap.String("resourceName", resourceName),
-					zap.Error(err))
-				resourceResults[resourceName] = MigrateResult{Success: false, Message: err.Error()}
+					zap.String("error", result.Message))
 			} else {
 				logger.Info("成功应用资源到目标集群",
 					zap.String("resourceType", resourceType),
-					zap.String("resourceName", resourceName))
-				resourceResults[resourceName] = MigrateResult{Success: true, Message: "迁移成功"}
+					zap.String("resourceName", resourceName),
+					zap.String("action", result.Action))
+			}
+			resourceResults[resourceName] = result
+
+			if opts.WaitForReady && !opts.DryRun && result.Success && result.Action != MigrateActionSkip {
+				if target, ok := newReadinessTarget(resourceResults, obj); ok {
+					pending = append(pending, target)
+				}
 			}
 		}
 
 		// 如果没有资源，记录信息
-		if len(resources) == 0 && resourceResults["_error"] == (MigrateResult{}) {
+		if len(group.Objects) == 0 {
 			logger.Info("没有找到需要迁移的资源", zap.String("resourceType", resourceType))
 			resourceResults["_info"] = MigrateResult{Success: true, Message: "没有找到需要迁移的资源"}
 		}
 	}
 
+	if len(pending) > 0 {
+		waitForReadiness(destClients, destNamespace, pending, opts.ReadyTimeout)
+	}
+
 	return results, nil
 }
 
+// migrationStrippedFields 迁移时额外去掉的字段：与源集群网络环境绑定的地址
+var migrationStrippedFields = map[schema.GroupKind][][]string{
+	{Kind: "Service"}: {
+		{"spec", "externalIPs"},
+		{"spec", "loadBalancerIP"},
+	},
+}
+
+// prepareSecretForMigration 对 Secret 应用策略，返回待应用的对象：Secret 或加密后的 SealedSecret。
+// 返回结果不为空时表示该 Secret 被跳过或处理失败
+func prepareSecretForMigration(obj *unstructured.Unstructured, namespace string, policy *compiledSecretPolicy) (*unstructured.Unstructured, *MigrateResult) {
+	obj.SetNamespace(namespace)
+	processed, err := policy.apply(obj)
+	if err != nil {
+		return nil, &MigrateResult{Success: false, Message: err.Error()}
+	}
+	if processed == nil {
+		return nil, &MigrateResult{Success: true, Message: "按Secret策略跳过", Action: MigrateActionSkip}
+	}
+	return processed, nil
+}
+
 // ensureNamespaceExists 确保目标命名空间存在，如果不存在则创建
 func ensureNamespaceExists(client *kubernetes.Clientset, namespaceName string) error {
 	// 检查命名空间是否存在
@@ -307,237 +268,79 @@ func ensureNamespaceExists(client *kubernetes.Clientset, namespaceName string) e
 	return nil
 }
 
-// applyResourceToCluster 将资源应用到目标集群
-func applyResourceToCluster(client *kubernetes.Clientset, obj runtime.Object, namespace string) error {
-	log.GetLogger().Info("应用资源到目标集群", zap.String("namespace", namespace))
-	// 将对象转换为YAML
-	yamlBytes, err := yaml.Marshal(obj)
-	if err != nil {
-		return fmt.Errorf("转换资源为YAML失败: %v", err)
+// applyResourceToCluster 以服务端应用的方式将资源写入目标集群，字段归属 opscore 字段管理者。
+// 与其他管理者的字段冲突默认报错，ForceConflicts 时强制接管；不可变字段有变化时按 ImmutableStrategies 处理。
+// 预演模式下同样经过服务端校验但不落盘，两种模式都返回变更类型和与现有对象的差异
+func applyResourceToCluster(clients *ClusterClients, obj *unstructured.Unstructured, namespace string, namespaceExists bool, opts MigrateOptions) MigrateResult {
+	gvk := obj.GroupVersionKind()
+	mapping, err := clients.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		// 目标集群不提供源集群的版本时使用目标集群的首选版本
+		mapping, err = clients.Mapper.RESTMapping(gvk.GroupKind())
 	}
-
-	// 解码YAML为动态对象
-	decoder := scheme.Codecs.UniversalDeserializer()
-	object, _, err := decoder.Decode(yamlBytes, nil, nil)
 	if err != nil {
-		return fmt.Errorf("解码YAML失败: %v", err)
+		return MigrateResult{Success: false, Message: fmt.Sprintf("目标集群不支持%s: %v", gvk.Kind, err)}
+	}
+	gk := mapping.GroupVersionKind.GroupKind()
+	obj.SetAPIVersion(mapping.GroupVersionKind.GroupVersion().String())
+
+	namespaced := mapping.Scope.Name() == meta.RESTScopeNameNamespace
+	client := clients.Dynamic.Resource(mapping.Resource)
+	var resource dynamic.ResourceInterface = client
+	if namespaced {
+		obj.SetNamespace(namespace)
+		resource = client.Namespace(namespace)
+	} else {
+		obj.SetNamespace("")
 	}
 
-	// 根据对象类型执行相应的创建或更新操作
-	switch o := object.(type) {
-	case *appsv1.Deployment:
-		// 修改对应类型的命名空间
-		o.Namespace = namespace
-		_, err := client.AppsV1().Deployments(namespace).Get(context.TODO(), o.Name, metav1.GetOptions{})
-		if err != nil {
-			if errors.IsNotFound(err) {
-				// 如果不存在，则创建
-				_, err = client.AppsV1().Deployments(namespace).Create(context.TODO(), o, metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("创建Deployment失败: %v", err)
-				}
-			} else {
-				return fmt.Errorf("获取Deployment失败: %v", err)
-			}
-		} else {
-			// 如果存在，则更新
-			_, err = client.AppsV1().Deployments(namespace).Update(context.TODO(), o, metav1.UpdateOptions{})
-			if err != nil {
-				return fmt.Errorf("更新Deployment失败: %v", err)
-			}
-		}
-
-	case *appsv1.StatefulSet:
-		o.Namespace = namespace
-		// 尝试获取现有的StatefulSet
-		_, err := client.AppsV1().StatefulSets(namespace).Get(context.TODO(), o.Name, metav1.GetOptions{})
-		if err != nil {
-			if errors.IsNotFound(err) {
-				// 如果不存在，则创建
-				_, err = client.AppsV1().StatefulSets(namespace).Create(context.TODO(), o, metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("创建StatefulSet失败: %v", err)
-				}
-			} else {
-				return fmt.Errorf("获取StatefulSet失败: %v", err)
-			}
-		} else {
-			// 如果存在，则更新
-			_, err = client.AppsV1().StatefulSets(namespace).Update(context.TODO(), o, metav1.UpdateOptions{})
-			if err != nil {
-				return fmt.Errorf("更新StatefulSet失败: %v", err)
-			}
-		}
-
-	case *corev1.Service:
-		o.Namespace = namespace
-		// 尝试获取现有的Service
-		_, err := client.CoreV1().Services(namespace).Get(context.TODO(), o.Name, metav1.GetOptions{})
-		if err != nil {
-			if errors.IsNotFound(err) {
-				// 如果不存在，则创建
-				_, err = client.CoreV1().Services(namespace).Create(context.TODO(), o, metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("创建Service失败: %v", err)
-				}
-			} else {
-				return fmt.Errorf("获取Service失败: %v", err)
-			}
-		} else {
-			// 如果存在，则更新
-			_, err = client.CoreV1().Services(namespace).Update(context.TODO(), o, metav1.UpdateOptions{})
-			if err != nil {
-				return fmt.Errorf("更新Service失败: %v", err)
-			}
-		}
-	case *corev1.ConfigMap:
-		o.Namespace = namespace
-		//获取当前现有的configmap
-		_,err := client.CoreV1().ConfigMaps(namespace).Get(context.TODO(), o.Name, metav1.GetOptions{})
-		if err!= nil {
-			if errors.IsNotFound(err) {
-				// 如果不存在，则创建
-				_, err = client.CoreV1().ConfigMaps(namespace).Create(context.TODO(), o, metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("创建ConfigMap失败: %v", err)
-				}
-			} else {
-				return fmt.Errorf("获取ConfigMap失败: %v", err)
-			}
-		} else {
-			// 如果存在，则更新
-			_, err = client.CoreV1().ConfigMaps(namespace).Update(context.TODO(), o, metav1.UpdateOptions{})
-			if err != nil {
-				return fmt.Errorf("更新ConfigMap失败: %v", err)
-			}
-		}
-
-
-	case *corev1.Secret:
-		o.Namespace = namespace
-		// 尝试获取现有的Secret
-		_, err := client.CoreV1().Secrets(namespace).Get(context.TODO(), o.Name, metav1.GetOptions{})
-		if err != nil {
-			if errors.IsNotFound(err) {
-				// 如果不存在，则创建
-				_, err = client.CoreV1().Secrets(namespace).Create(context.TODO(), o, metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("创建Secret失败: %v", err)
-				}
-			} else {
-				return fmt.Errorf("获取Secret失败: %v", err)
-			}
-		} else {
-			// 如果存在，则更新
-			_, err = client.CoreV1().Secrets(namespace).Update(context.TODO(), o, metav1.UpdateOptions{})
-			if err != nil {
-				return fmt.Errorf("更新Secret失败: %v", err)
-			}
-		}
-
-	case *corev1.PersistentVolumeClaim:
-		o.Namespace = namespace
-		// 尝试获取现有的PVC
-		_, err := client.CoreV1().PersistentVolumeClaims(namespace).Get(context.TODO(), o.Name, metav1.GetOptions{})
-		if err != nil {
-			if errors.IsNotFound(err) {
-				// 如果不存在，则创建
-				_, err = client.CoreV1().PersistentVolumeClaims(namespace).Create(context.TODO(), o, metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("创建PersistentVolumeClaim失败: %v", err)
-				}
-			} else {
-				return fmt.Errorf("获取PersistentVolumeClaim失败: %v", err)
-			}
-		} else {
-			// PVC不支持更新，需要先删除再创建
-			return fmt.Errorf("PersistentVolumeClaim已存在，无法更新，请先删除再创建")
-		}
-
-	case *corev1.PersistentVolume:
-		// 尝试获取现有的PV
-		_, err := client.CoreV1().PersistentVolumes().Get(context.TODO(), o.Name, metav1.GetOptions{})
-		if err != nil {
-			if errors.IsNotFound(err) {
-				// 如果不存在，则创建
-				_, err = client.CoreV1().PersistentVolumes().Create(context.TODO(), o, metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("创建PersistentVolume失败: %v", err)
-				}
-			} else {
-				return fmt.Errorf("获取PersistentVolume失败: %v", err)
-			}
-		} else {
-			// PV不支持更新，需要先删除再创建
-			return fmt.Errorf("PersistentVolume已存在，无法更新，请先删除再创建")
-		}
-
-	case *batchv1.CronJob:
-		o.Namespace = namespace
-		// 尝试获取现有的CronJob
-		_, err := client.BatchV1().CronJobs(namespace).Get(context.TODO(), o.Name, metav1.GetOptions{})
-		if err != nil {
-			if errors.IsNotFound(err) {
-				// 如果不存在，则创建
-				_, err = client.BatchV1().CronJobs(namespace).Create(context.TODO(), o, metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("创建CronJob失败: %v", err)
-				}
-			} else {
-				return fmt.Errorf("获取CronJob失败: %v", err)
-			}
-		} else {
-			// 如果存在，则更新
-			_, err = client.BatchV1().CronJobs(namespace).Update(context.TODO(), o, metav1.UpdateOptions{})
-			if err != nil {
-				return fmt.Errorf("更新CronJob失败: %v", err)
-			}
-		}
+	live, err := resource.Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		live = nil
+	} else if err != nil {
+		return MigrateResult{Success: false, Message: fmt.Sprintf("获取目标集群现有对象失败: %v", err)}
+	}
+	if live == nil && namespaced && !namespaceExists {
+		// 预演时目标命名空间尚未创建，无法做服务端校验
+		return MigrateResult{Success: true, Message: "目标命名空间不存在，未经服务端校验", Action: MigrateActionCreate,
+			Diff: objectDiff(gk.Kind, nil, obj)}
+	}
 
-	case *batchv1beta1.CronJob:
-		o.Namespace = namespace
-		// 尝试获取现有的CronJob (v1beta1)
-		_, err := client.BatchV1beta1().CronJobs(namespace).Get(context.TODO(), o.Name, metav1.GetOptions{})
-		if err != nil {
-			if errors.IsNotFound(err) {
-				// 如果不存在，则创建
-				_, err = client.BatchV1beta1().CronJobs(namespace).Create(context.TODO(), o, metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("创建CronJob (v1beta1)失败: %v", err)
-				}
-			} else {
-				return fmt.Errorf("获取CronJob (v1beta1)失败: %v", err)
-			}
-		} else {
-			// 如果存在，则更新
-			_, err = client.BatchV1beta1().CronJobs(namespace).Update(context.TODO(), o, metav1.UpdateOptions{})
-			if err != nil {
-				return fmt.Errorf("更新CronJob (v1beta1)失败: %v", err)
-			}
+	applyOptions := metav1.ApplyOptions{FieldManager: FieldManager, Force: opts.ForceConflicts}
+	if opts.DryRun {
+		applyOptions.DryRun = []string{metav1.DryRunAll}
+	}
+	applied, err := resource.Apply(context.TODO(), obj.GetName(), obj, applyOptions)
+	if err != nil {
+		switch {
+		case errors.IsConflict(err):
+			return MigrateResult{Success: false, Message: fmt.Sprintf("与目标集群中其他字段管理者冲突，可开启 forceConflicts 强制接管: %v", err)}
+		case live != nil && isImmutableFieldError(err):
+			return handleImmutableChange(resource, gk, live, obj, opts, err)
 		}
+		return MigrateResult{Success: false, Message: fmt.Sprintf("应用资源失败: %v", err)}
+	}
+	return appliedResult(gk, live, applied, opts.DryRun)
+}
 
-	case *batchv1.Job:
-		o.Namespace = namespace
-		// 尝试获取现有的Job
-		_, err := client.BatchV1().Jobs(namespace).Get(context.TODO(), o.Name, metav1.GetOptions{})
-		if err != nil {
-			if errors.IsNotFound(err) {
-				// 如果不存在，则创建
-				_, err = client.BatchV1().Jobs(namespace).Create(context.TODO(), o, metav1.CreateOptions{})
-				if err != nil {
-					return fmt.Errorf("创建Job失败: %v", err)
-				}
-			} else {
-				return fmt.Errorf("获取Job失败: %v", err)
-			}
-		} else {
-			// Job不支持更新，需要先删除再创建
-			return fmt.Errorf("Job已存在，无法更新，请先删除再创建")
+// appliedResult 根据应用前后的对象判断变更类型
+func appliedResult(gk schema.GroupKind, live, applied *unstructured.Unstructured, dryRun bool) MigrateResult {
+	cleanUnstructured(gk, applied)
+	if live == nil {
+		message := "创建成功"
+		if dryRun {
+			message = "将创建"
 		}
-
-	default:
-		return fmt.Errorf("不支持的资源类型: %T", o)
+		return MigrateResult{Success: true, Message: message, Action: MigrateActionCreate, Diff: objectDiff(gk.Kind, nil, applied)}
 	}
-
-	return nil
+	cleanUnstructured(gk, live)
+	diff := objectDiff(gk.Kind, live, applied)
+	if diff == "" {
+		return MigrateResult{Success: true, Message: "与目标集群一致", Action: MigrateActionUnchanged}
+	}
+	message := "更新成功"
+	if dryRun {
+		message = "将更新"
+	}
+	return MigrateResult{Success: true, Message: message, Action: MigrateActionUpdate, Diff: diff}
 }
@@ -0,0 +1,319 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	coreError "opscore/error"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// 转换规则类型
+const (
+	TransformJSONPatch = "jsonPatch" // RFC 6902 JSON Patch
+	TransformSet       = "set"       // 将路径处的值设为 value，不存在的中间对象会被创建
+	TransformRemove    = "remove"    // 删除路径处的字段，不存在时忽略
+	TransformReplace   = "replace"   // 替换路径处字符串中的 from 为 to，regex 为 true 时按正则替换
+	TransformRegistry  = "registry"  // 将所有工作负载容器镜像的前缀 from 替换为 to
+)
+
+// TransformRule 迁移时对对象的修改规则，在清理之后、应用之前按顺序执行。
+// path 使用 JSON Pointer 格式（例如 /spec/template/spec/nodeSelector，键中的 / 写作 ~1），
+// set、remove、replace 的路径中 * 匹配数组的所有元素或对象的所有键
+type TransformRule struct {
+	Kinds []string        `json:"kinds"` // 匹配的 Kind，为空时匹配所有类型
+	Names []string        `json:"names"` // 匹配的对象名，支持 * 和 ? 通配符，为空时匹配所有对象
+	Type  string          `json:"type"`
+	Patch json.RawMessage `json:"patch"` // jsonPatch 的操作数组
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"` // set 的值，任意 JSON
+	From  string          `json:"from"`
+	To    string          `json:"to"`
+	Regex bool            `json:"regex"`
+}
+
+// compiledTransformRule 已校验并解析的规则
+type compiledTransformRule struct {
+	TransformRule
+	description string
+	segments    []string
+	value       interface{}
+	pattern     *regexp.Regexp
+	patch       jsonpatch.Patch
+}
+
+// compileTransformRules 校验规则并预先解析路径、值、正则和 JSON Patch
+func compileTransformRules(rules []TransformRule) ([]compiledTransformRule, error) {
+	compiled := make([]compiledTransformRule, 0, len(rules))
+	for i, rule := range rules {
+		c := compiledTransformRule{TransformRule: rule}
+		invalid := func(format string, args ...interface{}) error {
+			return fmt.Errorf("%w: transform rule %d: %s", coreError.ErrInvalidResource, i, fmt.Sprintf(format, args...))
+		}
+		for _, name := range rule.Names {
+			if _, err := path.Match(name, ""); err != nil {
+				return nil, invalid("invalid name pattern %q", name)
+			}
+		}
+
+		var err error
+		switch rule.Type {
+		case TransformJSONPatch:
+			if c.patch, err = jsonpatch.DecodePatch(rule.Patch); err != nil {
+				return nil, invalid("invalid json patch: %v", err)
+			}
+			c.description = fmt.Sprintf("rule[%d] jsonPatch", i)
+		case TransformSet, TransformRemove, TransformReplace:
+			if c.segments, err = parseJSONPointer(rule.Path); err != nil {
+				return nil, invalid("%v", err)
+			}
+			c.description = fmt.Sprintf("rule[%d] %s %s", i, rule.Type, rule.Path)
+		case TransformRegistry:
+			if rule.From == "" {
+				return nil, invalid("from is required")
+			}
+			c.description = fmt.Sprintf("rule[%d] registry %s -> %s", i, rule.From, rule.To)
+		default:
+			return nil, invalid("unsupported type %q", rule.Type)
+		}
+
+		switch rule.Type {
+		case TransformSet:
+			if len(rule.Value) == 0 {
+				return nil, invalid("value is required")
+			}
+			if err := utiljson.Unmarshal(rule.Value, &c.value); err != nil {
+				return nil, invalid("invalid value: %v", err)
+			}
+		case TransformReplace:
+			if rule.From == "" {
+				return nil, invalid("from is required")
+			}
+			if rule.Regex {
+				if c.pattern, err = regexp.Compile(rule.From); err != nil {
+					return nil, invalid("invalid regex: %v", err)
+				}
+			}
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// matches 判断规则是否作用于该对象
+func (r *compiledTransformRule) matches(gk schema.GroupKind, obj *unstructured.Unstructured) bool {
+	if len(r.Kinds) > 0 && !containsFold(r.Kinds, gk.Kind) {
+		return false
+	}
+	if len(r.Names) == 0 {
+		return true
+	}
+	for _, pattern := range r.Names {
+		if ok, _ := path.Match(pattern, obj.GetName()); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold 忽略大小写判断切片中是否包含 s
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTransformRules 按顺序对对象执行匹配的规则，返回实际产生作用的规则描述
+func applyTransformRules(rules []compiledTransformRule, gk schema.GroupKind, obj *unstructured.Unstructured) ([]string, error) {
+	var applied []string
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.matches(gk, obj) {
+			continue
+		}
+		changed, err := rule.apply(gk, obj)
+		if err != nil {
+			return applied, fmt.Errorf("%s 执行失败: %v", rule.description, err)
+		}
+		if changed {
+			applied = append(applied, rule.description)
+		}
+	}
+	return applied, nil
+}
+
+// apply 执行单条规则，返回对象是否发生变化
+func (r *compiledTransformRule) apply(gk schema.GroupKind, obj *unstructured.Unstructured) (bool, error) {
+	changed := false
+	switch r.Type {
+	case TransformJSONPatch:
+		original, err := json.Marshal(obj.Object)
+		if err != nil {
+			return false, err
+		}
+		patched, err := r.patch.Apply(original)
+		if err != nil {
+			return false, err
+		}
+		if jsonpatch.Equal(original, patched) {
+			return false, nil
+		}
+		var content map[string]interface{}
+		if err := utiljson.Unmarshal(patched, &content); err != nil {
+			return false, err
+		}
+		obj.Object = content
+		return true, nil
+
+	case TransformSet:
+		visitJSONPointer(obj.Object, r.segments, true, func(container interface{}, key string) {
+			if reflect.DeepEqual(containerValue(container, key), r.value) {
+				return
+			}
+			setContainerValue(container, key, runtime.DeepCopyJSONValue(r.value))
+			changed = true
+		})
+
+	case TransformRemove:
+		visitJSONPointer(obj.Object, r.segments, false, func(container interface{}, key string) {
+			if m, ok := container.(map[string]interface{}); ok {
+				if _, found := m[key]; found {
+					delete(m, key)
+					changed = true
+				}
+			}
+		})
+
+	case TransformReplace:
+		visitJSONPointer(obj.Object, r.segments, false, func(container interface{}, key string) {
+			value, ok := containerValue(container, key).(string)
+			if !ok {
+				return
+			}
+			var replaced string
+			if r.pattern != nil {
+				replaced = r.pattern.ReplaceAllString(value, r.To)
+			} else {
+				replaced = strings.ReplaceAll(value, r.From, r.To)
+			}
+			if replaced != value {
+				setContainerValue(container, key, replaced)
+				changed = true
+			}
+		})
+
+	case TransformRegistry:
+		specPath, ok := podSpecPaths[gk]
+		if !ok {
+			return false, nil
+		}
+		for _, field := range []string{"containers", "initContainers", "ephemeralContainers"} {
+			segments := append(append(append([]string{}, specPath...), field), "*", "image")
+			visitJSONPointer(obj.Object, segments, false, func(container interface{}, key string) {
+				image, ok := containerValue(container, key).(string)
+				if ok && strings.HasPrefix(image, r.From) {
+					setContainerValue(container, key, r.To+strings.TrimPrefix(image, r.From))
+					changed = true
+				}
+			})
+		}
+	}
+	return changed, nil
+}
+
+// parseJSONPointer 解析 JSON Pointer 为路径段，处理 ~1 和 ~0 转义
+func parseJSONPointer(pointer string) ([]string, error) {
+	if !strings.HasPrefix(pointer, "/") || len(pointer) < 2 {
+		return nil, fmt.Errorf("path %q must be a JSON pointer like /spec/replicas", pointer)
+	}
+	segments := strings.Split(pointer[1:], "/")
+	for i, segment := range segments {
+		segments[i] = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+	}
+	return segments, nil
+}
+
+// visitJSONPointer 对路径匹配到的每个位置调用 fn，container 为最后一段所在的对象或数组，key 为键或下标。
+// create 为 true 时沿途创建缺失的对象，并对最后一段不存在的键也调用 fn
+func visitJSONPointer(node interface{}, segments []string, create bool, fn func(container interface{}, key string)) {
+	segment, last := segments[0], len(segments) == 1
+	switch n := node.(type) {
+	case map[string]interface{}:
+		keys := []string{segment}
+		if segment == "*" {
+			keys = keys[:0]
+			for key := range n {
+				keys = append(keys, key)
+			}
+		}
+		for _, key := range keys {
+			child, found := n[key]
+			if last {
+				if found || (create && segment != "*") {
+					fn(n, key)
+				}
+				continue
+			}
+			if !found {
+				if !create || segment == "*" {
+					continue
+				}
+				child = map[string]interface{}{}
+				n[key] = child
+			}
+			visitJSONPointer(child, segments[1:], create, fn)
+		}
+	case []interface{}:
+		var indexes []int
+		if segment == "*" {
+			for i := range n {
+				indexes = append(indexes, i)
+			}
+		} else if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(n) {
+			indexes = append(indexes, i)
+		}
+		for _, i := range indexes {
+			if last {
+				fn(n, strconv.Itoa(i))
+				continue
+			}
+			visitJSONPointer(n[i], segments[1:], create, fn)
+		}
+	}
+}
+
+// containerValue 读取对象或数组中的值
+func containerValue(container interface{}, key string) interface{} {
+	switch c := container.(type) {
+	case map[string]interface{}:
+		return c[key]
+	case []interface{}:
+		i, _ := strconv.Atoi(key)
+		return c[i]
+	}
+	return nil
+}
+
+// setContainerValue 写入对象或数组中的值，数组只能替换已有元素
+func setContainerValue(container interface{}, key string, value interface{}) {
+	switch c := container.(type) {
+	case map[string]interface{}:
+		c[key] = value
+	case []interface{}:
+		i, _ := strconv.Atoi(key)
+		c[i] = value
+	}
+}
@@ -2,7 +2,15 @@ package kubernetes
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	coreError "opscore/error"
+	"gorm.io/gorm"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"opscore/internal/db"
 	"opscore/internal/log"
 	"opscore/internal/model"
@@ -12,17 +20,25 @@ import (
 
 
 func ListNamespace(clusterID string) ( []string,error) {
-	c,err := GetClusterByClusterID(clusterID)
-	if err!= nil {
-		return nil,err
-	}
 	var namespaces []string
-	client, err := NewK8sClient(c.KubeConfig)
+	clients, err := GetClusterClients(clusterID)
 	if err != nil {
 		return namespaces,err
 	}
+	// 启用 informer 时从本地缓存读取
+	if lister, ok := clients.NamespaceLister(); ok {
+		items, err := lister.List(labels.Everything())
+		if err != nil {
+			return namespaces, err
+		}
+		for _, namespace := range items {
+			namespaces = append(namespaces, namespace.Name)
+		}
+		sort.Strings(namespaces)
+		return namespaces, nil
+	}
 	// 调用 Kubernetes API 获取 Namespace 列表
-	namespaceList, err := client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	namespaceList, err := clients.Clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		return namespaces,err
 	}
@@ -33,14 +49,26 @@ func ListNamespace(clusterID string) ( []string,error) {
 	return namespaces,nil
 }
 
+// GetClusterByClusterID 根据集群ID获取集群信息，数字ID按数据库主键查询，否则按 cluster_id 查询
 func GetClusterByClusterID(clusterID string) (*model.K8sClusterMetaData,error) {
 	logger := log.GetLogger()
 	var cluster model.K8sClusterMetaData
 	db := db.DBInstance
-	err := db.Where("id = ?", clusterID).First(&cluster).Error
+	err := clusterQuery(db.DB, clusterID).First(&cluster).Error
 	if err!= nil {
 		logger.Error("获取集群信息失败", zap.Error(err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", coreError.ErrClusterNotFound, clusterID)
+		}
 		return nil,err
 	}
 	return &cluster,nil
-}
\ No newline at end of file
+}
+
+// clusterQuery 构造按集群ID查询的条件，兼容数据库主键和 UUID 形式的 cluster_id
+func clusterQuery(tx *gorm.DB, clusterID string) *gorm.DB {
+	if _, err := strconv.ParseUint(clusterID, 10, 64); err == nil {
+		return tx.Where("id = ?", clusterID)
+	}
+	return tx.Where("cluster_id = ?", clusterID)
+}
@@ -0,0 +1,589 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	coreError "opscore/error"
+	"opscore/internal/db"
+	"opscore/internal/log"
+	"opscore/internal/model"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// 节点排空任务状态
+const (
+	DrainStatusRunning   = "running"
+	DrainStatusSucceeded = "succeeded"
+	DrainStatusFailed    = "failed"
+	DrainStatusTimeout   = "timeout"
+)
+
+const (
+	defaultDrainTimeout = 5 * time.Minute
+	// evictionRetryInterval PodDisruptionBudget 不允许驱逐时的重试间隔
+	evictionRetryInterval = 5 * time.Second
+	// podDeletePollInterval 等待被驱逐的 Pod 删除完成的轮询间隔
+	podDeletePollInterval = 2 * time.Second
+	mirrorPodAnnotation   = "kubernetes.io/config.mirror"
+	nodeRoleLabelPrefix   = "node-role.kubernetes.io/"
+)
+
+// NodeCondition 节点状态条件
+type NodeCondition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	Reason             string    `json:"reason"`
+	Message            string    `json:"message"`
+	LastTransitionTime time.Time `json:"lastTransitionTime"`
+}
+
+// NodeTaint 节点污点
+type NodeTaint struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+// NodeInfo 节点清单信息
+type NodeInfo struct {
+	Name             string            `json:"name"`
+	Ready            bool              `json:"ready"`
+	Unschedulable    bool              `json:"unschedulable"`
+	Roles            []string          `json:"roles"`
+	InternalIP       string            `json:"internalIP"`
+	KubeletVersion   string            `json:"kubeletVersion"`
+	OSImage          string            `json:"osImage"`
+	KernelVersion    string            `json:"kernelVersion"`
+	ContainerRuntime string            `json:"containerRuntime"`
+	Capacity         map[string]string `json:"capacity"`
+	Allocatable      map[string]string `json:"allocatable"`
+	Taints           []NodeTaint       `json:"taints"`
+	Conditions       []NodeCondition   `json:"conditions"`
+	Labels           map[string]string `json:"labels"`
+	PodCount         int               `json:"podCount"`
+	Age              string            `json:"age"`
+	CreatedAt        time.Time         `json:"createdAt"`
+}
+
+// DrainOptions 节点排空参数
+type DrainOptions struct {
+	TimeoutSeconds     int    // 整体超时，默认 300 秒
+	GracePeriodSeconds *int64 // Pod 优雅终止时间，为空时使用 Pod 自身的设置
+	DeleteEmptyDirData bool   // 允许驱逐使用 emptyDir 的 Pod，emptyDir 中的数据会丢失
+	Force              bool   // 允许驱逐没有控制器管理的 Pod，这些 Pod 不会被重建
+}
+
+// ListNodes 列出集群节点及每个节点上运行中的 Pod 数
+func ListNodes(clusterID string) ([]NodeInfo, error) {
+	logger := log.GetLogger()
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := clients.Clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		logger.Error("ListNodes: failed to list nodes", zap.Error(err), zap.String("clusterID", clusterID))
+		return nil, err
+	}
+	podCounts, err := countPodsByNode(clients, "")
+	if err != nil {
+		logger.Error("ListNodes: failed to count pods", zap.Error(err), zap.String("clusterID", clusterID))
+		return nil, err
+	}
+
+	infos := make([]NodeInfo, 0, len(nodes.Items))
+	for i := range nodes.Items {
+		info := toNodeInfo(&nodes.Items[i])
+		info.PodCount = podCounts[info.Name]
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// GetNode 获取单个节点信息
+func GetNode(clusterID, name string) (*NodeInfo, error) {
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	node, err := clients.Clientset.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	podCounts, err := countPodsByNode(clients, name)
+	if err != nil {
+		return nil, err
+	}
+	info := toNodeInfo(node)
+	info.PodCount = podCounts[name]
+	return &info, nil
+}
+
+// countPodsByNode 统计各节点上未结束的 Pod 数，nodeName 不为空时只统计该节点
+func countPodsByNode(clients *ClusterClients, nodeName string) (map[string]int, error) {
+	selector := "status.phase!=Succeeded,status.phase!=Failed"
+	if nodeName != "" {
+		selector += ",spec.nodeName=" + nodeName
+	}
+	pods, err := clients.Clientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for _, pod := range pods.Items {
+		counts[pod.Spec.NodeName]++
+	}
+	return counts, nil
+}
+
+func toNodeInfo(node *corev1.Node) NodeInfo {
+	info := NodeInfo{
+		Name:             node.Name,
+		Ready:            isNodeReady(node),
+		Unschedulable:    node.Spec.Unschedulable,
+		Roles:            nodeRoles(node),
+		KubeletVersion:   node.Status.NodeInfo.KubeletVersion,
+		OSImage:          node.Status.NodeInfo.OSImage,
+		KernelVersion:    node.Status.NodeInfo.KernelVersion,
+		ContainerRuntime: node.Status.NodeInfo.ContainerRuntimeVersion,
+		Capacity:         resourceListStrings(node.Status.Capacity),
+		Allocatable:      resourceListStrings(node.Status.Allocatable),
+		Taints:           make([]NodeTaint, 0, len(node.Spec.Taints)),
+		Conditions:       make([]NodeCondition, 0, len(node.Status.Conditions)),
+		Labels:           node.Labels,
+		Age:              duration.HumanDuration(time.Since(node.CreationTimestamp.Time)),
+		CreatedAt:        node.CreationTimestamp.Time,
+	}
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == corev1.NodeInternalIP {
+			info.InternalIP = addr.Address
+			break
+		}
+	}
+	for _, t := range node.Spec.Taints {
+		info.Taints = append(info.Taints, NodeTaint{Key: t.Key, Value: t.Value, Effect: string(t.Effect)})
+	}
+	for _, c := range node.Status.Conditions {
+		info.Conditions = append(info.Conditions, NodeCondition{
+			Type:               string(c.Type),
+			Status:             string(c.Status),
+			Reason:             c.Reason,
+			Message:            c.Message,
+			LastTransitionTime: c.LastTransitionTime.Time,
+		})
+	}
+	return info
+}
+
+// nodeRoles 从 node-role.kubernetes.io/<role> 和 kubernetes.io/role 标签中读取节点角色
+func nodeRoles(node *corev1.Node) []string {
+	roles := []string{}
+	for key, value := range node.Labels {
+		if role, ok := strings.CutPrefix(key, nodeRoleLabelPrefix); ok && role != "" {
+			roles = append(roles, role)
+		} else if key == "kubernetes.io/role" && value != "" {
+			roles = append(roles, value)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+func resourceListStrings(list corev1.ResourceList) map[string]string {
+	result := make(map[string]string, len(list))
+	for name, q := range list {
+		result[string(name)] = q.String()
+	}
+	return result
+}
+
+// CordonNode 设置节点是否可调度，unschedulable 为 true 时封锁节点，false 时解除封锁
+func CordonNode(clusterID, name string, unschedulable bool) (*NodeInfo, error) {
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := setNodeUnschedulable(clients, name, unschedulable); err != nil {
+		return nil, err
+	}
+	log.GetLogger().Info("设置节点调度状态", zap.String("clusterID", clusterID), zap.String("node", name), zap.Bool("unschedulable", unschedulable))
+	return GetNode(clusterID, name)
+}
+
+func setNodeUnschedulable(clients *ClusterClients, name string, unschedulable bool) error {
+	patch := fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable)
+	_, err := clients.Clientset.CoreV1().Nodes().Patch(context.TODO(), name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	return err
+}
+
+// drainRun 正在执行的排空任务，task 只能在持有 mu 时读写
+type drainRun struct {
+	mu      sync.Mutex
+	task    *model.K8sNodeDrainTask
+	pending map[string]bool
+}
+
+var (
+	drainRunsMu sync.Mutex
+	drainRuns   = map[string]*drainRun{} // 键为 TaskID，任务结束后移除，之后从数据库读取
+	// drainingNodes 已被排空任务占用的节点，键为 clusterID/nodeName，值为 TaskID。
+	// 在列出 Pod 之前占用，保证同一节点不会同时运行两个排空任务
+	drainingNodes = map[string]string{}
+)
+
+// StartNodeDrain 封锁节点并异步驱逐其上的 Pod，返回排空任务，进度通过 GetDrainTask 查询。
+// 驱逐通过 Eviction API 进行，因此遵守 PodDisruptionBudget；DaemonSet Pod 和静态 Pod 会被跳过。
+// 存在没有控制器管理的 Pod（未设置 Force）或使用 emptyDir 的 Pod（未设置 DeleteEmptyDirData）时不开始驱逐，节点保持封锁
+func StartNodeDrain(clusterID, nodeName string, opts DrainOptions, actor OperationActor) (*model.K8sNodeDrainTask, error) {
+	logger := log.GetLogger()
+	cluster, err := GetClusterByClusterID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	taskID := uuid.New().String()
+	if running := reserveDrainNode(cluster.ClusterID, nodeName, taskID); running != "" {
+		return nil, fmt.Errorf("%w: node %s is already being drained by task %s", coreError.ErrInvalidResource, nodeName, running)
+	}
+	started := false
+	defer func() {
+		if !started {
+			releaseDrainNode(cluster.ClusterID, nodeName)
+		}
+	}()
+	if _, err := clients.Clientset.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{}); err != nil {
+		return nil, err
+	}
+	if err := setNodeUnschedulable(clients, nodeName, true); err != nil {
+		return nil, err
+	}
+
+	pods, err := clients.Clientset.CoreV1().Pods("").List(context.TODO(), metav1.ListOptions{FieldSelector: "spec.nodeName=" + nodeName})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		toEvict  []corev1.Pod
+		skipped  []string
+		blocking []string
+	)
+	for _, pod := range pods.Items {
+		key := pod.Namespace + "/" + pod.Name
+		if _, ok := pod.Annotations[mirrorPodAnnotation]; ok || isDaemonSetPod(&pod) {
+			skipped = append(skipped, key)
+			continue
+		}
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if metav1.GetControllerOf(&pod) == nil && !opts.Force {
+			blocking = append(blocking, key+" (not managed by a controller)")
+			continue
+		}
+		if hasEmptyDir(&pod) && !opts.DeleteEmptyDirData {
+			blocking = append(blocking, key+" (uses emptyDir)")
+			continue
+		}
+		toEvict = append(toEvict, pod)
+	}
+	if len(blocking) > 0 {
+		return nil, fmt.Errorf("%w: cannot drain node %s, blocking pods: %s", coreError.ErrInvalidResource, nodeName, strings.Join(blocking, ", "))
+	}
+
+	timeout := defaultDrainTimeout
+	if opts.TimeoutSeconds > 0 {
+		timeout = time.Duration(opts.TimeoutSeconds) * time.Second
+	}
+	now := time.Now()
+	run := &drainRun{
+		task: &model.K8sNodeDrainTask{
+			TaskID:         taskID,
+			ClusterID:      cluster.ClusterID,
+			NodeName:       nodeName,
+			Status:         DrainStatusRunning,
+			TotalPods:      len(toEvict),
+			SkippedPods:    skipped,
+			TimeoutSeconds: int(timeout / time.Second),
+			User:           actor.User,
+			StartTime:      &now,
+		},
+		pending: make(map[string]bool, len(toEvict)),
+	}
+	for _, pod := range toEvict {
+		run.pending[pod.Namespace+"/"+pod.Name] = true
+	}
+	run.task.PendingPods = run.pendingList()
+	if err := db.DBInstance.DB.Create(run.task).Error; err != nil {
+		return nil, err
+	}
+
+	drainRunsMu.Lock()
+	drainRuns[run.task.TaskID] = run
+	drainRunsMu.Unlock()
+	started = true
+
+	logger.Info("开始排空节点", zap.String("taskID", run.task.TaskID), zap.String("clusterID", cluster.ClusterID),
+		zap.String("node", nodeName), zap.Int("pods", len(toEvict)), zap.Int("skipped", len(skipped)), zap.String("user", actor.User))
+	snapshot := run.snapshot()
+	go run.execute(clients, toEvict, opts.GracePeriodSeconds, timeout)
+	return snapshot, nil
+}
+
+// execute 并发驱逐所有 Pod 并等待删除完成，直到全部完成或超时
+func (r *drainRun) execute(clients *ClusterClients, pods []corev1.Pod, gracePeriod *int64, timeout time.Duration) {
+	logger := log.GetLogger()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		failures []string
+	)
+	for i := range pods {
+		wg.Add(1)
+		go func(pod *corev1.Pod) {
+			defer wg.Done()
+			if err := evictPod(ctx, clients, pod, gracePeriod); err != nil {
+				errMu.Lock()
+				failures = append(failures, fmt.Sprintf("%s/%s: %v", pod.Namespace, pod.Name, err))
+				errMu.Unlock()
+				return
+			}
+			r.podEvicted(pod.Namespace + "/" + pod.Name)
+		}(&pods[i])
+	}
+	wg.Wait()
+
+	status := DrainStatusSucceeded
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		status = DrainStatusTimeout
+	} else if len(failures) > 0 {
+		status = DrainStatusFailed
+	}
+	sort.Strings(failures)
+
+	r.mu.Lock()
+	now := time.Now()
+	r.task.Status = status
+	r.task.EndTime = &now
+	r.task.ErrorMessage = strings.Join(failures, "; ")
+	r.task.PendingPods = r.pendingList()
+	if err := db.DBInstance.DB.Model(r.task).Updates(map[string]interface{}{
+		"status":        r.task.Status,
+		"end_time":      r.task.EndTime,
+		"error_message": r.task.ErrorMessage,
+		"pending_pods":  r.task.PendingPods,
+		"evicted_pods":  r.task.EvictedPods,
+	}).Error; err != nil {
+		logger.Error("更新排空任务失败", zap.String("taskID", r.task.TaskID), zap.Error(err))
+	}
+	r.mu.Unlock()
+
+	drainRunsMu.Lock()
+	delete(drainRuns, r.task.TaskID)
+	delete(drainingNodes, drainNodeKey(r.task.ClusterID, r.task.NodeName))
+	drainRunsMu.Unlock()
+	logger.Info("节点排空结束", zap.String("taskID", r.task.TaskID), zap.String("node", r.task.NodeName), zap.String("status", status),
+		zap.Int("evicted", r.task.EvictedPods), zap.Int("total", r.task.TotalPods))
+}
+
+// evictPod 通过 Eviction API 驱逐 Pod 并等待其删除。被 PodDisruptionBudget 拒绝（429）时持续重试直到超时
+func evictPod(ctx context.Context, clients *ClusterClients, pod *corev1.Pod, gracePeriod *int64) error {
+	eviction := &policyv1.Eviction{
+		ObjectMeta:    metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		DeleteOptions: &metav1.DeleteOptions{GracePeriodSeconds: gracePeriod},
+	}
+	for {
+		err := clients.Clientset.PolicyV1().Evictions(pod.Namespace).Evict(ctx, eviction)
+		if err == nil {
+			break
+		}
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if !apierrors.IsTooManyRequests(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("eviction blocked by PodDisruptionBudget: %w", ctx.Err())
+		case <-time.After(evictionRetryInterval):
+		}
+	}
+
+	return wait.PollUntilContextCancel(ctx, podDeletePollInterval, true, func(ctx context.Context) (bool, error) {
+		current, err := clients.Clientset.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, nil
+		}
+		// 同名 Pod 被重建（例如 StatefulSet）也视为原 Pod 已删除
+		return current.UID != pod.UID, nil
+	})
+}
+
+func (r *drainRun) podEvicted(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.pending, key)
+	r.task.EvictedPods++
+	r.task.PendingPods = r.pendingList()
+	if err := db.DBInstance.DB.Model(r.task).Updates(map[string]interface{}{
+		"evicted_pods": r.task.EvictedPods,
+		"pending_pods": r.task.PendingPods,
+	}).Error; err != nil {
+		log.GetLogger().Error("更新排空进度失败", zap.String("taskID", r.task.TaskID), zap.Error(err))
+	}
+}
+
+// pendingList 调用方需持有 mu
+func (r *drainRun) pendingList() model.StringSlice {
+	list := make(model.StringSlice, 0, len(r.pending))
+	for key := range r.pending {
+		list = append(list, key)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func (r *drainRun) snapshot() *model.K8sNodeDrainTask {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task := *r.task
+	task.PendingPods = append(model.StringSlice{}, r.task.PendingPods...)
+	task.SkippedPods = append(model.StringSlice{}, r.task.SkippedPods...)
+	return &task
+}
+
+func drainNodeKey(clusterID, nodeName string) string {
+	return clusterID + "/" + nodeName
+}
+
+// reserveDrainNode 为排空任务占用节点，节点已被其他任务占用时返回该任务 ID
+func reserveDrainNode(clusterID, nodeName, taskID string) string {
+	drainRunsMu.Lock()
+	defer drainRunsMu.Unlock()
+	key := drainNodeKey(clusterID, nodeName)
+	if running, ok := drainingNodes[key]; ok {
+		return running
+	}
+	drainingNodes[key] = taskID
+	return ""
+}
+
+// releaseDrainNode 释放未能启动的排空任务占用的节点
+func releaseDrainNode(clusterID, nodeName string) {
+	drainRunsMu.Lock()
+	defer drainRunsMu.Unlock()
+	delete(drainingNodes, drainNodeKey(clusterID, nodeName))
+}
+
+// FailOrphanedDrainTasks 将进程重启前未结束的排空任务标记为失败。
+// 排空在进程内异步执行，重启后不会继续，这些任务的状态否则会一直停留在 running
+func FailOrphanedDrainTasks() (int64, error) {
+	now := time.Now()
+	result := db.DBInstance.DB.Model(&model.K8sNodeDrainTask{}).
+		Where("status = ?", DrainStatusRunning).
+		Updates(map[string]interface{}{
+			"status":        DrainStatusFailed,
+			"end_time":      &now,
+			"error_message": "drain interrupted: opscore restarted before the task finished",
+		})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.GetLogger().Warn("已将中断的节点排空任务标记为失败", zap.Int64("count", result.RowsAffected))
+	}
+	return result.RowsAffected, nil
+}
+
+// GetDrainTask 获取排空任务进度，执行中的任务从内存读取，其余从数据库读取
+func GetDrainTask(clusterID, taskID string) (*model.K8sNodeDrainTask, error) {
+	cluster, err := GetClusterByClusterID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	drainRunsMu.Lock()
+	run, ok := drainRuns[taskID]
+	drainRunsMu.Unlock()
+	if ok && run.task.ClusterID == cluster.ClusterID {
+		return run.snapshot(), nil
+	}
+
+	var task model.K8sNodeDrainTask
+	err = db.DBInstance.DB.Where("cluster_id = ? AND task_id = ?", cluster.ClusterID, taskID).First(&task).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", coreError.ErrDrainTaskNotFound, taskID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// ListDrainTasks 按开始时间倒序列出排空任务，nodeName 为空时列出集群所有节点
+func ListDrainTasks(clusterID, nodeName string, limit int) ([]model.K8sNodeDrainTask, error) {
+	cluster, err := GetClusterByClusterID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	query := db.DBInstance.DB.Where("cluster_id = ?", cluster.ClusterID)
+	if nodeName != "" {
+		query = query.Where("node_name = ?", nodeName)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var tasks []model.K8sNodeDrainTask
+	if err := query.Order("created_at DESC").Find(&tasks).Error; err != nil {
+		return nil, err
+	}
+	// 执行中的任务用内存中的最新进度替换
+	for i := range tasks {
+		drainRunsMu.Lock()
+		run, ok := drainRuns[tasks[i].TaskID]
+		drainRunsMu.Unlock()
+		if ok {
+			tasks[i] = *run.snapshot()
+		}
+	}
+	return tasks, nil
+}
+
+func isDaemonSetPod(pod *corev1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == "DaemonSet"
+}
+
+func hasEmptyDir(pod *corev1.Pod) bool {
+	for _, v := range pod.Spec.Volumes {
+		if v.EmptyDir != nil {
+			return true
+		}
+	}
+	return false
+}
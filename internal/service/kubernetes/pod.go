@@ -7,7 +7,16 @@ import (
 	//"k8s.io/client-go/informers"
 	//"time"
 	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/duration"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 
 )
 
@@ -16,10 +25,33 @@ type PodInfo struct {
 	Namespace string `json:"namespace"`
 	Status string `json:"status"` 
 	NodeName string  `json:"nodename"`
-	Cpu string `json:"cpu"` 
+	Cpu string `json:"cpu"` // 实际使用量，来自 metrics.k8s.io，未安装 metrics-server 时为空
 	Memory string `json:"memory"` 
-	RestartCount int `json:"restartCount"`
-	Age string `json:"age"` 
+	CpuMillicores int64 `json:"cpuMillicores"`
+	MemoryBytes int64 `json:"memoryBytes"`
+	CpuRequests string `json:"cpuRequests"`
+	CpuLimits string `json:"cpuLimits"`
+	MemoryRequests string `json:"memoryRequests"`
+	MemoryLimits string `json:"memoryLimits"`
+	RestartCount int `json:"restartCount"` // 所有容器重启次数之和
+	Age string `json:"age"` // 存活时长，例如 3d4h
+	CreatedAt string `json:"createdAt"`
+	Containers []ContainerInfo `json:"containers"`
+}
+
+// ContainerInfo 容器详情
+type ContainerInfo struct {
+	Name          string `json:"name"`
+	Image         string `json:"image"`
+	Ready         bool   `json:"ready"`
+	State         string `json:"state"`
+	RestartCount  int    `json:"restartCount"`
+	Cpu           string `json:"cpu"`
+	Memory        string `json:"memory"`
+	CpuRequest    string `json:"cpuRequest"`
+	CpuLimit      string `json:"cpuLimit"`
+	MemoryRequest string `json:"memoryRequest"`
+	MemoryLimit   string `json:"memoryLimit"`
 }
 
 // GetPodsInNamespace retrieves a list of pods in a given namespace using limit and continue token for pagination.
@@ -27,15 +59,19 @@ type PodInfo struct {
 func GetPodsInNamespace(clusterID string, namespace string, limit int64, continueToken string) ([]PodInfo, string, error) {
 	logger := log.GetLogger()
 	podInfos := []PodInfo{}
-	k8sClusterMetaData, err := GetClusterByClusterID(clusterID)
+	clients, err := GetClusterClients(clusterID)
 	if err != nil {
-		logger.Error("GetPodsInNamespace: failed to get cluster metadata", zap.Error(err), zap.String("clusterID", clusterID))
+		logger.Error("GetPodsInNamespace: failed to get k8s client", zap.Error(err), zap.String("clusterID", clusterID))
 		return podInfos, "", err
 	}
-	client, err := NewK8sClient(k8sClusterMetaData.KubeConfig)
-	if err != nil {
-		logger.Error("GetPodsInNamespace: failed to create k8s client", zap.Error(err), zap.String("clusterID", clusterID))
-		return podInfos, "", err
+
+	// 启用 informer 时从本地缓存分页读取，continue token 为下一页的偏移量
+	if lister, ok := clients.PodLister(); ok {
+		podInfos, next, err := listPodsFromCache(lister, namespace, limit, continueToken)
+		if err == nil {
+			withPodMetrics(clients, namespace, podInfos)
+		}
+		return podInfos, next, err
 	}
 
 	listOptions := metav1.ListOptions{}
@@ -47,32 +83,125 @@ func GetPodsInNamespace(clusterID string, namespace string, limit int64, continu
 	}
 
 	logger.Info("GetPodsInNamespace", zap.String("clusterID", clusterID), zap.String("namespace", namespace), zap.Int64("limit", limit), zap.String("continueToken", continueToken))
-	pods, err := client.CoreV1().Pods(namespace).List(context.TODO(), listOptions)
+	pods, err := clients.Clientset.CoreV1().Pods(namespace).List(context.TODO(), listOptions)
 	logger.Info("GetPodsInNamespace", zap.Int("获取到pods数:", len(pods.Items)))
 	if err != nil {
 		logger.Error("GetPodsInNamespace: failed to list pods", zap.Error(err), zap.String("clusterID", clusterID), zap.String("namespace", namespace))
 		return podInfos, "", err
 	}
 
-	for _, pod := range pods.Items {
-		// Ensure ContainerStatuses is not empty to avoid panic
-		var restartCount int32 = 0
-		if len(pod.Status.ContainerStatuses) > 0 {
-			restartCount = pod.Status.ContainerStatuses[0].RestartCount
+	for i := range pods.Items {
+		podInfos = append(podInfos, toPodInfo(&pods.Items[i]))
+	}
+	withPodMetrics(clients, namespace, podInfos)
+
+	return podInfos, pods.ListMeta.Continue, nil
+}
+
+// withPodMetrics 查询 metrics API 填充使用量，不可用时保持为空
+func withPodMetrics(clients *ClusterClients, namespace string, podInfos []PodInfo) {
+	if len(podInfos) == 0 {
+		return
+	}
+	if usages, ok := listPodMetrics(clients, namespace); ok {
+		applyPodMetrics(podInfos, usages)
+	}
+}
+
+// toPodInfo 将 Pod 转换为列表展示信息，使用量由 applyPodMetrics 填充
+func toPodInfo(pod *corev1.Pod) PodInfo {
+	requests, limits := sumContainerResources(pod.Spec.Containers)
+	info := PodInfo{
+		Name:           pod.Name,
+		Namespace:      pod.Namespace,
+		Status:         string(pod.Status.Phase),
+		NodeName:       pod.Spec.NodeName,
+		CpuRequests:    quantityString(requests, corev1.ResourceCPU),
+		CpuLimits:      quantityString(limits, corev1.ResourceCPU),
+		MemoryRequests: quantityString(requests, corev1.ResourceMemory),
+		MemoryLimits:   quantityString(limits, corev1.ResourceMemory),
+		Age:            duration.HumanDuration(time.Since(pod.CreationTimestamp.Time)),
+		CreatedAt:      pod.CreationTimestamp.Format("2006-01-02 15:04:05"),
+		Containers:     make([]ContainerInfo, 0, len(pod.Spec.Containers)),
+	}
+
+	statuses := make(map[string]corev1.ContainerStatus, len(pod.Status.ContainerStatuses))
+	for _, cs := range pod.Status.ContainerStatuses {
+		statuses[cs.Name] = cs
+		info.RestartCount += int(cs.RestartCount)
+	}
+	for _, c := range pod.Spec.Containers {
+		container := ContainerInfo{
+			Name:          c.Name,
+			Image:         c.Image,
+			CpuRequest:    quantityString(c.Resources.Requests, corev1.ResourceCPU),
+			CpuLimit:      quantityString(c.Resources.Limits, corev1.ResourceCPU),
+			MemoryRequest: quantityString(c.Resources.Requests, corev1.ResourceMemory),
+			MemoryLimit:   quantityString(c.Resources.Limits, corev1.ResourceMemory),
 		}
+		if cs, ok := statuses[c.Name]; ok {
+			container.Ready = cs.Ready
+			container.RestartCount = int(cs.RestartCount)
+			container.State = containerState(cs.State)
+		}
+		info.Containers = append(info.Containers, container)
+	}
+	return info
+}
+
+// containerState 返回容器状态，等待或终止时带上原因
+func containerState(state corev1.ContainerState) string {
+	switch {
+	case state.Running != nil:
+		return "Running"
+	case state.Waiting != nil:
+		return "Waiting: " + state.Waiting.Reason
+	case state.Terminated != nil:
+		return "Terminated: " + state.Terminated.Reason
+	}
+	return "Unknown"
+}
+
+// listPodsFromCache 从 informer 缓存中按命名空间、名称排序后分页
+func listPodsFromCache(lister corev1listers.PodLister, namespace string, limit int64, continueToken string) ([]PodInfo, string, error) {
+	var pods []*corev1.Pod
+	var err error
+	if namespace == "" {
+		pods, err = lister.List(labels.Everything())
+	} else {
+		pods, err = lister.Pods(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
 
-		podInfo := PodInfo{
-			Name:         pod.Name,
-			Namespace:    pod.Namespace,
-			Status:       string(pod.Status.Phase),
-			NodeName:     pod.Spec.NodeName,
-			Cpu:          "1", // Placeholder, consider fetching actual metrics if needed
-			Memory:       "1", // Placeholder, consider fetching actual metrics if needed
-			RestartCount: int(restartCount),
-			Age:          pod.CreationTimestamp.Format("2006-01-02 15:04:05"),
+	offset := 0
+	if continueToken != "" {
+		if offset, err = strconv.Atoi(continueToken); err != nil || offset < 0 {
+			return nil, "", fmt.Errorf("invalid continue token: %s", continueToken)
 		}
-		podInfos = append(podInfos, podInfo)
+	}
+	if offset > len(pods) {
+		offset = len(pods)
+	}
+	end := len(pods)
+	if limit > 0 && offset+int(limit) < end {
+		end = offset + int(limit)
 	}
 
-	return podInfos, pods.ListMeta.Continue, nil
-}
\ No newline at end of file
+	podInfos := make([]PodInfo, 0, end-offset)
+	for _, pod := range pods[offset:end] {
+		podInfos = append(podInfos, toPodInfo(pod))
+	}
+	next := ""
+	if end < len(pods) {
+		next = strconv.Itoa(end)
+	}
+	return podInfos, next, nil
+}
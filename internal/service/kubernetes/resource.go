@@ -0,0 +1,330 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	coreError "opscore/error"
+	"opscore/internal/log"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+)
+
+// APIResourceInfo 集群支持的资源类型
+type APIResourceInfo struct {
+	Name       string   `json:"name"`
+	ShortNames []string `json:"shortNames,omitempty"`
+	Kind       string   `json:"kind"`
+	Group      string   `json:"group"`
+	Version    string   `json:"version"`
+	Namespaced bool     `json:"namespaced"`
+	Verbs      []string `json:"verbs"`
+}
+
+// ResourceQuery 列出资源时的过滤和分页参数，与 GetPodsInNamespace 的分页方式一致
+type ResourceQuery struct {
+	LabelSelector string
+	FieldSelector string
+	Limit         int64
+	Continue      string
+}
+
+// ResourceList 资源列表及下一页的 continue token
+type ResourceList struct {
+	Kind            string                   `json:"kind"`
+	APIVersion      string                   `json:"apiVersion"`
+	ResourceVersion string                   `json:"resourceVersion"`
+	Items           []map[string]interface{} `json:"items"`
+	ContinueToken   string                   `json:"continueToken"`
+}
+
+// ListAPIResources 通过发现接口列出集群支持的全部资源类型，包括 CRD。
+// 部分 API 组发现失败时返回其余可用的资源
+func ListAPIResources(clusterID string) ([]APIResourceInfo, error) {
+	logger := log.GetLogger()
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	lists, err := discovery.ServerPreferredResources(clients.Discovery)
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, err
+		}
+		logger.Warn("部分 API 组发现失败", zap.String("clusterID", clusterID), zap.Error(err))
+	}
+
+	var resources []APIResourceInfo
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			// 跳过 pods/log 之类的子资源
+			if strings.Contains(r.Name, "/") {
+				continue
+			}
+			resources = append(resources, APIResourceInfo{
+				Name:       r.Name,
+				ShortNames: r.ShortNames,
+				Kind:       r.Kind,
+				Group:      gv.Group,
+				Version:    gv.Version,
+				Namespaced: r.Namespaced,
+				Verbs:      r.Verbs,
+			})
+		}
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Group != resources[j].Group {
+			return resources[i].Group < resources[j].Group
+		}
+		return resources[i].Name < resources[j].Name
+	})
+	return resources, nil
+}
+
+// ResolveResource 将资源类型解析为 REST 映射，支持复数、单数、简称、Kind 以及
+// deployments.apps、deployments.v1.apps 形式。找不到时刷新发现缓存重试一次，以识别新安装的 CRD
+func (c *ClusterClients) ResolveResource(resourceType string) (*meta.RESTMapping, error) {
+	mapping, err := c.resolveResource(resourceType)
+	if meta.IsNoMatchError(err) {
+		c.ResetDiscovery()
+		mapping, err = c.resolveResource(resourceType)
+	}
+	if meta.IsNoMatchError(err) {
+		return nil, fmt.Errorf("%w: %s", coreError.ErrResourceTypeNotFound, resourceType)
+	}
+	return mapping, err
+}
+
+func (c *ClusterClients) resolveResource(resourceType string) (*meta.RESTMapping, error) {
+	mapper := restmapper.NewShortcutExpander(c.Mapper, c.Discovery, func(msg string) {
+		log.GetLogger().Warn(msg, zap.String("clusterID", c.ClusterID))
+	})
+
+	arg := strings.ToLower(resourceType)
+	var gvk schema.GroupVersionKind
+	var err error
+	fullySpecified, groupResource := schema.ParseResourceArg(arg)
+	if fullySpecified != nil {
+		gvk, err = mapper.KindFor(*fullySpecified)
+	}
+	if gvk.Empty() {
+		gvk, err = mapper.KindFor(groupResource.WithVersion(""))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+}
+
+// resourceClient 返回资源类型对应的动态客户端，并校验命名空间与资源作用域是否匹配。
+// allNamespaces 为 true 时命名空间级资源允许不指定命名空间（跨命名空间列出）
+func resourceClient(clients *ClusterClients, resourceType, namespace string, allNamespaces bool) (dynamic.ResourceInterface, *meta.RESTMapping, error) {
+	mapping, err := clients.ResolveResource(resourceType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if namespace == "" && !allNamespaces {
+			return nil, nil, fmt.Errorf("%w: %s is namespaced, namespace is required", coreError.ErrResourceScope, mapping.Resource.Resource)
+		}
+		return clients.Dynamic.Resource(mapping.Resource).Namespace(namespace), mapping, nil
+	}
+	if namespace != "" {
+		return nil, nil, fmt.Errorf("%w: %s is cluster-scoped", coreError.ErrResourceScope, mapping.Resource.Resource)
+	}
+	return clients.Dynamic.Resource(mapping.Resource), mapping, nil
+}
+
+// ListResources 列出资源，命名空间为空时列出所有命名空间
+func ListResources(clusterID, resourceType, namespace string, query ResourceQuery) (*ResourceList, error) {
+	logger := log.GetLogger()
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	client, mapping, err := resourceClient(clients, resourceType, namespace, true)
+	if err != nil {
+		return nil, err
+	}
+
+	listOptions := metav1.ListOptions{
+		LabelSelector: query.LabelSelector,
+		FieldSelector: query.FieldSelector,
+		Continue:      query.Continue,
+	}
+	if query.Limit > 0 {
+		listOptions.Limit = query.Limit
+	}
+
+	logger.Info("ListResources", zap.String("clusterID", clusterID), zap.String("resource", mapping.Resource.String()), zap.String("namespace", namespace))
+	list, err := client.List(context.TODO(), listOptions)
+	if err != nil {
+		logger.Error("ListResources: failed to list resources", zap.Error(err), zap.String("clusterID", clusterID), zap.String("resource", mapping.Resource.String()))
+		return nil, err
+	}
+
+	result := &ResourceList{
+		Kind:            mapping.GroupVersionKind.Kind,
+		APIVersion:      mapping.GroupVersionKind.GroupVersion().String(),
+		ResourceVersion: list.GetResourceVersion(),
+		Items:           make([]map[string]interface{}, 0, len(list.Items)),
+		ContinueToken:   list.GetContinue(),
+	}
+	for i := range list.Items {
+		obj := list.Items[i].Object
+		// 列表中的条目不带 apiVersion 和 kind，补齐后前端可直接编辑回写
+		obj["apiVersion"] = result.APIVersion
+		obj["kind"] = result.Kind
+		result.Items = append(result.Items, obj)
+	}
+	return result, nil
+}
+
+// GetResource 获取单个资源
+func GetResource(clusterID, resourceType, namespace, name string) (*unstructured.Unstructured, error) {
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	client, _, err := resourceClient(clients, resourceType, namespace, false)
+	if err != nil {
+		return nil, err
+	}
+	return client.Get(context.TODO(), name, metav1.GetOptions{})
+}
+
+// CreateResource 创建资源，对象的 apiVersion、kind 和命名空间缺省时按路径补齐
+func CreateResource(clusterID, resourceType, namespace string, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	logger := log.GetLogger()
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	client, mapping, err := resourceClient(clients, resourceType, namespace, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := prepareObject(obj, mapping, namespace); err != nil {
+		return nil, err
+	}
+
+	created, err := client.Create(context.TODO(), obj, metav1.CreateOptions{})
+	if err != nil {
+		logger.Error("CreateResource: failed to create resource", zap.Error(err), zap.String("clusterID", clusterID), zap.String("resource", mapping.Resource.String()), zap.String("name", obj.GetName()))
+		return nil, err
+	}
+	logger.Info("创建资源成功", zap.String("clusterID", clusterID), zap.String("resource", mapping.Resource.String()), zap.String("namespace", namespace), zap.String("name", created.GetName()))
+	return created, nil
+}
+
+// UpdateResource 更新资源。对象未带 resourceVersion 时使用集群中的当前版本，即以提交内容覆盖
+func UpdateResource(clusterID, resourceType, namespace, name string, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	logger := log.GetLogger()
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	client, mapping, err := resourceClient(clients, resourceType, namespace, false)
+	if err != nil {
+		return nil, err
+	}
+	if obj.GetName() == "" {
+		obj.SetName(name)
+	}
+	if obj.GetName() != name {
+		return nil, fmt.Errorf("%w: name %q does not match %q", coreError.ErrInvalidResource, obj.GetName(), name)
+	}
+	if err := prepareObject(obj, mapping, namespace); err != nil {
+		return nil, err
+	}
+	if obj.GetResourceVersion() == "" {
+		current, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		obj.SetResourceVersion(current.GetResourceVersion())
+	}
+
+	updated, err := client.Update(context.TODO(), obj, metav1.UpdateOptions{})
+	if err != nil {
+		logger.Error("UpdateResource: failed to update resource", zap.Error(err), zap.String("clusterID", clusterID), zap.String("resource", mapping.Resource.String()), zap.String("name", name))
+		return nil, err
+	}
+	logger.Info("更新资源成功", zap.String("clusterID", clusterID), zap.String("resource", mapping.Resource.String()), zap.String("namespace", namespace), zap.String("name", name))
+	return updated, nil
+}
+
+// DeleteResource 删除资源，propagation 为空时使用 Background
+func DeleteResource(clusterID, resourceType, namespace, name, propagation string) error {
+	logger := log.GetLogger()
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return err
+	}
+	client, mapping, err := resourceClient(clients, resourceType, namespace, false)
+	if err != nil {
+		return err
+	}
+
+	policy := metav1.DeletePropagationBackground
+	switch metav1.DeletionPropagation(propagation) {
+	case "":
+	case metav1.DeletePropagationForeground, metav1.DeletePropagationOrphan, metav1.DeletePropagationBackground:
+		policy = metav1.DeletionPropagation(propagation)
+	default:
+		return fmt.Errorf("%w: unknown propagation policy %q", coreError.ErrInvalidResource, propagation)
+	}
+
+	if err := client.Delete(context.TODO(), name, metav1.DeleteOptions{PropagationPolicy: &policy}); err != nil {
+		logger.Error("DeleteResource: failed to delete resource", zap.Error(err), zap.String("clusterID", clusterID), zap.String("resource", mapping.Resource.String()), zap.String("name", name))
+		return err
+	}
+	logger.Info("删除资源成功", zap.String("clusterID", clusterID), zap.String("resource", mapping.Resource.String()), zap.String("namespace", namespace), zap.String("name", name))
+	return nil
+}
+
+// prepareObject 校验提交对象的类型和命名空间与路径一致，缺省字段按路径补齐
+func prepareObject(obj *unstructured.Unstructured, mapping *meta.RESTMapping, namespace string) error {
+	gvk := mapping.GroupVersionKind
+	if obj.GetKind() == "" {
+		obj.SetKind(gvk.Kind)
+	}
+	if obj.GetAPIVersion() == "" {
+		obj.SetAPIVersion(gvk.GroupVersion().String())
+	}
+	if obj.GetKind() != gvk.Kind {
+		return fmt.Errorf("%w: kind %q does not match resource type %s", coreError.ErrInvalidResource, obj.GetKind(), mapping.Resource.Resource)
+	}
+	objGV, err := schema.ParseGroupVersion(obj.GetAPIVersion())
+	if err != nil || objGV.Group != gvk.Group {
+		return fmt.Errorf("%w: apiVersion %q does not match resource type %s", coreError.ErrInvalidResource, obj.GetAPIVersion(), mapping.Resource.String())
+	}
+	if obj.GetName() == "" && obj.GetGenerateName() == "" {
+		return fmt.Errorf("%w: metadata.name is required", coreError.ErrInvalidResource)
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace(namespace)
+		}
+		if obj.GetNamespace() != namespace {
+			return fmt.Errorf("%w: namespace %q does not match %q", coreError.ErrInvalidResource, obj.GetNamespace(), namespace)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,358 @@
+package kubernetes
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"strings"
+
+	coreError "opscore/error"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	pgparmor "github.com/ProtonMail/go-crypto/openpgp/armor"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Secret 处理方式
+const (
+	SecretPolicyInclude = "include" // 原样保留
+	SecretPolicyRedact  = "redact"  // 保留键，清空值
+	SecretPolicyEncrypt = "encrypt" // 使用公钥加密值
+	SecretPolicySkip    = "skip"    // 不导出、不迁移任何 Secret
+)
+
+// Secret 加密方式
+const (
+	SecretEncryptionAge    = "age"    // 值替换为 age 加密后的 ASCII armor，使用 age -d 解密
+	SecretEncryptionPGP    = "pgp"    // 值替换为 PGP 加密后的 ASCII armor，使用 gpg -d 解密
+	SecretEncryptionSealed = "sealed" // 转换为 SealedSecret，由集群中的 sealed-secrets 控制器解密
+)
+
+const (
+	secretPolicyAnnotation = "opscore.io/secret-policy"
+	sealedSecretAPIVersion = "bitnami.com/v1alpha1"
+)
+
+// systemSecretTypes 由集群组件生成、不应跨集群复制的 Secret 类型
+var systemSecretTypes = sets.New(
+	"kubernetes.io/service-account-token",
+	"bootstrap.kubernetes.io/token",
+	"helm.sh/release.v1",
+)
+
+// SecretPolicy 导出和迁移时 Secret 的处理策略。默认原样保留，但跳过系统类型的 Secret
+type SecretPolicy struct {
+	Mode               string `json:"mode"`               // include、redact、encrypt、skip，默认 include
+	IncludeSystemTypes bool   `json:"includeSystemTypes"` // 是否保留 ServiceAccount 令牌、Helm release 等系统类型
+	Encryption         string `json:"encryption"`         // Mode 为 encrypt 时的加密方式：age、pgp、sealed
+	PublicKey          string `json:"publicKey"`          // age 接收者（可多个，换行分隔）、PGP 公钥或 sealed-secrets 证书（PEM）
+}
+
+// secretEncryptor 将单个值加密，label 为 namespace/name，仅 sealed 方式使用
+type secretEncryptor func(plaintext []byte, label string) ([]byte, error)
+
+// compiledSecretPolicy 已解析公钥的策略
+type compiledSecretPolicy struct {
+	SecretPolicy
+	encrypt secretEncryptor
+}
+
+// compile 校验策略并解析公钥。forMigration 为 true 时只允许目标集群能直接使用的方式
+func (p *SecretPolicy) compile(forMigration bool) (*compiledSecretPolicy, error) {
+	policy := SecretPolicy{Mode: SecretPolicyInclude}
+	if p != nil {
+		policy = *p
+	}
+	if policy.Mode == "" {
+		policy.Mode = SecretPolicyInclude
+	}
+	compiled := &compiledSecretPolicy{SecretPolicy: policy}
+
+	switch policy.Mode {
+	case SecretPolicyInclude, SecretPolicyRedact, SecretPolicySkip:
+		return compiled, nil
+	case SecretPolicyEncrypt:
+	default:
+		return nil, fmt.Errorf("%w: unsupported secret policy %q", coreError.ErrInvalidResource, policy.Mode)
+	}
+
+	if strings.TrimSpace(policy.PublicKey) == "" {
+		return nil, fmt.Errorf("%w: publicKey is required for secret encryption", coreError.ErrInvalidResource)
+	}
+	var err error
+	switch policy.Encryption {
+	case SecretEncryptionAge:
+		compiled.encrypt, err = ageEncryptor(policy.PublicKey)
+	case SecretEncryptionPGP:
+		compiled.encrypt, err = pgpEncryptor(policy.PublicKey)
+	case SecretEncryptionSealed:
+		compiled.encrypt, err = sealedEncryptor(policy.PublicKey)
+	default:
+		return nil, fmt.Errorf("%w: unsupported secret encryption %q", coreError.ErrInvalidResource, policy.Encryption)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s public key: %v", coreError.ErrInvalidResource, policy.Encryption, err)
+	}
+	// age、pgp 加密后的 Secret 在目标集群中无法使用，迁移只支持 sealed
+	if forMigration && policy.Encryption != SecretEncryptionSealed {
+		return nil, fmt.Errorf("%w: migration only supports sealed secret encryption", coreError.ErrInvalidResource)
+	}
+	return compiled, nil
+}
+
+// apply 对 Secret 应用策略，返回 nil 表示跳过该对象。
+// sealed 加密时返回 SealedSecret 对象，其余情况返回原对象
+func (p *compiledSecretPolicy) apply(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if p.Mode == SecretPolicySkip {
+		return nil, nil
+	}
+	secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+	if !p.IncludeSystemTypes && systemSecretTypes.Has(secretType) {
+		return nil, nil
+	}
+
+	switch p.Mode {
+	case SecretPolicyRedact:
+		data, _, _ := unstructured.NestedMap(obj.Object, "data")
+		for key := range data {
+			data[key] = ""
+		}
+		if data != nil {
+			_ = unstructured.SetNestedMap(obj.Object, data, "data")
+		}
+		unstructured.RemoveNestedField(obj.Object, "stringData")
+		setAnnotation(obj, secretPolicyAnnotation, SecretPolicyRedact)
+	case SecretPolicyEncrypt:
+		data, err := secretData(obj)
+		if err != nil {
+			return nil, err
+		}
+		label := obj.GetNamespace() + "/" + obj.GetName()
+		encrypted := make(map[string]interface{}, len(data))
+		for key, value := range data {
+			ciphertext, err := p.encrypt(value, label)
+			if err != nil {
+				return nil, fmt.Errorf("加密Secret %s 的 %s 失败: %w", label, key, err)
+			}
+			encrypted[key] = base64.StdEncoding.EncodeToString(ciphertext)
+		}
+		if p.Encryption == SecretEncryptionSealed {
+			return sealedSecret(obj, secretType, encrypted), nil
+		}
+		_ = unstructured.SetNestedMap(obj.Object, encrypted, "data")
+		unstructured.RemoveNestedField(obj.Object, "stringData")
+		setAnnotation(obj, secretPolicyAnnotation, SecretPolicyEncrypt+"/"+p.Encryption)
+	}
+	return obj, nil
+}
+
+// secretData 解码 data 以及 stringData 中的值
+func secretData(obj *unstructured.Unstructured) (map[string][]byte, error) {
+	result := map[string][]byte{}
+	data, _, _ := unstructured.NestedStringMap(obj.Object, "data")
+	for key, value := range data {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("Secret %s 的 %s 不是合法的 base64: %w", obj.GetName(), key, err)
+		}
+		result[key] = decoded
+	}
+	stringData, _, _ := unstructured.NestedStringMap(obj.Object, "stringData")
+	for key, value := range stringData {
+		result[key] = []byte(value)
+	}
+	return result, nil
+}
+
+// sealedSecret 构造 SealedSecret，模板保留原 Secret 的类型、标签和注解
+func sealedSecret(secret *unstructured.Unstructured, secretType string, encrypted map[string]interface{}) *unstructured.Unstructured {
+	templateMeta := map[string]interface{}{}
+	if labels := secret.GetLabels(); len(labels) > 0 {
+		templateMeta["labels"] = stringMapToInterface(labels)
+	}
+	if annotations := secret.GetAnnotations(); len(annotations) > 0 {
+		templateMeta["annotations"] = stringMapToInterface(annotations)
+	}
+	template := map[string]interface{}{"metadata": templateMeta}
+	if secretType != "" {
+		template["type"] = secretType
+	}
+
+	sealed := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": sealedSecretAPIVersion,
+		"kind":       "SealedSecret",
+		"metadata": map[string]interface{}{
+			"name": secret.GetName(),
+		},
+		"spec": map[string]interface{}{
+			"encryptedData": encrypted,
+			"template":      template,
+		},
+	}}
+	if secret.GetNamespace() != "" {
+		sealed.SetNamespace(secret.GetNamespace())
+	}
+	return sealed
+}
+
+func stringMapToInterface(m map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
+
+func setAnnotation(obj *unstructured.Unstructured, key, value string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[key] = value
+	obj.SetAnnotations(annotations)
+}
+
+func ageEncryptor(publicKey string) (secretEncryptor, error) {
+	recipients, err := age.ParseRecipients(strings.NewReader(publicKey))
+	if err != nil {
+		return nil, err
+	}
+	return func(plaintext []byte, _ string) ([]byte, error) {
+		var buffer bytes.Buffer
+		armorWriter := armor.NewWriter(&buffer)
+		w, err := age.Encrypt(armorWriter, recipients...)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(plaintext); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		if err := armorWriter.Close(); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	}, nil
+}
+
+func pgpEncryptor(publicKey string) (secretEncryptor, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(publicKey))
+	if err != nil {
+		return nil, err
+	}
+	return func(plaintext []byte, _ string) ([]byte, error) {
+		var buffer bytes.Buffer
+		armorWriter, err := pgparmor.Encode(&buffer, "PGP MESSAGE", nil)
+		if err != nil {
+			return nil, err
+		}
+		w, err := openpgp.Encrypt(armorWriter, entities, nil, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(plaintext); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		if err := armorWriter.Close(); err != nil {
+			return nil, err
+		}
+		return buffer.Bytes(), nil
+	}, nil
+}
+
+// sealedEncryptor 使用 sealed-secrets 控制器的证书或 RSA 公钥，按其 strict 作用域格式加密
+func sealedEncryptor(publicKey string) (secretEncryptor, error) {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	var pub interface{}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		pub = cert.PublicKey
+	default:
+		var err error
+		if pub, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
+			if pub, err = x509.ParsePKCS1PublicKey(block.Bytes); err != nil {
+				return nil, err
+			}
+		}
+	}
+	rsaKey, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not RSA")
+	}
+	return func(plaintext []byte, label string) ([]byte, error) {
+		return hybridEncrypt(rand.Reader, rsaKey, plaintext, []byte(label))
+	}, nil
+}
+
+// hybridEncrypt 与 sealed-secrets 相同的混合加密：随机会话密钥经 RSA-OAEP 加密，
+// 数据使用 AES-256-GCM 加密，输出为 2 字节长度前缀 + RSA 密文 + GCM 密文
+func hybridEncrypt(rnd io.Reader, pub *rsa.PublicKey, plaintext, label []byte) ([]byte, error) {
+	sessionKey := make([]byte, 32)
+	if _, err := io.ReadFull(rnd, sessionKey); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	rsaCiphertext, err := rsa.EncryptOAEP(sha256.New(), rnd, pub, sessionKey, label)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := make([]byte, 2, 2+len(rsaCiphertext)+len(plaintext)+aead.Overhead())
+	binary.BigEndian.PutUint16(ciphertext, uint16(len(rsaCiphertext)))
+	ciphertext = append(ciphertext, rsaCiphertext...)
+	// 会话密钥只使用一次，因此可以使用全零 nonce
+	nonce := make([]byte, aead.NonceSize())
+	return aead.Seal(ciphertext, nonce, plaintext, nil), nil
+}
+
+// applySecretPolicyToCollected 对收集结果中的 Secret 应用策略
+func applySecretPolicyToCollected(collected []CollectedResources, policy *compiledSecretPolicy) ([]CollectedResources, error) {
+	for i := range collected {
+		gk := collected[i].Mapping.GroupVersionKind.GroupKind()
+		if gk.Group != "" || gk.Kind != "Secret" {
+			continue
+		}
+		kept := collected[i].Objects[:0]
+		for _, obj := range collected[i].Objects {
+			result, err := policy.apply(obj)
+			if err != nil {
+				return nil, err
+			}
+			if result != nil {
+				kept = append(kept, result)
+			}
+		}
+		collected[i].Objects = kept
+	}
+	return collected, nil
+}
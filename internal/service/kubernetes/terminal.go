@@ -0,0 +1,237 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"opscore/config"
+	coreError "opscore/error"
+	"opscore/internal/db"
+	"opscore/internal/log"
+	"opscore/internal/model"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	defaultTerminalIdleTimeout  = 10 * time.Minute
+	defaultTerminalRecordingDir = "./data/terminal-recordings"
+	// ShellAuto 优先使用 bash，不存在时回退到 sh
+	ShellAuto = "auto"
+)
+
+// allowedShells 可选的 shell，终端只允许启动交互式 shell 而不是任意命令
+var allowedShells = map[string][]string{
+	ShellAuto: {"/bin/sh", "-c", "if command -v bash >/dev/null 2>&1; then exec bash; else exec sh; fi"},
+	"bash":    {"bash"},
+	"sh":      {"sh"},
+	"ash":     {"ash"},
+	"zsh":     {"zsh"},
+}
+
+// TerminalOptions 打开 Pod 终端的参数
+type TerminalOptions struct {
+	Namespace string
+	Pod       string
+	Container string // 为空时使用 Pod 的第一个容器
+	Shell     string // bash、sh、ash、zsh 或 auto，默认 auto
+	Actor     OperationActor
+	Width     uint16
+	Height    uint16
+}
+
+// TerminalIO 终端的输入输出及窗口大小变化来源
+type TerminalIO interface {
+	io.Reader
+	io.Writer
+	remotecommand.TerminalSizeQueue
+}
+
+// TerminalSession 一次终端会话，包含审计记录和录像
+type TerminalSession struct {
+	Record   *model.K8sTerminalSession
+	Recorder *AsciicastRecorder
+	command  []string
+}
+
+// TerminalIdleTimeout 终端无输入的超时时间
+func TerminalIdleTimeout() time.Duration {
+	if sec := config.GetConfig().Kubernetes.Terminal.IdleTimeoutSec; sec > 0 {
+		return time.Duration(sec) * time.Second
+	}
+	return defaultTerminalIdleTimeout
+}
+
+func terminalRecordingDir() string {
+	if dir := config.GetConfig().Kubernetes.Terminal.RecordingDir; dir != "" {
+		return dir
+	}
+	return defaultTerminalRecordingDir
+}
+
+// StartTerminalSession 校验 Pod 与容器，创建会话审计记录和 asciicast 录像文件
+func StartTerminalSession(clusterID string, opts TerminalOptions) (*TerminalSession, error) {
+	logger := log.GetLogger()
+	if opts.Shell == "" {
+		opts.Shell = ShellAuto
+	}
+	command, ok := allowedShells[opts.Shell]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported shell %q", coreError.ErrInvalidResource, opts.Shell)
+	}
+
+	cluster, err := GetClusterByClusterID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	pod, err := clients.Clientset.CoreV1().Pods(opts.Namespace).Get(context.TODO(), opts.Pod, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if opts.Container == "" && len(pod.Spec.Containers) > 0 {
+		opts.Container = pod.Spec.Containers[0].Name
+	}
+	if !hasContainer(pod, opts.Container) {
+		return nil, fmt.Errorf("%w: container %q not found in pod %s", coreError.ErrInvalidResource, opts.Container, opts.Pod)
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return nil, fmt.Errorf("%w: pod %s is %s", coreError.ErrInvalidResource, opts.Pod, pod.Status.Phase)
+	}
+
+	sessionID := uuid.New().String()
+	recordingPath := filepath.Join(terminalRecordingDir(), cluster.ClusterID, sessionID+".cast")
+	title := fmt.Sprintf("%s/%s/%s/%s", cluster.ClusterName, opts.Namespace, opts.Pod, opts.Container)
+	recorder, err := NewAsciicastRecorder(recordingPath, opts.Width, opts.Height, title, opts.Shell)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &model.K8sTerminalSession{
+		SessionID:     sessionID,
+		ClusterID:     cluster.ClusterID,
+		Namespace:     opts.Namespace,
+		Pod:           opts.Pod,
+		Container:     opts.Container,
+		Command:       strings.Join(command, " "),
+		User:          opts.Actor.User,
+		ClientIP:      opts.Actor.ClientIP,
+		RecordingPath: recordingPath,
+		StartedAt:     time.Now(),
+	}
+	if err := db.DBInstance.DB.Create(record).Error; err != nil {
+		recorder.Close()
+		return nil, err
+	}
+
+	logger.Info("打开Pod终端", zap.String("sessionID", sessionID), zap.String("clusterID", cluster.ClusterID),
+		zap.String("namespace", opts.Namespace), zap.String("pod", opts.Pod), zap.String("container", opts.Container),
+		zap.String("user", opts.Actor.User), zap.String("clientIP", opts.Actor.ClientIP))
+	return &TerminalSession{Record: record, Recorder: recorder, command: command}, nil
+}
+
+// Exec 通过 SPDY 在容器中启动带 TTY 的 shell，直到 shell 退出、ctx 取消或输入流结束
+func (s *TerminalSession) Exec(ctx context.Context, tio TerminalIO) error {
+	clients, err := GetClusterClients(s.Record.ClusterID)
+	if err != nil {
+		return err
+	}
+	req := clients.Clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(s.Record.Namespace).
+		Name(s.Record.Pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: s.Record.Container,
+			Command:   s.command,
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    false, // TTY 模式下 stderr 合并到 stdout
+			TTY:       true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(clients.RESTConfig, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to create executor: %w", err)
+	}
+	return executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:             tio,
+		Stdout:            tio,
+		Tty:               true,
+		TerminalSizeQueue: tio,
+	})
+}
+
+// Finish 关闭录像并记录会话结束时间和原因
+func (s *TerminalSession) Finish(reason string) {
+	logger := log.GetLogger()
+	if err := s.Recorder.Close(); err != nil {
+		logger.Error("关闭终端录像失败", zap.String("sessionID", s.Record.SessionID), zap.Error(err))
+	}
+	now := time.Now()
+	if err := db.DBInstance.DB.Model(s.Record).Updates(map[string]interface{}{
+		"ended_at":    now,
+		"exit_reason": reason,
+	}).Error; err != nil {
+		logger.Error("更新终端会话记录失败", zap.String("sessionID", s.Record.SessionID), zap.Error(err))
+	}
+	logger.Info("关闭Pod终端", zap.String("sessionID", s.Record.SessionID), zap.String("reason", reason),
+		zap.Duration("duration", now.Sub(s.Record.StartedAt)))
+}
+
+// ListTerminalSessions 按开始时间倒序列出集群的终端会话
+func ListTerminalSessions(clusterID string, limit int) ([]model.K8sTerminalSession, error) {
+	cluster, err := GetClusterByClusterID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []model.K8sTerminalSession
+	query := db.DBInstance.DB.Where("cluster_id = ?", cluster.ClusterID).Order("started_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// GetTerminalSession 获取终端会话记录
+func GetTerminalSession(clusterID, sessionID string) (*model.K8sTerminalSession, error) {
+	cluster, err := GetClusterByClusterID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	var session model.K8sTerminalSession
+	err = db.DBInstance.DB.Where("cluster_id = ? AND session_id = ?", cluster.ClusterID, sessionID).First(&session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: terminal session %s", coreError.ErrTerminalSessionNotFound, sessionID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func hasContainer(pod *corev1.Pod, name string) bool {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
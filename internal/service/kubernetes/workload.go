@@ -0,0 +1,474 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	coreError "opscore/error"
+	"opscore/internal/db"
+	"opscore/internal/log"
+	"opscore/internal/model"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+)
+
+// 工作负载操作类型
+const (
+	WorkloadActionScale    = "scale"
+	WorkloadActionRestart  = "restart"
+	WorkloadActionRollback = "rollback"
+	WorkloadActionSetImage = "set-image"
+)
+
+const (
+	// restartedAtAnnotation 与 kubectl rollout restart 使用相同的注解
+	restartedAtAnnotation  = "kubectl.kubernetes.io/restartedAt"
+	deploymentRevisionKey  = "deployment.kubernetes.io/revision"
+	changeCauseAnnotation  = "kubernetes.io/change-cause"
+	podTemplateHashLabel   = "pod-template-hash"
+	defaultOperationsLimit = 50
+)
+
+// workloadKinds 支持操作的工作负载类型
+var workloadKinds = map[string]bool{"Deployment": true, "StatefulSet": true, "DaemonSet": true}
+
+// OperationActor 发起操作的用户，写入审计记录
+type OperationActor struct {
+	User     string
+	ClientIP string
+}
+
+// WorkloadRevision 工作负载的一个历史版本，Deployment 对应 ReplicaSet，StatefulSet 和 DaemonSet 对应 ControllerRevision
+type WorkloadRevision struct {
+	Revision    int64     `json:"revision"`
+	Name        string    `json:"name"`
+	Images      []string  `json:"images"`
+	ChangeCause string    `json:"changeCause,omitempty"`
+	Current     bool      `json:"current"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// workloadTarget 已解析的工作负载
+type workloadTarget struct {
+	clients   *ClusterClients
+	clusterID string // 数据库中的集群 UUID
+	mapping   *meta.RESTMapping
+	namespace string
+	name      string
+}
+
+func resolveWorkload(clusterID, resourceType, namespace, name string) (*workloadTarget, error) {
+	cluster, err := GetClusterByClusterID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := clients.ResolveResource(resourceType)
+	if err != nil {
+		return nil, err
+	}
+	if !workloadKinds[mapping.GroupVersionKind.Kind] {
+		return nil, fmt.Errorf("%w: %s does not support workload operations", coreError.ErrInvalidResource, mapping.GroupVersionKind.Kind)
+	}
+	return &workloadTarget{clients: clients, clusterID: cluster.ClusterID, mapping: mapping, namespace: namespace, name: name}, nil
+}
+
+func (w *workloadTarget) kind() string {
+	return w.mapping.GroupVersionKind.Kind
+}
+
+func (w *workloadTarget) get() (*unstructured.Unstructured, error) {
+	return w.clients.Dynamic.Resource(w.mapping.Resource).Namespace(w.namespace).Get(context.TODO(), w.name, metav1.GetOptions{})
+}
+
+// patch 对工作负载打补丁，记录操作前后 spec 的差异并写入审计记录。
+// buildPatch 根据当前对象生成补丁，返回的错误直接作为操作失败返回
+func (w *workloadTarget) patch(action string, params interface{}, actor OperationActor,
+	buildPatch func(obj *unstructured.Unstructured) (types.PatchType, []byte, error)) (*model.K8sWorkloadOperation, error) {
+	logger := log.GetLogger()
+	paramsJSON, _ := json.Marshal(params)
+	record := &model.K8sWorkloadOperation{
+		ClusterID: w.clusterID,
+		Namespace: w.namespace,
+		Kind:      w.kind(),
+		Name:      w.name,
+		Action:    action,
+		Params:    string(paramsJSON),
+		User:      actor.User,
+		ClientIP:  actor.ClientIP,
+	}
+
+	before, err := w.get()
+	if err != nil {
+		return nil, err
+	}
+	after, err := func() (*unstructured.Unstructured, error) {
+		patchType, data, err := buildPatch(before)
+		if err != nil {
+			return nil, err
+		}
+		return w.clients.Dynamic.Resource(w.mapping.Resource).Namespace(w.namespace).Patch(context.TODO(), w.name, patchType, data, metav1.PatchOptions{})
+	}()
+	if err != nil {
+		record.Error = err.Error()
+	} else {
+		record.Success = true
+		record.Diff = specDiff(before, after)
+	}
+
+	if dbErr := db.DBInstance.DB.Create(record).Error; dbErr != nil {
+		logger.Error("保存工作负载操作记录失败", zap.Error(dbErr), zap.String("action", action), zap.String("name", w.name))
+	}
+	logger.Info("工作负载操作", zap.String("clusterID", w.clusterID), zap.String("kind", w.kind()), zap.String("namespace", w.namespace),
+		zap.String("name", w.name), zap.String("action", action), zap.String("user", actor.User), zap.Bool("success", record.Success))
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// specDiff 生成操作前后 spec 的 unified diff
+func specDiff(before, after *unstructured.Unstructured) string {
+	a, _ := yaml.Marshal(before.Object["spec"])
+	b, _ := yaml.Marshal(after.Object["spec"])
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: "before/spec",
+		ToFile:   "after/spec",
+		Context:  3,
+	})
+	return diff
+}
+
+// ScaleWorkload 调整 Deployment 或 StatefulSet 的副本数
+func ScaleWorkload(clusterID, resourceType, namespace, name string, replicas int32, actor OperationActor) (*model.K8sWorkloadOperation, error) {
+	w, err := resolveWorkload(clusterID, resourceType, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if w.kind() == "DaemonSet" {
+		return nil, fmt.Errorf("%w: DaemonSet cannot be scaled", coreError.ErrInvalidResource)
+	}
+	if replicas < 0 {
+		return nil, fmt.Errorf("%w: replicas must not be negative", coreError.ErrInvalidResource)
+	}
+	return w.patch(WorkloadActionScale, map[string]interface{}{"replicas": replicas}, actor,
+		func(*unstructured.Unstructured) (types.PatchType, []byte, error) {
+			data, err := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"replicas": replicas}})
+			return types.MergePatchType, data, err
+		})
+}
+
+// RestartWorkload 通过修改 Pod 模板注解触发滚动重启，与 kubectl rollout restart 相同
+func RestartWorkload(clusterID, resourceType, namespace, name string, actor OperationActor) (*model.K8sWorkloadOperation, error) {
+	w, err := resolveWorkload(clusterID, resourceType, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().Format(time.RFC3339)
+	return w.patch(WorkloadActionRestart, map[string]interface{}{"restartedAt": now}, actor,
+		func(*unstructured.Unstructured) (types.PatchType, []byte, error) {
+			data, err := json.Marshal(map[string]interface{}{
+				"spec": map[string]interface{}{
+					"template": map[string]interface{}{
+						"metadata": map[string]interface{}{
+							"annotations": map[string]interface{}{restartedAtAnnotation: now},
+						},
+					},
+				},
+			})
+			return types.MergePatchType, data, err
+		})
+}
+
+// SetWorkloadImage 修改容器镜像，容器可以是普通容器或 init 容器
+func SetWorkloadImage(clusterID, resourceType, namespace, name, container, image string, actor OperationActor) (*model.K8sWorkloadOperation, error) {
+	w, err := resolveWorkload(clusterID, resourceType, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if container == "" || image == "" {
+		return nil, fmt.Errorf("%w: container and image are required", coreError.ErrInvalidResource)
+	}
+	params := map[string]interface{}{"container": container, "image": image}
+	return w.patch(WorkloadActionSetImage, params, actor, func(obj *unstructured.Unstructured) (types.PatchType, []byte, error) {
+		for _, field := range []string{"containers", "initContainers"} {
+			containers, _, _ := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", field)
+			for _, c := range containers {
+				if m, ok := c.(map[string]interface{}); ok && m["name"] == container {
+					data, err := json.Marshal(map[string]interface{}{
+						"spec": map[string]interface{}{
+							"template": map[string]interface{}{
+								"spec": map[string]interface{}{
+									field: []interface{}{map[string]interface{}{"name": container, "image": image}},
+								},
+							},
+						},
+					})
+					return types.StrategicMergePatchType, data, err
+				}
+			}
+		}
+		return "", nil, fmt.Errorf("%w: container %q not found in %s %s", coreError.ErrInvalidResource, container, w.kind(), w.name)
+	})
+}
+
+// ListWorkloadRevisions 列出工作负载的历史版本，按版本号倒序
+func ListWorkloadRevisions(clusterID, resourceType, namespace, name string) ([]WorkloadRevision, error) {
+	w, err := resolveWorkload(clusterID, resourceType, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	revisions, _, err := w.revisions()
+	if err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+// RollbackWorkload 回滚到指定版本，revision 为 0 时回滚到上一个版本
+func RollbackWorkload(clusterID, resourceType, namespace, name string, revision int64, actor OperationActor) (*model.K8sWorkloadOperation, error) {
+	w, err := resolveWorkload(clusterID, resourceType, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	revisions, patches, err := w.revisions()
+	if err != nil {
+		return nil, err
+	}
+
+	target := findRollbackTarget(revisions, revision)
+	if target == nil {
+		if revision == 0 {
+			return nil, fmt.Errorf("%w: no previous revision of %s %s", coreError.ErrRevisionNotFound, w.kind(), name)
+		}
+		return nil, fmt.Errorf("%w: revision %d of %s %s", coreError.ErrRevisionNotFound, revision, w.kind(), name)
+	}
+	if target.Current {
+		return nil, fmt.Errorf("%w: revision %d is already the current revision", coreError.ErrInvalidResource, target.Revision)
+	}
+
+	params := map[string]interface{}{"revision": target.Revision, "from": target.Name}
+	return w.patch(WorkloadActionRollback, params, actor, func(*unstructured.Unstructured) (types.PatchType, []byte, error) {
+		p := patches[target.Revision]
+		return p.patchType, p.data, nil
+	})
+}
+
+// findRollbackTarget 查找回滚目标，revision 为 0 时返回当前版本之前最新的版本
+func findRollbackTarget(revisions []WorkloadRevision, revision int64) *WorkloadRevision {
+	if revision != 0 {
+		for i := range revisions {
+			if revisions[i].Revision == revision {
+				return &revisions[i]
+			}
+		}
+		return nil
+	}
+	var current int64
+	for _, r := range revisions {
+		if r.Current {
+			current = r.Revision
+		}
+	}
+	// revisions 按版本号倒序
+	for i := range revisions {
+		if revisions[i].Revision < current {
+			return &revisions[i]
+		}
+	}
+	return nil
+}
+
+// revisionPatch 回滚到某个版本所需的补丁
+type revisionPatch struct {
+	patchType types.PatchType
+	data      []byte
+}
+
+// revisions 返回历史版本以及每个版本对应的回滚补丁
+func (w *workloadTarget) revisions() ([]WorkloadRevision, map[int64]revisionPatch, error) {
+	ctx := context.TODO()
+	apps := w.clients.Clientset.AppsV1()
+	var (
+		revisions []WorkloadRevision
+		patches   = map[int64]revisionPatch{}
+	)
+
+	switch w.kind() {
+	case "Deployment":
+		deploy, err := apps.Deployments(w.namespace).Get(ctx, w.name, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		rsList, err := apps.ReplicaSets(w.namespace).List(ctx, metav1.ListOptions{LabelSelector: metav1.FormatLabelSelector(deploy.Spec.Selector)})
+		if err != nil {
+			return nil, nil, err
+		}
+		current := deploy.Annotations[deploymentRevisionKey]
+		for i := range rsList.Items {
+			rs := &rsList.Items[i]
+			if !isOwnedBy(rs.OwnerReferences, deploy.UID) {
+				continue
+			}
+			n, err := strconv.ParseInt(rs.Annotations[deploymentRevisionKey], 10, 64)
+			if err != nil {
+				continue
+			}
+			revisions = append(revisions, WorkloadRevision{
+				Revision:    n,
+				Name:        rs.Name,
+				Images:      containerImages(rs.Spec.Template.Spec.Containers),
+				ChangeCause: rs.Annotations[changeCauseAnnotation],
+				Current:     rs.Annotations[deploymentRevisionKey] == current,
+				CreatedAt:   rs.CreationTimestamp.Time,
+			})
+			data, err := deploymentRollbackPatch(rs)
+			if err != nil {
+				return nil, nil, err
+			}
+			patches[n] = revisionPatch{patchType: types.JSONPatchType, data: data}
+		}
+	case "StatefulSet", "DaemonSet":
+		var (
+			uid             types.UID
+			selector        *metav1.LabelSelector
+			currentRevision string
+		)
+		if w.kind() == "StatefulSet" {
+			sts, err := apps.StatefulSets(w.namespace).Get(ctx, w.name, metav1.GetOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			uid, selector, currentRevision = sts.UID, sts.Spec.Selector, sts.Status.UpdateRevision
+		} else {
+			ds, err := apps.DaemonSets(w.namespace).Get(ctx, w.name, metav1.GetOptions{})
+			if err != nil {
+				return nil, nil, err
+			}
+			uid, selector = ds.UID, ds.Spec.Selector
+		}
+		crList, err := apps.ControllerRevisions(w.namespace).List(ctx, metav1.ListOptions{LabelSelector: metav1.FormatLabelSelector(selector)})
+		if err != nil {
+			return nil, nil, err
+		}
+		var latest int64
+		for i := range crList.Items {
+			cr := &crList.Items[i]
+			if !isOwnedBy(cr.OwnerReferences, uid) {
+				continue
+			}
+			revisions = append(revisions, WorkloadRevision{
+				Revision:    cr.Revision,
+				Name:        cr.Name,
+				Images:      controllerRevisionImages(cr),
+				ChangeCause: cr.Annotations[changeCauseAnnotation],
+				Current:     cr.Name == currentRevision,
+				CreatedAt:   cr.CreationTimestamp.Time,
+			})
+			// ControllerRevision 的 data 本身就是 Pod 模板的 strategic merge patch
+			patches[cr.Revision] = revisionPatch{patchType: types.StrategicMergePatchType, data: cr.Data.Raw}
+			if cr.Revision > latest {
+				latest = cr.Revision
+			}
+		}
+		// DaemonSet 的状态中没有当前版本名，版本号最大的即为当前版本
+		if currentRevision == "" {
+			for i := range revisions {
+				revisions[i].Current = revisions[i].Revision == latest
+			}
+		}
+	}
+
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Revision > revisions[j].Revision })
+	return revisions, patches, nil
+}
+
+// deploymentRollbackPatch 用 ReplicaSet 的 Pod 模板整体替换 Deployment 的模板，与 kubectl rollout undo 相同
+func deploymentRollbackPatch(rs *appsv1.ReplicaSet) ([]byte, error) {
+	template := rs.Spec.Template.DeepCopy()
+	delete(template.Labels, podTemplateHashLabel)
+	return json.Marshal([]map[string]interface{}{
+		{"op": "replace", "path": "/spec/template", "value": template},
+	})
+}
+
+func containerImages(containers []corev1.Container) []string {
+	images := make([]string, 0, len(containers))
+	for _, c := range containers {
+		images = append(images, c.Image)
+	}
+	return images
+}
+
+func controllerRevisionImages(cr *appsv1.ControllerRevision) []string {
+	var data map[string]interface{}
+	if err := json.Unmarshal(cr.Data.Raw, &data); err != nil {
+		return nil
+	}
+	containers, _, _ := unstructured.NestedSlice(data, "spec", "template", "spec", "containers")
+	images := make([]string, 0, len(containers))
+	for _, c := range containers {
+		if m, ok := c.(map[string]interface{}); ok {
+			if image, ok := m["image"].(string); ok {
+				images = append(images, image)
+			}
+		}
+	}
+	return images
+}
+
+// ListWorkloadOperations 按时间倒序列出工作负载操作记录，namespace、kind、name 为空时不过滤
+func ListWorkloadOperations(clusterID, namespace, kind, name string, limit int) ([]model.K8sWorkloadOperation, error) {
+	cluster, err := GetClusterByClusterID(clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultOperationsLimit
+	}
+	query := db.DBInstance.DB.Where("cluster_id = ?", cluster.ClusterID)
+	if namespace != "" {
+		query = query.Where("namespace = ?", namespace)
+	}
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	var operations []model.K8sWorkloadOperation
+	if err := query.Order("created_at DESC").Limit(limit).Find(&operations).Error; err != nil {
+		return nil, err
+	}
+	return operations, nil
+}
+
+// WorkloadKind 将资源类型解析为工作负载的 Kind，用于按对象过滤操作记录
+func WorkloadKind(clusterID, resourceType string) (string, error) {
+	clients, err := GetClusterClients(clusterID)
+	if err != nil {
+		return "", err
+	}
+	mapping, err := clients.ResolveResource(resourceType)
+	if err != nil {
+		return "", err
+	}
+	return mapping.GroupVersionKind.Kind, nil
+}
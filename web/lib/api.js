@@ -602,4 +602,31 @@ export const datamigrateAPI = {
       method: "POST",
       body: JSON.stringify(data),
     }),
+
+  // 已保存的数据源连接
+  listConnections: () => fetchAPI("/datamigrate/connections"),
+
+  getConnection: (connectionId) => fetchAPI(`/datamigrate/connections/${connectionId}`),
+
+  createConnection: (connection) =>
+    fetchAPI("/datamigrate/connections", {
+      method: "POST",
+      body: JSON.stringify(connection),
+    }),
+
+  updateConnection: (connectionId, connection) =>
+    fetchAPI(`/datamigrate/connections/${connectionId}`, {
+      method: "PUT",
+      body: JSON.stringify(connection),
+    }),
+
+  deleteConnection: (connectionId) =>
+    fetchAPI(`/datamigrate/connections/${connectionId}`, {
+      method: "DELETE",
+    }),
+
+  testSavedConnection: (connectionId) =>
+    fetchAPI(`/datamigrate/connections/${connectionId}/test`, {
+      method: "POST",
+    }),
 };